@@ -0,0 +1,205 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applyset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	kubectlapply "sigs.k8s.io/kubebuilder-declarative-pattern/applylib/forked/github.com/kubernetes/kubectl/pkg/cmd/apply"
+)
+
+// AdoptedSelectorAnnotation on the parent records the adopt selector whose objects were adopted into the applyset,
+// so that adoption only runs on the first apply with that selector.
+const AdoptedSelectorAnnotation = "addons.k8s.io/adopted-selector"
+
+// DefaultAdoptGroupKinds are the kinds searched for objects to adopt, if Options.AdoptGroupKinds is not set.
+// They match the kinds that kubectl apply --prune considers by default; the kinds of the desired objects are always searched.
+var DefaultAdoptGroupKinds = []schema.GroupKind{
+	{Group: "", Kind: "ConfigMap"},
+	{Group: "", Kind: "Endpoints"},
+	{Group: "", Kind: "Namespace"},
+	{Group: "", Kind: "PersistentVolumeClaim"},
+	{Group: "", Kind: "PersistentVolume"},
+	{Group: "", Kind: "Pod"},
+	{Group: "", Kind: "ReplicationController"},
+	{Group: "", Kind: "Secret"},
+	{Group: "", Kind: "Service"},
+	{Group: "batch", Kind: "Job"},
+	{Group: "batch", Kind: "CronJob"},
+	{Group: "networking.k8s.io", Kind: "Ingress"},
+	{Group: "apps", Kind: "DaemonSet"},
+	{Group: "apps", Kind: "Deployment"},
+	{Group: "apps", Kind: "ReplicaSet"},
+	{Group: "apps", Kind: "StatefulSet"},
+}
+
+// adoptCandidate is a live object that was managed with a label selector, and will be added to the applyset.
+type adoptCandidate struct {
+	restMapping *meta.RESTMapping
+	object      *unstructured.Unstructured
+}
+
+// findObjectsToAdopt finds the objects that match the adopt selector but are not yet members of the applyset.
+//
+// Objects controlled by another object (eg the Pods of a ReplicaSet) are not adopted, unless they are controlled by the parent;
+// objects that are members of another applyset are never adopted.
+func (a *ApplySet) findObjectsToAdopt(ctx context.Context, trackers *objectTrackerList, restMappings map[schema.GroupVersionKind]restMappingResult) ([]adoptCandidate, error) {
+	groupKinds := a.adoptGroupKinds
+	if len(groupKinds) == 0 {
+		groupKinds = DefaultAdoptGroupKinds
+	}
+
+	mappings := make(map[schema.GroupKind]*meta.RESTMapping)
+	for _, gk := range groupKinds {
+		restMapping, err := a.restMapper.RESTMapping(gk)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				// The kind is not served by this cluster
+				continue
+			}
+			return nil, fmt.Errorf("error getting rest mapping for %v: %w", gk, err)
+		}
+		mappings[gk] = restMapping
+	}
+
+	namespaces := sets.New[string]()
+	for i := range trackers.items {
+		obj := trackers.items[i].desired
+		gvk := obj.GroupVersionKind()
+		if result := restMappings[gvk]; result.restMapping != nil {
+			mappings[gvk.GroupKind()] = result.restMapping
+		}
+		if ns := obj.GetNamespace(); ns != "" {
+			namespaces.Insert(ns)
+		}
+	}
+
+	// We also match the selector ourselves, in case the server ignores it
+	selector, err := labels.Parse(a.adoptSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid adopt selector %q: %w", a.adoptSelector, err)
+	}
+
+	var parentUID types.UID
+	if accessor, err := meta.Accessor(a.parent.GetSubject()); err == nil {
+		parentUID = accessor.GetUID()
+	}
+	parentGK := a.parent.RESTMapping().GroupVersionKind.GroupKind()
+
+	var candidates []adoptCandidate
+	for _, restMapping := range mappings {
+		var scopes []string
+		if restMapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			scopes = sets.List(namespaces)
+		} else {
+			scopes = []string{metav1.NamespaceNone}
+		}
+		for _, ns := range scopes {
+			list, err := a.client.Resource(restMapping.Resource).Namespace(ns).List(ctx, metav1.ListOptions{
+				LabelSelector: a.adoptSelector,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("error listing %v objects to adopt: %w", restMapping.GroupVersionKind, err)
+			}
+			for i := range list.Items {
+				obj := &list.Items[i]
+				if _, found := obj.GetLabels()[kubectlapply.ApplysetPartOfLabel]; found {
+					continue
+				}
+				if !selector.Matches(labels.Set(obj.GetLabels())) {
+					continue
+				}
+				if obj.GroupVersionKind().GroupKind() == parentGK && obj.GetName() == a.parent.Name() && obj.GetNamespace() == a.parent.Namespace() {
+					continue
+				}
+				if owner := metav1.GetControllerOf(obj); owner != nil && owner.UID != parentUID {
+					continue
+				}
+				candidates = append(candidates, adoptCandidate{restMapping: restMapping, object: obj})
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// needsAdoption is true if the adopt selector is set and its objects have not yet been adopted into the applyset.
+func (a *ApplySet) needsAdoption() bool {
+	if a.adoptSelector == "" {
+		return false
+	}
+	accessor, err := meta.Accessor(a.parent.GetSubject())
+	if err != nil {
+		return true
+	}
+	return accessor.GetAnnotations()[AdoptedSelectorAnnotation] != a.adoptSelector
+}
+
+// recordAdoption annotates the parent with the adopt selector, once its objects are adopted.
+func (a *ApplySet) recordAdoption(ctx context.Context) error {
+	accessor, err := meta.Accessor(a.parent.GetSubject())
+	if err != nil {
+		return err
+	}
+	annotations := accessor.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[AdoptedSelectorAnnotation] = a.adoptSelector
+	accessor.SetAnnotations(annotations)
+	if err := a.parentClient.Update(ctx, a.parent.GetSubject().(client.Object)); err != nil {
+		return fmt.Errorf("error recording adoption on parent: %w", err)
+	}
+	return nil
+}
+
+// adoptObjects adds the applyset membership label to the objects, recording them in the results.
+// The adopted objects are added to adoptedUids, so that they are not pruned by the same apply.
+func (a *ApplySet) adoptObjects(ctx context.Context, candidates []adoptCandidate, memberLabels map[string]string, adoptedUids sets.Set[types.UID], results *ApplyResults) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": memberLabels,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error building adopt patch: %w", err)
+	}
+
+	patchOptions := metav1.PatchOptions{FieldManager: a.patchOptions.FieldManager}
+	for _, candidate := range candidates {
+		obj := candidate.object
+		nn := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+		adopted, err := a.client.Resource(candidate.restMapping.Resource).Namespace(nn.Namespace).Patch(ctx, nn.Name, types.MergePatchType, patch, patchOptions)
+		if err != nil {
+			return fmt.Errorf("error adopting %v %v into applyset: %w", obj.GroupVersionKind(), nn, err)
+		}
+		adoptedUids.Insert(adopted.GetUID())
+		klog.Infof("adopted resource %v %v into applyset", obj.GroupVersionKind(), nn)
+		results.adopted()
+	}
+	return nil
+}
@@ -77,6 +77,26 @@ type ApplySet struct {
 
 	// Health callback
 	computeHealth ComputeHealthCallback
+
+	// prunePolicy guards against pruning objects by mistake
+	prunePolicy PrunePolicy
+
+	// conflictPolicy controls what happens when the apply conflicts with fields owned by other field managers
+	conflictPolicy ConflictPolicy
+
+	// recreatePolicy controls whether objects are recreated when the apply changes immutable fields
+	recreatePolicy RecreatePolicy
+
+	// retryPolicy controls how the apply of an object is retried after transient errors
+	retryPolicy RetryPolicy
+
+	// warnings, if set, collects the warnings returned by the apiserver to client
+	warnings *WarningRecorder
+
+	// adoptSelector, if set, selects the objects previously managed with label-selector pruning, to be adopted into the applyset.
+	adoptSelector string
+	// adoptGroupKinds are the kinds searched for objects to adopt
+	adoptGroupKinds []schema.GroupKind
 }
 
 // Options holds the parameters for building an ApplySet.
@@ -94,6 +114,29 @@ type Options struct {
 	Parent        Parent
 	Tooling       string
 	ComputeHealth ComputeHealthCallback
+	// PrunePolicy guards against pruning objects by mistake, when Prune is set.
+	PrunePolicy PrunePolicy
+	// ConflictPolicy controls what happens when the apply conflicts with fields owned by other field managers.
+	// If set, it overrides PatchOptions.Force; if not set, PatchOptions.Force is used as is.
+	ConflictPolicy ConflictPolicy
+	// RecreatePolicy controls whether objects are deleted and recreated when the apply changes immutable fields.
+	RecreatePolicy RecreatePolicy
+	// RetryPolicy controls how the apply of an object is retried after transient errors.
+	// If not set, DefaultRetryPolicy is used.
+	RetryPolicy RetryPolicy
+	// Warnings, if set, records the warnings returned by the apiserver for each object in the ApplyResults.
+	// It must be the WarningHandler of Client, or Client's transport must be wrapped with WrapTransportForWarnings,
+	// in which case Warnings is set as the WarningRecorder of the context of the apply.
+	Warnings *WarningRecorder
+
+	// AdoptSelector, if set, is the label selector that was used to prune with kubectl apply --prune --selector.
+	// Live objects matching it are adopted into the applyset by the first apply with the selector, which is recorded
+	// in AdoptedSelectorAnnotation on the parent, so that migrating to the applyset neither orphans them nor deletes them at once.
+	// The adopting apply does not prune them; adopted objects that are no longer desired are pruned by the next apply.
+	AdoptSelector string
+	// AdoptGroupKinds are the kinds searched for objects to adopt, in addition to the kinds of the desired objects.
+	// If not set, DefaultAdoptGroupKinds is used.
+	AdoptGroupKinds []schema.GroupKind
 }
 
 // New constructs a new ApplySet
@@ -114,10 +157,25 @@ func New(options Options) (*ApplySet, error) {
 		options.PatchOptions.FieldManager = kapplyset.FieldManager()
 	}
 
+	switch options.ConflictPolicy {
+	case "":
+	case ConflictPolicyForce:
+		force := true
+		options.PatchOptions.Force = &force
+	case ConflictPolicyFail, ConflictPolicySkipConflictingFields:
+		options.PatchOptions.Force = nil
+	default:
+		return nil, fmt.Errorf("unknown conflict policy %q", options.ConflictPolicy)
+	}
+
 	if options.ComputeHealth == nil {
 		options.ComputeHealth = IsHealthy
 	}
 
+	if options.RetryPolicy == (RetryPolicy{}) {
+		options.RetryPolicy = DefaultRetryPolicy
+	}
+
 	a := &ApplySet{
 		parentClient:  options.ParentClient,
 		client:        options.Client,
@@ -128,6 +186,15 @@ func New(options Options) (*ApplySet, error) {
 		parent:        parent,
 		tooling:       tooling,
 		computeHealth: options.ComputeHealth,
+		prunePolicy:   options.PrunePolicy,
+
+		conflictPolicy: options.ConflictPolicy,
+		recreatePolicy: options.RecreatePolicy,
+		retryPolicy:    options.RetryPolicy,
+		warnings:       options.Warnings,
+
+		adoptSelector:   options.AdoptSelector,
+		adoptGroupKinds: options.AdoptGroupKinds,
 	}
 	a.trackers = &objectTrackerList{}
 	return a, nil
@@ -159,6 +226,10 @@ type restMappingResult struct {
 //
 // TODO: We re-apply every object every iteration; we should be able to do better.
 func (a *ApplySet) ApplyOnce(ctx context.Context) (*ApplyResults, error) {
+	if a.warnings != nil {
+		ctx = ContextWithWarningRecorder(ctx, a.warnings)
+	}
+
 	// snapshot the state
 	a.mutex.Lock()
 	trackers := a.trackers
@@ -199,10 +270,36 @@ func (a *ApplySet) ApplyOnce(ctx context.Context) (*ApplyResults, error) {
 			kapplyset.AddResource(restMapping, obj.GetNamespace())
 		}
 	}
+
+	needsAdoption := a.needsAdoption()
+	var adoptCandidates []adoptCandidate
+	if needsAdoption {
+		candidates, err := a.findObjectsToAdopt(ctx, trackers, restMappings)
+		if err != nil {
+			return results, err
+		}
+		for _, candidate := range candidates {
+			// Record the kinds and namespaces in the parent before labeling the objects, so they can't be orphaned.
+			kapplyset.AddResource(candidate.restMapping, candidate.object.GetNamespace())
+		}
+		adoptCandidates = candidates
+	}
+
 	if err := a.WithParent(ctx, kapplyset); err != nil {
 		return results, fmt.Errorf("unable to update Parent: %w", err)
 	}
 
+	// Objects adopted by this apply are only pruned by a later apply, if they are no longer desired.
+	adoptedUids := sets.New[types.UID]()
+	if needsAdoption {
+		if err := a.adoptObjects(ctx, adoptCandidates, kapplyset.LabelsForMember(), adoptedUids, results); err != nil {
+			return results, err
+		}
+		if err := a.recordAdoption(ctx); err != nil {
+			return results, err
+		}
+	}
+
 	for i := range trackers.items {
 		tracker := &trackers.items[i]
 		obj := tracker.desired
@@ -261,9 +358,47 @@ func (a *ApplySet) ApplyOnce(ctx context.Context) (*ApplyResults, error) {
 			continue
 		}
 
-		lastApplied, err := dynamicResource.Patch(ctx, name, types.ApplyPatchType, j, a.patchOptions)
+		// Discard any warnings that are not from applying this object
+		a.warnings.Take()
+
+		var lastApplied *unstructured.Unstructured
+		retries, err := a.retryPolicy.Retry(ctx, func() error {
+			var err error
+			lastApplied, err = dynamicResource.Patch(ctx, name, types.ApplyPatchType, j, a.patchOptions)
+			if err != nil && IsRetryable(err) {
+				klog.Warningf("retryable error from apply on %s %s: %v", gvk, nn, err)
+			}
+			return err
+		})
+		var skippedConflicts []Conflict
+		if err != nil && a.conflictPolicy == ConflictPolicySkipConflictingFields {
+			if conflicts := ParseConflicts(err); len(conflicts) != 0 {
+				lastApplied, err = a.applyWithoutConflicts(ctx, dynamicResource, name, j, conflicts)
+				skippedConflicts = conflicts
+			}
+		}
+		var recreatedFields []string
+		if fields := ImmutableFields(err); len(fields) != 0 {
+			annotations, parseErr := objectAnnotations(j)
+			if parseErr != nil {
+				klog.Warningf("unable to check %s for %s: %v", RecreateAnnotation, nn, parseErr)
+			} else if a.recreatePolicy.shouldRecreate(annotations) {
+				lastApplied, err = a.recreate(ctx, dynamicResource, nn, j, fields)
+				recreatedFields = fields
+			}
+		}
+		warnings := a.warnings.Take()
+		for _, warning := range warnings {
+			klog.Warningf("warning from apply on %s %s: %s", gvk, nn, warning)
+		}
 		if err != nil {
 			results.applyError(gvk, nn, fmt.Errorf("error from apply: %w", err))
+			if retries != 0 {
+				results.RecordRetries(gvk, nn, retries)
+			}
+			if len(warnings) != 0 {
+				results.RecordWarnings(gvk, nn, warnings)
+			}
 			continue
 		}
 		visitedUids.Insert(lastApplied.GetUID())
@@ -272,15 +407,41 @@ func (a *ApplySet) ApplyOnce(ctx context.Context) (*ApplyResults, error) {
 		message := ""
 		tracker.isHealthy, message, err = a.computeHealth(lastApplied)
 		results.reportHealth(gvk, nn, lastApplied, tracker.isHealthy, message, err)
+		results.Objects[len(results.Objects)-1].Apply.Operation = OperationServerSideApplied
+		if len(recreatedFields) != 0 {
+			results.Objects[len(results.Objects)-1].Apply.Operation = OperationRecreated
+			results.Objects[len(results.Objects)-1].Apply.Message = fmt.Sprintf("recreated to change immutable fields %s", strings.Join(recreatedFields, ", "))
+		}
+		if len(skippedConflicts) != 0 {
+			results.Objects[len(results.Objects)-1].Apply.Conflicts = skippedConflicts
+			results.Objects[len(results.Objects)-1].Apply.Message = fmt.Sprintf("skipped %d fields owned by other field managers", len(skippedConflicts))
+		}
+		if retries != 0 {
+			results.RecordRetries(gvk, nn, retries)
+		}
+		if len(warnings) != 0 {
+			results.RecordWarnings(gvk, nn, warnings)
+		}
 	}
 
 	// We want to be more cautions on pruning and only do it if all manifests are applied.
 	if a.prune && results.applyFailCount == 0 {
 		klog.V(4).Infof("Prune is enabled")
-		pruneObjects, err := kapplyset.FindAllObjectsToPrune(ctx, a.client, visitedUids)
+		pruneObjects, err := kapplyset.FindAllObjectsToPrune(ctx, a.client, visitedUids.Union(adoptedUids))
 		if err != nil {
 			return results, err
 		}
+		trackedCount := len(visitedUids) + len(pruneObjects)
+		pruneObjects = a.skipProtectedObjects(pruneObjects, results)
+
+		policy := a.prunePolicy
+		if parentAllowsMassPrune(a.parent) {
+			policy.AllowMassPrune = true
+		}
+		if err := policy.CheckMassPrune(len(pruneObjects), trackedCount); err != nil {
+			// We don't update the parent, so the objects remain in the applyset and will be pruned once allowed.
+			return results, err
+		}
 		if err = a.deleteObjects(ctx, pruneObjects, results); err != nil {
 			return results, err
 		}
@@ -293,6 +454,18 @@ func (a *ApplySet) ApplyOnce(ctx context.Context) (*ApplyResults, error) {
 	return results, nil
 }
 
+// applyWithoutConflicts applies the object again without the conflicting fields, leaving them to their current managers.
+func (a *ApplySet) applyWithoutConflicts(ctx context.Context, dynamicResource dynamic.ResourceInterface, name string, j []byte, conflicts []Conflict) (*unstructured.Unstructured, error) {
+	for _, conflict := range conflicts {
+		klog.Infof("skipping field %s of %s, which is owned by field manager %q", conflict.Field, name, conflict.Manager)
+	}
+	j, err := removeConflictingFields(j, conflicts)
+	if err != nil {
+		return nil, fmt.Errorf("error removing conflicting fields: %w", err)
+	}
+	return dynamicResource.Patch(ctx, name, types.ApplyPatchType, j, a.patchOptions)
+}
+
 // updateManifestLabel adds the "applyset.kubernetes.io/part-of: Parent-ID" label to the manifest.
 func (a *ApplySet) updateManifestLabel(obj ApplyableObject, applysetLabels map[string]string) error {
 	u, ok := obj.(*unstructured.Unstructured)
@@ -356,6 +529,36 @@ func (a *ApplySet) updateParentLabelsAndAnnotations(ctx context.Context, kapplys
 	return nil
 }
 
+// skipProtectedObjects removes the objects that the prune policy protects, recording that they were not pruned.
+func (a *ApplySet) skipProtectedObjects(pruneObjects []kubectlapply.PruneObject, results *ApplyResults) []kubectlapply.PruneObject {
+	var prunable []kubectlapply.PruneObject
+	for _, pruneObject := range pruneObjects {
+		gvk := pruneObject.Object.GetObjectKind().GroupVersionKind()
+		nn := types.NamespacedName{Namespace: pruneObject.Namespace, Name: pruneObject.Name}
+
+		var annotations map[string]string
+		if accessor, err := meta.Accessor(pruneObject.Object); err == nil {
+			annotations = accessor.GetAnnotations()
+		}
+		if reason := a.prunePolicy.ProtectedReason(gvk.GroupKind(), annotations); reason != "" {
+			klog.Infof("not pruning resource %v: %s", pruneObject.String(), reason)
+			results.pruneSkipped(gvk, nn, reason)
+			continue
+		}
+		prunable = append(prunable, pruneObject)
+	}
+	return prunable
+}
+
+// parentAllowsMassPrune is true if the parent is annotated to override the mass-deletion threshold.
+func parentAllowsMassPrune(parent Parent) bool {
+	accessor, err := meta.Accessor(parent.GetSubject())
+	if err != nil {
+		return false
+	}
+	return accessor.GetAnnotations()[AllowMassPruneAnnotation] == "true"
+}
+
 func (a *ApplySet) deleteObjects(ctx context.Context, pruneObjects []kubectlapply.PruneObject, results *ApplyResults) error {
 	for i := range pruneObjects {
 		pruneObject := &pruneObjects[i]
@@ -141,3 +141,173 @@ data:
 	testDir := filepath.Join("testdata", strings.ToLower(t.Name()))
 	h.AssertMatchesFile(filepath.Join(testDir, "expected.yaml"), strings.Join(actual, "\n---\n"))
 }
+
+func TestApplySetAdoptsLabelSelectorObjects(t *testing.T) {
+	h := testutils.NewHarness(t)
+
+	parentYAML := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  namespace: default
+`
+
+	// These objects were previously applied with kubectl apply --prune --selector app=foo
+	existing := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+  namespace: default
+  labels:
+    app: foo
+data:
+  foo: bar
+
+---
+
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: old
+  namespace: default
+  labels:
+    app: foo
+
+---
+
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unrelated
+  namespace: default
+  labels:
+    app: bar
+`
+
+	apply := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+  namespace: default
+  labels:
+    app: foo
+data:
+  foo: baz
+`
+
+	h.WithObjects(append(h.ParseObjects(parentYAML), h.ParseObjects(existing)...)...)
+
+	parent := h.ParseObjects(parentYAML)[0]
+	parentGVK := parent.GroupVersionKind()
+	restmapping, err := h.RESTMapper().RESTMapping(parentGVK.GroupKind(), parentGVK.Version)
+	if err != nil {
+		h.Fatalf("error building parent restmapping: %v", err)
+	}
+
+	force := true
+	s, err := New(Options{
+		Parent:        NewParentRef(parent, "test", "default", restmapping),
+		RESTMapper:    h.RESTMapper(),
+		Client:        h.DynamicClient(),
+		ParentClient:  h.Client(),
+		PatchOptions:  metav1.PatchOptions{FieldManager: "test", Force: &force},
+		Prune:         true,
+		AdoptSelector: "app=foo",
+	})
+	if err != nil {
+		h.Fatalf("error building applyset object: %v", err)
+	}
+
+	var applyableObjects []ApplyableObject
+	for _, object := range h.ParseObjects(apply) {
+		applyableObjects = append(applyableObjects, object)
+	}
+	if err := s.SetDesiredObjects(applyableObjects); err != nil {
+		h.Fatalf("failed to set desired objects: %v", err)
+	}
+
+	liveConfigMaps := func() map[string]string {
+		list := &unstructured.UnstructuredList{}
+		list.SetAPIVersion("v1")
+		list.SetKind("ConfigMapList")
+		if err := h.Client().List(h.Ctx, list); err != nil {
+			h.Fatalf("failed to list configmaps: %v", err)
+		}
+		partOf := make(map[string]string)
+		for _, obj := range list.Items {
+			partOf[obj.GetName()] = obj.GetLabels()["applyset.kubernetes.io/part-of"]
+		}
+		return partOf
+	}
+
+	// The first apply adopts the objects, without pruning them; they are pruned on a later apply once the parent lists them
+	results, err := s.ApplyOnce(h.Ctx)
+	if err != nil {
+		h.Fatalf("failed to apply objects: %v", err)
+	}
+	if !results.AllApplied() {
+		h.Fatalf("not all objects were applied")
+	}
+	if got, want := results.AdoptedCount(), 2; got != want {
+		h.Errorf("unexpected adopted count, got %d, want %d", got, want)
+	}
+	live := liveConfigMaps()
+	if live["foo"] == "" || live["old"] == "" {
+		h.Errorf("expected foo and old to be adopted into the applyset, got %v", live)
+	}
+	if live["unrelated"] != "" {
+		h.Errorf("expected unrelated not to be adopted, got %v", live)
+	}
+	if _, found := live["old"]; !found {
+		h.Errorf("expected old not to be pruned by the first apply, got %v", live)
+	}
+	if got := parent.GetAnnotations()[AdoptedSelectorAnnotation]; got != "app=foo" {
+		h.Errorf("expected the parent to record the adopted selector, got %q", got)
+	}
+
+	// Adoption only runs once: objects created with the label after the migration are not adopted by later applies
+	for _, obj := range h.ParseObjects(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: later
+  namespace: default
+  labels:
+    app: foo
+`) {
+		if err := h.Client().Create(h.Ctx, obj); err != nil {
+			h.Fatalf("failed to create object: %v", err)
+		}
+	}
+	liveParent := parent.DeepCopy()
+	if err := h.Client().Get(h.Ctx, types.NamespacedName{Namespace: "default", Name: "test"}, liveParent); err != nil {
+		h.Fatalf("failed to get parent: %v", err)
+	}
+	s, err = New(Options{
+		Parent:        NewParentRef(liveParent, "test", "default", restmapping),
+		RESTMapper:    h.RESTMapper(),
+		Client:        h.DynamicClient(),
+		ParentClient:  h.Client(),
+		PatchOptions:  metav1.PatchOptions{FieldManager: "test", Force: &force},
+		AdoptSelector: "app=foo",
+	})
+	if err != nil {
+		h.Fatalf("error building applyset object: %v", err)
+	}
+	if err := s.SetDesiredObjects(applyableObjects); err != nil {
+		h.Fatalf("failed to set desired objects: %v", err)
+	}
+	results, err = s.ApplyOnce(h.Ctx)
+	if err != nil {
+		h.Fatalf("failed to apply objects: %v", err)
+	}
+	if got, want := results.AdoptedCount(), 0; got != want {
+		h.Errorf("unexpected adopted count on the second apply, got %d, want %d", got, want)
+	}
+	if live := liveConfigMaps(); live["later"] != "" {
+		h.Errorf("expected later not to be adopted, got %v", live)
+	}
+}
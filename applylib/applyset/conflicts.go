@@ -0,0 +1,297 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applyset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConflictPolicy controls what happens when a server-side apply conflicts with fields owned by another field manager.
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyForce takes ownership of the conflicting fields, overwriting the values set by the other managers.
+	ConflictPolicyForce ConflictPolicy = "Force"
+	// ConflictPolicyFail fails the apply of the object, reporting the conflicting fields and managers.
+	ConflictPolicyFail ConflictPolicy = "Fail"
+	// ConflictPolicySkipConflictingFields applies the object without the conflicting fields,
+	// leaving them to the other managers.
+	ConflictPolicySkipConflictingFields ConflictPolicy = "SkipConflictingFields"
+)
+
+// Conflict is a field that could not be applied because it is owned by another field manager.
+type Conflict struct {
+	// Field is the path of the conflicting field, eg .spec.template.spec.containers[name="foo"].image
+	Field string
+	// Manager is the name of the field manager that owns the field, or "" if it could not be parsed.
+	Manager string
+	// Message is the conflict message returned by the server.
+	Message string
+}
+
+// managerRegexp extracts the manager from messages like `conflict with "kubectl-edit" using apps/v1`
+var managerRegexp = regexp.MustCompile(`^conflicts? with ("(?:[^"\\]|\\.)*")`)
+
+// ParseConflicts returns the field conflicts reported by a server-side apply error,
+// or nil if err is not an apply conflict.
+func ParseConflicts(err error) []Conflict {
+	if !apierrors.IsConflict(err) {
+		return nil
+	}
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		return nil
+	}
+	details := status.Status().Details
+	if details == nil {
+		return nil
+	}
+
+	var conflicts []Conflict
+	for _, cause := range details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		conflict := Conflict{
+			Field:   cause.Field,
+			Message: cause.Message,
+		}
+		if match := managerRegexp.FindStringSubmatch(cause.Message); match != nil {
+			if manager, err := strconv.Unquote(match[1]); err == nil {
+				conflict.Manager = manager
+			}
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
+
+// removeConflictingFields returns the JSON of the object without the conflicting fields.
+func removeConflictingFields(j []byte, conflicts []Conflict) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(j))
+	decoder.UseNumber()
+	var obj map[string]interface{}
+	if err := decoder.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("failed to parse object: %w", err)
+	}
+
+	for _, conflict := range conflicts {
+		path, err := parseFieldPath(conflict.Field)
+		if err != nil {
+			return nil, err
+		}
+		obj = removeFieldPath(obj, path).(map[string]interface{})
+	}
+	return json.Marshal(obj)
+}
+
+// pathElement is an element of a server-side apply field path, see sigs.k8s.io/structured-merge-diff/fieldpath.
+type pathElement struct {
+	// fieldName is set for map fields, eg .spec
+	fieldName *string
+	// keys is set for associative list items, eg [name="foo"]
+	keys map[string]string
+	// value is set for set items, eg [="foo"]
+	value *string
+	// index is set for list items, eg [0]
+	index *int
+}
+
+// parseFieldPath parses a field path as formatted by fieldpath.Path.String(), eg .spec.containers[name="foo"].image
+//
+// Field names are split on dots; as map keys can contain dots (eg labels), removeFieldPath rejoins them as needed.
+func parseFieldPath(s string) ([]pathElement, error) {
+	var path []pathElement
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '.':
+			end := i + 1
+			for end < len(s) && s[end] != '.' && s[end] != '[' {
+				end++
+			}
+			name := s[i+1 : end]
+			path = append(path, pathElement{fieldName: &name})
+			i = end
+
+		case '[':
+			end, err := closingBracket(s, i)
+			if err != nil {
+				return nil, err
+			}
+			element, err := parseBracket(s[i+1 : end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid field path %q: %w", s, err)
+			}
+			path = append(path, element)
+			i = end + 1
+
+		default:
+			return nil, fmt.Errorf("invalid field path %q: unexpected %q at offset %d", s, s[i], i)
+		}
+	}
+	return path, nil
+}
+
+// closingBracket returns the offset of the bracket closing the one at start, skipping quoted strings.
+func closingBracket(s string, start int) (int, error) {
+	inQuote := false
+	for i := start + 1; i < len(s); i++ {
+		switch {
+		case inQuote && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			inQuote = !inQuote
+		case !inQuote && s[i] == ']':
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid field path %q: unterminated [", s)
+}
+
+// parseBracket parses the contents of a bracketed path element: a key list, a set value or an index.
+func parseBracket(s string) (pathElement, error) {
+	if strings.HasPrefix(s, "=") {
+		value := s[1:]
+		return pathElement{value: &value}, nil
+	}
+	if index, err := strconv.Atoi(s); err == nil {
+		return pathElement{index: &index}, nil
+	}
+
+	keys := make(map[string]string)
+	for len(s) > 0 {
+		name, rest, found := strings.Cut(s, "=")
+		if !found {
+			return pathElement{}, fmt.Errorf("invalid key %q", s)
+		}
+		end := len(rest)
+		inQuote := false
+		for i := 0; i < len(rest); i++ {
+			if inQuote && rest[i] == '\\' {
+				i++
+			} else if rest[i] == '"' {
+				inQuote = !inQuote
+			} else if !inQuote && rest[i] == ',' {
+				end = i
+				break
+			}
+		}
+		keys[name] = rest[:end]
+		s = strings.TrimPrefix(rest[end:], ",")
+	}
+	return pathElement{keys: keys}, nil
+}
+
+// formatValue formats a scalar value the same way as structured-merge-diff, for matching keys and set values.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// removeFieldPath removes the field at path from obj, returning the updated value.
+// Paths that are not found in obj are ignored.
+func removeFieldPath(obj interface{}, path []pathElement) interface{} {
+	if len(path) == 0 {
+		return obj
+	}
+
+	switch obj := obj.(type) {
+	case map[string]interface{}:
+		if path[0].fieldName == nil {
+			return obj
+		}
+		// Map keys can contain dots, so we try the longest key first
+		n := 1
+		for n < len(path) && path[n].fieldName != nil {
+			n++
+		}
+		for ; n > 0; n-- {
+			names := make([]string, n)
+			for i := range names {
+				names[i] = *path[i].fieldName
+			}
+			key := strings.Join(names, ".")
+			child, found := obj[key]
+			if !found {
+				continue
+			}
+			if n == len(path) {
+				delete(obj, key)
+			} else {
+				obj[key] = removeFieldPath(child, path[n:])
+			}
+			break
+		}
+		return obj
+
+	case []interface{}:
+		for i, item := range obj {
+			if !matchesListElement(i, item, path[0]) {
+				continue
+			}
+			if len(path) == 1 {
+				return append(obj[:i], obj[i+1:]...)
+			}
+			obj[i] = removeFieldPath(item, path[1:])
+			return obj
+		}
+		return obj
+
+	default:
+		return obj
+	}
+}
+
+// matchesListElement is true if the list item at index matches the path element.
+func matchesListElement(index int, item interface{}, element pathElement) bool {
+	switch {
+	case element.index != nil:
+		return *element.index == index
+	case element.value != nil:
+		return formatValue(item) == *element.value
+	case element.keys != nil:
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for name, value := range element.keys {
+			if formatValue(m[name]) != value {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applyset
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseConflicts(t *testing.T) {
+	err := apierrors.NewApplyConflict([]metav1.StatusCause{
+		{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: `conflict with "kubectl-edit" using apps/v1`,
+			Field:   ".spec.replicas",
+		},
+		{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: `conflict with "hpa-controller" with subresource "scale"`,
+			Field:   `.spec.template.spec.containers[name="nginx"].image`,
+		},
+		{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "not a conflict",
+		},
+	}, "Apply failed with 2 conflicts")
+
+	want := []Conflict{
+		{Field: ".spec.replicas", Manager: "kubectl-edit", Message: `conflict with "kubectl-edit" using apps/v1`},
+		{Field: `.spec.template.spec.containers[name="nginx"].image`, Manager: "hpa-controller", Message: `conflict with "hpa-controller" with subresource "scale"`},
+	}
+	if got := ParseConflicts(fmt.Errorf("error from apply: %w", err)); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected conflicts from ParseConflicts; got %+v, want %+v", got, want)
+	}
+
+	if got := ParseConflicts(errors.New("some other error")); got != nil {
+		t.Errorf("expected no conflicts from a non-conflict error, got %+v", got)
+	}
+}
+
+func TestRemoveConflictingFields(t *testing.T) {
+	obj := `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"labels":{"app.kubernetes.io/name":"foo","app":"foo"},"name":"foo"},` +
+		`"spec":{"replicas":3,"template":{"spec":{"containers":[{"image":"nginx:1","name":"nginx","ports":[{"containerPort":80,"protocol":"TCP"}]},{"image":"sidecar","name":"sidecar"}],` +
+		`"finalizers":["a","b"]}}}}`
+
+	tests := []struct {
+		name  string
+		field string
+		want  string
+	}{
+		{
+			name:  "scalar",
+			field: ".spec.replicas",
+			want: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"labels":{"app":"foo","app.kubernetes.io/name":"foo"},"name":"foo"},` +
+				`"spec":{"template":{"spec":{"containers":[{"image":"nginx:1","name":"nginx","ports":[{"containerPort":80,"protocol":"TCP"}]},{"image":"sidecar","name":"sidecar"}],` +
+				`"finalizers":["a","b"]}}}}`,
+		},
+		{
+			name:  "key with dots",
+			field: ".metadata.labels.app.kubernetes.io/name",
+			want: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"labels":{"app":"foo"},"name":"foo"},` +
+				`"spec":{"replicas":3,"template":{"spec":{"containers":[{"image":"nginx:1","name":"nginx","ports":[{"containerPort":80,"protocol":"TCP"}]},{"image":"sidecar","name":"sidecar"}],` +
+				`"finalizers":["a","b"]}}}}`,
+		},
+		{
+			name:  "associative list",
+			field: `.spec.template.spec.containers[name="nginx"].image`,
+			want: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"labels":{"app":"foo","app.kubernetes.io/name":"foo"},"name":"foo"},` +
+				`"spec":{"replicas":3,"template":{"spec":{"containers":[{"name":"nginx","ports":[{"containerPort":80,"protocol":"TCP"}]},{"image":"sidecar","name":"sidecar"}],` +
+				`"finalizers":["a","b"]}}}}`,
+		},
+		{
+			name:  "multiple keys",
+			field: `.spec.template.spec.containers[name="nginx"].ports[containerPort=80,protocol="TCP"]`,
+			want: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"labels":{"app":"foo","app.kubernetes.io/name":"foo"},"name":"foo"},` +
+				`"spec":{"replicas":3,"template":{"spec":{"containers":[{"image":"nginx:1","name":"nginx","ports":[]},{"image":"sidecar","name":"sidecar"}],` +
+				`"finalizers":["a","b"]}}}}`,
+		},
+		{
+			name:  "set value",
+			field: `.spec.template.spec.finalizers[="a"]`,
+			want: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"labels":{"app":"foo","app.kubernetes.io/name":"foo"},"name":"foo"},` +
+				`"spec":{"replicas":3,"template":{"spec":{"containers":[{"image":"nginx:1","name":"nginx","ports":[{"containerPort":80,"protocol":"TCP"}]},{"image":"sidecar","name":"sidecar"}],` +
+				`"finalizers":["b"]}}}}`,
+		},
+		{
+			name:  "not found",
+			field: `.spec.template.spec.containers[name="other"].image`,
+			want: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"labels":{"app":"foo","app.kubernetes.io/name":"foo"},"name":"foo"},` +
+				`"spec":{"replicas":3,"template":{"spec":{"containers":[{"image":"nginx:1","name":"nginx","ports":[{"containerPort":80,"protocol":"TCP"}]},{"image":"sidecar","name":"sidecar"}],` +
+				`"finalizers":["a","b"]}}}}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := removeConflictingFields([]byte(obj), []Conflict{{Field: test.field}})
+			if err != nil {
+				t.Fatalf("removeConflictingFields failed: %v", err)
+			}
+			if string(got) != test.want {
+				t.Errorf("unexpected result from removeConflictingFields;\ngot  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
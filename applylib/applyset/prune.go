@@ -0,0 +1,107 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applyset
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// PruneAnnotation on a live object controls whether it may be pruned; set it to PruneDisabled to keep the object.
+	PruneAnnotation = "addons.k8s.io/prune"
+	// PruneDisabled is the value of PruneAnnotation that protects an object from pruning.
+	PruneDisabled = "disabled"
+
+	// AllowMassPruneAnnotation on the parent, if set to "true", overrides the mass-deletion threshold of the PrunePolicy.
+	AllowMassPruneAnnotation = "addons.k8s.io/allow-mass-prune"
+)
+
+// PrunePolicy guards against pruning objects by mistake, eg if a bad manifest is applied.
+//
+// Objects annotated with addons.k8s.io/prune=disabled are never pruned,
+// CRDs and Namespaces are only pruned if explicitly allowed,
+// and pruning is blocked if it would delete more of the tracked objects at once than the limits, if any, allow.
+type PrunePolicy struct {
+	// AllowPruneCRDs allows CustomResourceDefinitions to be pruned; deleting a CRD deletes all of its custom resources.
+	AllowPruneCRDs bool
+	// AllowPruneNamespaces allows Namespaces to be pruned; deleting a Namespace deletes all of its contents.
+	AllowPruneNamespaces bool
+
+	// MaxPruneCount is the maximum number of objects that may be pruned in one apply; 0 means no limit.
+	MaxPruneCount int
+	// MaxPruneFraction is the maximum fraction of the tracked objects (applied or pruned) that may be pruned in one apply.
+	// 0 means no limit.
+	MaxPruneFraction float64
+
+	// AllowMassPrune overrides MaxPruneCount and MaxPruneFraction, eg once a large removal has been reviewed.
+	AllowMassPrune bool
+}
+
+var (
+	crdGroupKind       = schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}
+	namespaceGroupKind = schema.GroupKind{Group: "", Kind: "Namespace"}
+)
+
+// ProtectedReason returns why an object must not be pruned, or "" if it may be pruned.
+func (p *PrunePolicy) ProtectedReason(gk schema.GroupKind, annotations map[string]string) string {
+	if annotations[PruneAnnotation] == PruneDisabled {
+		return fmt.Sprintf("object is annotated with %s=%s", PruneAnnotation, PruneDisabled)
+	}
+	if gk == crdGroupKind && !p.AllowPruneCRDs {
+		return "pruning CustomResourceDefinitions is not allowed"
+	}
+	if gk == namespaceGroupKind && !p.AllowPruneNamespaces {
+		return "pruning Namespaces is not allowed"
+	}
+	return ""
+}
+
+// CheckMassPrune returns a PruneBlockedError if pruning pruneCount of the tracked objects exceeds the limits of the policy.
+func (p *PrunePolicy) CheckMassPrune(pruneCount int, trackedCount int) error {
+	if p.AllowMassPrune || pruneCount == 0 {
+		return nil
+	}
+	if p.MaxPruneCount > 0 && pruneCount > p.MaxPruneCount {
+		return &PruneBlockedError{
+			PruneCount:   pruneCount,
+			TrackedCount: trackedCount,
+			Reason:       fmt.Sprintf("the limit is %d objects", p.MaxPruneCount),
+		}
+	}
+	maxFraction := p.MaxPruneFraction
+	if maxFraction > 0 && trackedCount > 0 && float64(pruneCount)/float64(trackedCount) > maxFraction {
+		return &PruneBlockedError{
+			PruneCount:   pruneCount,
+			TrackedCount: trackedCount,
+			Reason:       fmt.Sprintf("the limit is %.0f%% of the tracked objects", maxFraction*100),
+		}
+	}
+	return nil
+}
+
+// PruneBlockedError is returned when pruning was skipped because it would delete too many objects.
+type PruneBlockedError struct {
+	PruneCount   int
+	TrackedCount int
+	Reason       string
+}
+
+func (e *PruneBlockedError) Error() string {
+	return fmt.Sprintf("refusing to prune %d of %d tracked objects (%s); set %s=true on the parent to allow it", e.PruneCount, e.TrackedCount, e.Reason, AllowMassPruneAnnotation)
+}
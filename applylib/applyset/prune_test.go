@@ -0,0 +1,82 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applyset
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPrunePolicyProtectedReason(t *testing.T) {
+	configMap := schema.GroupKind{Kind: "ConfigMap"}
+	disabled := map[string]string{PruneAnnotation: PruneDisabled}
+
+	tests := []struct {
+		name        string
+		policy      PrunePolicy
+		gk          schema.GroupKind
+		annotations map[string]string
+		protected   bool
+	}{
+		{name: "configmap", gk: configMap},
+		{name: "annotated configmap", gk: configMap, annotations: disabled, protected: true},
+		{name: "crd", gk: crdGroupKind, protected: true},
+		{name: "crd allowed", gk: crdGroupKind, policy: PrunePolicy{AllowPruneCRDs: true}},
+		{name: "namespace", gk: namespaceGroupKind, protected: true},
+		{name: "namespace allowed", gk: namespaceGroupKind, policy: PrunePolicy{AllowPruneNamespaces: true}},
+		{name: "annotated namespace allowed", gk: namespaceGroupKind, annotations: disabled, policy: PrunePolicy{AllowPruneNamespaces: true}, protected: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reason := test.policy.ProtectedReason(test.gk, test.annotations)
+			if got := reason != ""; got != test.protected {
+				t.Errorf("ProtectedReason returned %q, expected protected=%v", reason, test.protected)
+			}
+		})
+	}
+}
+
+func TestPrunePolicyCheckMassPrune(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  PrunePolicy
+		prune   int
+		tracked int
+		blocked bool
+	}{
+		{name: "nothing to prune", prune: 0, tracked: 0},
+		{name: "no limits by default", prune: 10, tracked: 10},
+		{name: "half of the objects", policy: PrunePolicy{MaxPruneFraction: 0.5}, prune: 5, tracked: 10},
+		{name: "most of the objects", policy: PrunePolicy{MaxPruneFraction: 0.5}, prune: 9, tracked: 10, blocked: true},
+		{name: "all of the objects", policy: PrunePolicy{MaxPruneFraction: 0.5}, prune: 1, tracked: 1, blocked: true},
+		{name: "fraction limit", policy: PrunePolicy{MaxPruneFraction: 0.2}, prune: 3, tracked: 10, blocked: true},
+		{name: "no fraction limit", policy: PrunePolicy{MaxPruneFraction: 1}, prune: 10, tracked: 10},
+		{name: "count limit", policy: PrunePolicy{MaxPruneCount: 2}, prune: 3, tracked: 100, blocked: true},
+		{name: "override", policy: PrunePolicy{MaxPruneCount: 2, AllowMassPrune: true}, prune: 10, tracked: 10},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.policy.CheckMassPrune(test.prune, test.tracked)
+			var blocked *PruneBlockedError
+			if got := errors.As(err, &blocked); got != test.blocked {
+				t.Errorf("CheckMassPrune returned %v, expected blocked=%v", err, test.blocked)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applyset
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+// RecreateAnnotation on a desired object controls whether it is deleted and recreated when an apply changes an immutable field;
+// "true" or "false" overrides RecreatePolicy.RecreateOnImmutableChange for the object.
+const RecreateAnnotation = "addons.k8s.io/recreate-on-immutable-change"
+
+// RecreatePolicy controls whether objects are deleted and recreated when an apply fails because it changes an immutable field,
+// eg the selector of a Deployment or the volumeClaimTemplates of a StatefulSet.
+type RecreatePolicy struct {
+	// RecreateOnImmutableChange recreates objects on immutable-field changes, unless they are annotated with RecreateAnnotation=false.
+	// If not set, only objects annotated with RecreateAnnotation=true are recreated.
+	RecreateOnImmutableChange bool
+	// PropagationPolicy is used when deleting the object; if not set, metav1.DeletePropagationBackground is used.
+	// Use metav1.DeletePropagationOrphan to keep the dependents, eg the Pods of a StatefulSet.
+	PropagationPolicy metav1.DeletionPropagation
+}
+
+// shouldRecreate is true if an object with the given annotations should be recreated on an immutable-field change.
+func (p *RecreatePolicy) shouldRecreate(annotations map[string]string) bool {
+	switch annotations[RecreateAnnotation] {
+	case "true":
+		return true
+	case "false":
+		return false
+	default:
+		return p.RecreateOnImmutableChange
+	}
+}
+
+// immutableFieldMessages are the messages that the apiserver uses for changes to immutable fields.
+var immutableFieldMessages = []string{
+	"field is immutable",
+	"may not change once set",
+	"updates to statefulset spec for fields other than",
+}
+
+// ImmutableFields returns the fields that an apply error reports as immutable, or nil if err is not an immutable-field error.
+// If the error also reports other invalid fields, nil is returned, as recreating the object would not fix them.
+func ImmutableFields(err error) []string {
+	if !apierrors.IsInvalid(err) {
+		return nil
+	}
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		return nil
+	}
+	details := status.Status().Details
+	if details == nil {
+		return nil
+	}
+
+	var fields []string
+	for _, cause := range details.Causes {
+		if !isImmutableFieldMessage(cause.Message) {
+			return nil
+		}
+		fields = append(fields, cause.Field)
+	}
+	return fields
+}
+
+func isImmutableFieldMessage(message string) bool {
+	for _, immutable := range immutableFieldMessages {
+		if strings.Contains(message, immutable) {
+			return true
+		}
+	}
+	return false
+}
+
+// objectAnnotations returns the annotations of an object in JSON form.
+func objectAnnotations(j []byte) (map[string]string, error) {
+	var obj struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(j, &obj); err != nil {
+		return nil, fmt.Errorf("failed to parse object: %w", err)
+	}
+	return obj.Metadata.Annotations, nil
+}
+
+// recreate deletes the live object and applies it again, for an apply that changed immutable fields.
+// If the live object is still being deleted, an error is returned and the object is recreated by a later apply.
+func (a *ApplySet) recreate(ctx context.Context, dynamicResource dynamic.ResourceInterface, nn types.NamespacedName, j []byte, immutableFields []string) (*unstructured.Unstructured, error) {
+	live, err := dynamicResource.Get(ctx, nn.Name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("error getting object to recreate: %w", err)
+	}
+
+	if live != nil && live.GetDeletionTimestamp() == nil {
+		klog.Infof("recreating %v, to change immutable fields %v", nn, immutableFields)
+		propagationPolicy := a.recreatePolicy.PropagationPolicy
+		if propagationPolicy == "" {
+			propagationPolicy = metav1.DeletePropagationBackground
+		}
+		uid := live.GetUID()
+		deleteOptions := metav1.DeleteOptions{
+			PropagationPolicy: &propagationPolicy,
+			Preconditions:     &metav1.Preconditions{UID: &uid},
+		}
+		if err := dynamicResource.Delete(ctx, nn.Name, deleteOptions); err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("error deleting object to recreate: %w", err)
+		}
+	}
+
+	lastApplied, err := dynamicResource.Patch(ctx, nn.Name, types.ApplyPatchType, j, a.patchOptions)
+	if err != nil {
+		if len(ImmutableFields(err)) != 0 {
+			return nil, fmt.Errorf("waiting for object to be deleted before it is recreated: %w", err)
+		}
+		return nil, err
+	}
+	return lastApplied, nil
+}
@@ -0,0 +1,323 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applyset
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/testutils"
+)
+
+func TestImmutableFields(t *testing.T) {
+	deployment := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	immutable := apierrors.NewInvalid(deployment, "foo", field.ErrorList{
+		field.Invalid(field.NewPath("spec", "selector"), "app=bar", "field is immutable"),
+	})
+	if got, want := ImmutableFields(immutable), []string{"spec.selector"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected immutable fields; got %v, want %v", got, want)
+	}
+
+	// Recreating the object would not fix the other invalid field, so the object must not be deleted
+	mixed := apierrors.NewInvalid(deployment, "foo", field.ErrorList{
+		field.Invalid(field.NewPath("spec", "selector"), "app=bar", "field is immutable"),
+		field.Invalid(field.NewPath("spec", "replicas"), -1, "must be greater than or equal to 0"),
+	})
+	if got := ImmutableFields(mixed); got != nil {
+		t.Errorf("expected no immutable fields for an error with other invalid fields, got %v", got)
+	}
+
+	invalid := apierrors.NewInvalid(deployment, "foo", field.ErrorList{
+		field.Invalid(field.NewPath("spec", "replicas"), -1, "must be greater than or equal to 0"),
+	})
+	if got := ImmutableFields(invalid); got != nil {
+		t.Errorf("expected no immutable fields, got %v", got)
+	}
+}
+
+func TestRecreatePolicyShouldRecreate(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      RecreatePolicy
+		annotations map[string]string
+		want        bool
+	}{
+		{name: "default"},
+		{name: "annotated", annotations: map[string]string{RecreateAnnotation: "true"}, want: true},
+		{name: "enabled", policy: RecreatePolicy{RecreateOnImmutableChange: true}, want: true},
+		{name: "enabled but annotated", policy: RecreatePolicy{RecreateOnImmutableChange: true}, annotations: map[string]string{RecreateAnnotation: "false"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.policy.shouldRecreate(test.annotations); got != test.want {
+				t.Errorf("shouldRecreate returned %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// errorInjector fails the first applies to a path with an error, eg for errors that the mock apiserver does not return.
+type errorInjector struct {
+	next http.RoundTripper
+	path string
+	err  *apierrors.StatusError
+	// count is the number of applies to fail
+	count int
+}
+
+func (i *errorInjector) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPatch || req.URL.Path != i.path || i.count == 0 {
+		return i.next.RoundTrip(req)
+	}
+	i.count--
+
+	status := i.err.ErrStatus
+	status.APIVersion = "v1"
+	status.Kind = "Status"
+	body, err := json.Marshal(status)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: int(status.Code),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// dynamicClientWithErrors returns a dynamic client for which the first count applies to path fail with err.
+func dynamicClientWithErrors(h *testutils.Harness, path string, err *apierrors.StatusError, count int) dynamic.Interface {
+	restConfig := rest.CopyConfig(h.RESTConfig())
+	restConfig.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &errorInjector{next: rt, path: path, err: err, count: count}
+	})
+	dynamicClient, dynamicErr := dynamic.NewForConfig(restConfig)
+	if dynamicErr != nil {
+		h.Fatalf("error building dynamic client: %v", dynamicErr)
+	}
+	return dynamicClient
+}
+
+func TestApplySetRecreatesOnImmutableChange(t *testing.T) {
+	h := testutils.NewHarness(t)
+
+	parentYAML := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  namespace: default
+`
+
+	existing := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+  namespace: default
+data:
+  foo: bar
+`
+
+	apply := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+  namespace: default
+  annotations:
+    addons.k8s.io/recreate-on-immutable-change: "true"
+data:
+  foo: baz
+`
+
+	h.WithObjects(append(h.ParseObjects(parentYAML), h.ParseObjects(existing)...)...)
+
+	parent := h.ParseObjects(parentYAML)[0]
+	parentGVK := parent.GroupVersionKind()
+	restmapping, err := h.RESTMapper().RESTMapping(parentGVK.GroupKind(), parentGVK.Version)
+	if err != nil {
+		h.Fatalf("error building parent restmapping: %v", err)
+	}
+
+	immutable := apierrors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "foo", field.ErrorList{
+		field.Invalid(field.NewPath("data"), "bar", "field is immutable"),
+	})
+	dynamicClient := dynamicClientWithErrors(h, "/api/v1/namespaces/default/configmaps/foo", immutable, 1)
+
+	force := true
+	s, err := New(Options{
+		Parent:       NewParentRef(parent, "test", "default", restmapping),
+		RESTMapper:   h.RESTMapper(),
+		Client:       dynamicClient,
+		ParentClient: h.Client(),
+		PatchOptions: metav1.PatchOptions{FieldManager: "test", Force: &force},
+	})
+	if err != nil {
+		h.Fatalf("error building applyset object: %v", err)
+	}
+
+	var applyableObjects []ApplyableObject
+	for _, object := range h.ParseObjects(apply) {
+		applyableObjects = append(applyableObjects, object)
+	}
+	if err := s.SetDesiredObjects(applyableObjects); err != nil {
+		h.Fatalf("failed to set desired objects: %v", err)
+	}
+
+	before := &unstructured.Unstructured{}
+	before.SetAPIVersion("v1")
+	before.SetKind("ConfigMap")
+	if err := h.Client().Get(h.Ctx, types.NamespacedName{Namespace: "default", Name: "foo"}, before); err != nil {
+		h.Fatalf("failed to get configmap: %v", err)
+	}
+
+	results, err := s.ApplyOnce(h.Ctx)
+	if err != nil {
+		h.Fatalf("failed to apply objects: %v", err)
+	}
+	if !results.AllApplied() {
+		h.Fatalf("not all objects were applied: %+v", results.Objects)
+	}
+	if got, want := results.Objects[0].Apply.Operation, OperationRecreated; got != want {
+		h.Errorf("unexpected operation, got %q, want %q", got, want)
+	}
+
+	after := &unstructured.Unstructured{}
+	after.SetAPIVersion("v1")
+	after.SetKind("ConfigMap")
+	if err := h.Client().Get(h.Ctx, types.NamespacedName{Namespace: "default", Name: "foo"}, after); err != nil {
+		h.Fatalf("failed to get configmap: %v", err)
+	}
+	if before.GetUID() == after.GetUID() {
+		h.Errorf("expected configmap to be recreated with a new uid, got %q", after.GetUID())
+	}
+	if got, _, _ := unstructured.NestedString(after.Object, "data", "foo"); got != "baz" {
+		h.Errorf("unexpected data after recreate, got %q", got)
+	}
+}
+
+func TestApplySetDoesNotRecreateWithOtherInvalidFields(t *testing.T) {
+	h := testutils.NewHarness(t)
+
+	parentYAML := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  namespace: default
+`
+
+	existing := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+  namespace: default
+data:
+  foo: bar
+`
+
+	apply := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+  namespace: default
+  annotations:
+    addons.k8s.io/recreate-on-immutable-change: "true"
+data:
+  foo: baz
+`
+
+	h.WithObjects(append(h.ParseObjects(parentYAML), h.ParseObjects(existing)...)...)
+
+	parent := h.ParseObjects(parentYAML)[0]
+	parentGVK := parent.GroupVersionKind()
+	restmapping, err := h.RESTMapper().RESTMapping(parentGVK.GroupKind(), parentGVK.Version)
+	if err != nil {
+		h.Fatalf("error building parent restmapping: %v", err)
+	}
+
+	// The recreated object would be rejected for the other invalid field, leaving it deleted
+	invalid := apierrors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "foo", field.ErrorList{
+		field.Invalid(field.NewPath("data"), "bar", "field is immutable"),
+		field.Invalid(field.NewPath("metadata", "labels"), "-", "a valid label must be an empty string or consist of alphanumeric characters"),
+	})
+	dynamicClient := dynamicClientWithErrors(h, "/api/v1/namespaces/default/configmaps/foo", invalid, 1)
+
+	force := true
+	s, err := New(Options{
+		Parent:       NewParentRef(parent, "test", "default", restmapping),
+		RESTMapper:   h.RESTMapper(),
+		Client:       dynamicClient,
+		ParentClient: h.Client(),
+		PatchOptions: metav1.PatchOptions{FieldManager: "test", Force: &force},
+	})
+	if err != nil {
+		h.Fatalf("error building applyset object: %v", err)
+	}
+
+	var applyableObjects []ApplyableObject
+	for _, object := range h.ParseObjects(apply) {
+		applyableObjects = append(applyableObjects, object)
+	}
+	if err := s.SetDesiredObjects(applyableObjects); err != nil {
+		h.Fatalf("failed to set desired objects: %v", err)
+	}
+
+	before := &unstructured.Unstructured{}
+	before.SetAPIVersion("v1")
+	before.SetKind("ConfigMap")
+	if err := h.Client().Get(h.Ctx, types.NamespacedName{Namespace: "default", Name: "foo"}, before); err != nil {
+		h.Fatalf("failed to get configmap: %v", err)
+	}
+
+	results, err := s.ApplyOnce(h.Ctx)
+	if err != nil {
+		h.Fatalf("failed to apply objects: %v", err)
+	}
+	if results.AllApplied() {
+		h.Fatalf("expected the apply to fail: %+v", results.Objects)
+	}
+	if got := results.Objects[0].Apply.Operation; got == OperationRecreated {
+		h.Errorf("unexpected operation %q", got)
+	}
+
+	after := &unstructured.Unstructured{}
+	after.SetAPIVersion("v1")
+	after.SetKind("ConfigMap")
+	if err := h.Client().Get(h.Ctx, types.NamespacedName{Namespace: "default", Name: "foo"}, after); err != nil {
+		h.Fatalf("expected configmap to be kept: %v", err)
+	}
+	if before.GetUID() != after.GetUID() {
+		h.Errorf("expected configmap not to be recreated, got uid %q, want %q", after.GetUID(), before.GetUID())
+	}
+}
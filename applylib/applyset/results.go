@@ -31,10 +31,32 @@ type HealthInfo struct {
 
 type ApplyInfo struct {
 	IsPruned bool
-	Message  string
-	Error    error
+	// Operation is what was done to the object, eg created, configured, unchanged, serverside-applied or pruned.
+	// It is empty if the apply or prune failed, or if the applier could not tell what was done.
+	Operation string
+	Message   string
+	Error     error
+	// Conflicts are the fields owned by other field managers, that were skipped or caused the apply to fail.
+	Conflicts []Conflict
+	// Retries is the number of times the apply was retried after a transient error.
+	Retries int
+	// Warnings are the warnings returned by the apiserver for the apply, eg for unknown fields.
+	Warnings []string
 }
 
+// Operations reported in ApplyInfo.Operation; these match the output of kubectl apply
+const (
+	OperationCreated           = "created"
+	OperationConfigured        = "configured"
+	OperationUnchanged         = "unchanged"
+	OperationServerSideApplied = "serverside-applied"
+	OperationPruned            = "pruned"
+	// OperationPruneSkipped is reported for objects that are no longer desired, but are protected from pruning
+	OperationPruneSkipped = "prune-skipped"
+	// OperationRecreated is reported for objects that were deleted and recreated, to change immutable fields
+	OperationRecreated = "recreated"
+)
+
 type ObjectStatus struct {
 	GVK           schema.GroupVersionKind
 	NameNamespace types.NamespacedName
@@ -52,6 +74,8 @@ type ApplyResults struct {
 	pruneFailCount    int
 	healthyCount      int
 	unhealthyCount    int
+	adoptedCount      int
+	retryCount        int
 	Objects           []ObjectStatus
 }
 
@@ -71,6 +95,73 @@ func (r *ApplyResults) AllHealthy() bool {
 	return r.unhealthyCount == 0
 }
 
+// AdoptedCount is the number of live objects that were adopted into the applyset, see Options.AdoptSelector.
+func (r *ApplyResults) AdoptedCount() int {
+	return r.adoptedCount
+}
+
+// RetryCount is the total number of times that applies were retried after transient errors.
+func (r *ApplyResults) RetryCount() int {
+	return r.retryCount
+}
+
+// NewApplyResults returns empty results for applying total objects, for appliers that do not use an ApplySet
+// and record the results with RecordApplied, RecordApplyError, RecordPruned and RecordPruneError.
+func NewApplyResults(total int) *ApplyResults {
+	return &ApplyResults{total: total}
+}
+
+// RecordApplied records that an object was applied by operation.
+// The health of the object is not checked, so it is reported as healthy.
+func (r *ApplyResults) RecordApplied(gvk schema.GroupVersionKind, nn types.NamespacedName, operation string, lastApplied *unstructured.Unstructured) {
+	r.applySuccess(gvk, nn)
+	r.reportHealth(gvk, nn, lastApplied, true, "", nil)
+	r.Objects[len(r.Objects)-1].Apply.Operation = operation
+}
+
+// RecordApplyError records that the apply of an object failed with an error.
+func (r *ApplyResults) RecordApplyError(gvk schema.GroupVersionKind, nn types.NamespacedName, err error) {
+	r.applyError(gvk, nn, err)
+}
+
+// RecordPruned records that an object was pruned.
+func (r *ApplyResults) RecordPruned(gvk schema.GroupVersionKind, nn types.NamespacedName) {
+	r.pruneSuccess(gvk, nn)
+}
+
+// RecordPruneError records that the prune of an object failed with an error.
+func (r *ApplyResults) RecordPruneError(gvk schema.GroupVersionKind, nn types.NamespacedName, err error) {
+	r.pruneError(gvk, nn, err)
+}
+
+// RecordPruneSkipped records that an object was not pruned, because it is protected.
+func (r *ApplyResults) RecordPruneSkipped(gvk schema.GroupVersionKind, nn types.NamespacedName, reason string) {
+	r.pruneSkipped(gvk, nn, reason)
+}
+
+// RecordRetries records that the apply of an object was retried, after the outcome of the apply has been recorded.
+func (r *ApplyResults) RecordRetries(gvk schema.GroupVersionKind, nn types.NamespacedName, retries int) {
+	r.retryCount += retries
+	for i := len(r.Objects) - 1; i >= 0; i-- {
+		obj := &r.Objects[i]
+		if obj.GVK == gvk && obj.NameNamespace == nn && !obj.Apply.IsPruned {
+			obj.Apply.Retries = retries
+			return
+		}
+	}
+}
+
+// RecordWarnings records the warnings returned by the apiserver for an object, after the outcome of the apply has been recorded.
+func (r *ApplyResults) RecordWarnings(gvk schema.GroupVersionKind, nn types.NamespacedName, warnings []string) {
+	for i := len(r.Objects) - 1; i >= 0; i-- {
+		obj := &r.Objects[i]
+		if obj.GVK == gvk && obj.NameNamespace == nn && !obj.Apply.IsPruned {
+			obj.Apply.Warnings = append(obj.Apply.Warnings, warnings...)
+			return
+		}
+	}
+}
+
 // checkInvariants is an internal function that warns if the object doesn't match the expected invariants.
 func (r *ApplyResults) checkInvariants() {
 	if r.total != (r.applySuccessCount + r.applyFailCount) {
@@ -82,6 +173,7 @@ func (r *ApplyResults) checkInvariants() {
 }
 
 // applyError records that the apply of an object failed with an error.
+// Any server-side apply conflicts are parsed from the error.
 func (r *ApplyResults) applyError(gvk schema.GroupVersionKind, nn types.NamespacedName, err error) {
 	r.applyFailCount++
 	r.Objects = append(r.Objects, ObjectStatus{
@@ -91,9 +183,10 @@ func (r *ApplyResults) applyError(gvk schema.GroupVersionKind, nn types.Namespac
 			IsHealthy: false,
 		},
 		Apply: ApplyInfo{
-			IsPruned: false,
-			Message:  "Apply Error",
-			Error:    err,
+			IsPruned:  false,
+			Message:   "Apply Error",
+			Error:     err,
+			Conflicts: ParseConflicts(err),
 		},
 	})
 	klog.Warningf("error from apply on %s %s: %v", gvk, nn, err)
@@ -131,7 +224,8 @@ func (r *ApplyResults) pruneSuccess(gvk schema.GroupVersionKind, nn types.Namesp
 			IsHealthy: true,
 		},
 		Apply: ApplyInfo{
-			IsPruned: true,
+			IsPruned:  true,
+			Operation: OperationPruned,
 		},
 	})
 	r.pruneSuccessCount++
@@ -158,3 +252,24 @@ func (r *ApplyResults) reportHealth(gvk schema.GroupVersionKind, nn types.Namesp
 		r.unhealthyCount++
 	}
 }
+
+// pruneSkipped records that an object was not pruned, because it is protected.
+func (r *ApplyResults) pruneSkipped(gvk schema.GroupVersionKind, nn types.NamespacedName, reason string) {
+	r.Objects = append(r.Objects, ObjectStatus{
+		GVK:           gvk,
+		NameNamespace: nn,
+		Health: HealthInfo{
+			IsHealthy: true,
+		},
+		Apply: ApplyInfo{
+			IsPruned:  false,
+			Operation: OperationPruneSkipped,
+			Message:   reason,
+		},
+	})
+}
+
+// adopted records that an object was adopted into the applyset.
+func (r *ApplyResults) adopted() {
+	r.adoptedCount++
+}
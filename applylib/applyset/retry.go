@@ -0,0 +1,130 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applyset
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// DefaultRetryPolicy is the RetryPolicy used if Options.RetryPolicy is not set.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     3,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+// RetryPolicy controls how the apply of an individual object is retried after a transient error,
+// eg throttling, a timeout or an unavailable webhook. Other errors, eg validation errors, are never retried.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries for each object; a negative value disables retries.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry; the delay doubles on each retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between retries, including delays requested by the server with Retry-After.
+	MaxBackoff time.Duration
+}
+
+// IsRetryable is true for transient errors, for which retrying the same request may succeed.
+func IsRetryable(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case apierrors.IsTooManyRequests(err),
+		apierrors.IsServerTimeout(err),
+		apierrors.IsTimeout(err),
+		apierrors.IsServiceUnavailable(err):
+		return true
+	case apierrors.IsInternalError(err):
+		// An InternalError is returned when a webhook cannot be called, but also for deterministic failures
+		return isTransientInternalError(err)
+	case apierrors.IsConflict(err):
+		// Conflicts with other field managers will not resolve themselves, but optimistic-lock conflicts may
+		return len(ParseConflicts(err)) == 0
+	case utilnet.IsConnectionReset(err), utilnet.IsProbableEOF(err), utilnet.IsHTTP2ConnectionLost(err):
+		return true
+	default:
+		return false
+	}
+}
+
+// transientInternalErrors are the messages of the InternalErrors that may succeed on retry,
+// eg when a webhook is unreachable or times out.
+var transientInternalErrors = []string{
+	"connection refused",
+	"connection reset",
+	"no endpoints available",
+	"context deadline exceeded",
+	"Client.Timeout exceeded",
+	"i/o timeout",
+	"timed out",
+	": EOF",
+}
+
+func isTransientInternalError(err error) bool {
+	message := err.Error()
+	for _, transient := range transientInternalErrors {
+		if strings.Contains(message, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// Retry calls fn until it succeeds, it returns an error that is not retryable, or the retries are exhausted.
+// It returns the number of retries, along with the last error from fn.
+func (p RetryPolicy) Retry(ctx context.Context, fn func() error) (int, error) {
+	backoff := wait.Backoff{
+		Duration: p.InitialBackoff,
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    p.MaxRetries,
+		Cap:      p.MaxBackoff,
+	}
+
+	retries := 0
+	for {
+		err := fn()
+		if err == nil || !IsRetryable(err) || retries >= p.MaxRetries {
+			return retries, err
+		}
+
+		delay := backoff.Step()
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok {
+			if suggested := time.Duration(seconds) * time.Second; suggested > delay {
+				delay = suggested
+			}
+		}
+		if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+			delay = p.MaxBackoff
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return retries, err
+		case <-timer.C:
+		}
+		retries++
+	}
+}
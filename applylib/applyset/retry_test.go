@@ -0,0 +1,165 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applyset
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/testutils"
+)
+
+func TestIsRetryable(t *testing.T) {
+	configMaps := schema.GroupResource{Resource: "configmaps"}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "throttled", err: apierrors.NewTooManyRequests("slow down", 1), want: true},
+		{name: "timeout", err: apierrors.NewTimeoutError("timed out", 1), want: true},
+		{name: "webhook unreachable", err: apierrors.NewInternalError(errors.New(`failed calling webhook "validate.example.com": failed to call webhook: Post "https://webhook.default.svc:443/validate": dial tcp 10.0.0.1:443: connect: connection refused`)), want: true},
+		{name: "webhook without endpoints", err: apierrors.NewInternalError(errors.New(`failed calling webhook "validate.example.com": failed to call webhook: Post "https://webhook.default.svc:443/validate": no endpoints available for service "webhook"`)), want: true},
+		{name: "webhook timeout", err: apierrors.NewInternalError(errors.New(`failed calling webhook "validate.example.com": failed to call webhook: Post "https://webhook.default.svc:443/validate?timeout=10s": context deadline exceeded`)), want: true},
+		{name: "webhook failure", err: apierrors.NewInternalError(errors.New(`failed calling webhook "validate.example.com": failed to call webhook: Post "https://webhook.default.svc:443/validate?timeout=10s": x509: certificate signed by unknown authority`))},
+		{name: "internal error", err: apierrors.NewInternalError(errors.New("unable to convert object"))},
+		{name: "unavailable", err: apierrors.NewServiceUnavailable("unavailable"), want: true},
+		{name: "optimistic lock", err: apierrors.NewConflict(configMaps, "foo", errors.New("the object has been modified")), want: true},
+		{name: "apply conflict", err: apierrors.NewApplyConflict([]metav1.StatusCause{{Type: metav1.CauseTypeFieldManagerConflict, Field: ".data"}}, "conflict")},
+		{name: "invalid", err: apierrors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "foo", field.ErrorList{field.Required(field.NewPath("data"), "")})},
+		{name: "forbidden", err: apierrors.NewForbidden(configMaps, "foo", errors.New("denied"))},
+		{name: "other", err: errors.New("some error")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsRetryable(test.err); got != test.want {
+				t.Errorf("IsRetryable(%v) returned %v, want %v", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyRetry(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	throttled := apierrors.NewTooManyRequests("slow down", 0)
+	invalid := apierrors.NewBadRequest("invalid")
+
+	tests := []struct {
+		name        string
+		policy      RetryPolicy
+		errs        []error
+		wantRetries int
+		wantErr     error
+	}{
+		{name: "success", policy: policy, errs: []error{nil}},
+		{name: "retried", policy: policy, errs: []error{throttled, throttled, nil}, wantRetries: 2},
+		{name: "exhausted", policy: policy, errs: []error{throttled, throttled, throttled, nil}, wantRetries: 2, wantErr: throttled},
+		{name: "not retryable", policy: policy, errs: []error{throttled, invalid, nil}, wantRetries: 1, wantErr: invalid},
+		{name: "disabled", policy: RetryPolicy{MaxRetries: -1}, errs: []error{throttled, nil}, wantErr: throttled},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			retries, err := test.policy.Retry(context.Background(), func() error {
+				err := test.errs[calls]
+				calls++
+				return err
+			})
+			if retries != test.wantRetries {
+				t.Errorf("unexpected retries, got %d, want %d", retries, test.wantRetries)
+			}
+			if err != test.wantErr {
+				t.Errorf("unexpected error, got %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplySetRetriesTransientErrors(t *testing.T) {
+	h := testutils.NewHarness(t)
+
+	parentYAML := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  namespace: default
+`
+
+	apply := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+  namespace: default
+data:
+  foo: bar
+`
+
+	h.WithObjects(h.ParseObjects(parentYAML)...)
+
+	parent := h.ParseObjects(parentYAML)[0]
+	parentGVK := parent.GroupVersionKind()
+	restmapping, err := h.RESTMapper().RESTMapping(parentGVK.GroupKind(), parentGVK.Version)
+	if err != nil {
+		h.Fatalf("error building parent restmapping: %v", err)
+	}
+
+	throttled := apierrors.NewTooManyRequests("slow down", 0)
+	dynamicClient := dynamicClientWithErrors(h, "/api/v1/namespaces/default/configmaps/foo", throttled, 2)
+
+	force := true
+	s, err := New(Options{
+		Parent:       NewParentRef(parent, "test", "default", restmapping),
+		RESTMapper:   h.RESTMapper(),
+		Client:       dynamicClient,
+		ParentClient: h.Client(),
+		PatchOptions: metav1.PatchOptions{FieldManager: "test", Force: &force},
+		RetryPolicy:  RetryPolicy{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond},
+	})
+	if err != nil {
+		h.Fatalf("error building applyset object: %v", err)
+	}
+
+	var applyableObjects []ApplyableObject
+	for _, object := range h.ParseObjects(apply) {
+		applyableObjects = append(applyableObjects, object)
+	}
+	if err := s.SetDesiredObjects(applyableObjects); err != nil {
+		h.Fatalf("failed to set desired objects: %v", err)
+	}
+
+	results, err := s.ApplyOnce(h.Ctx)
+	if err != nil {
+		h.Fatalf("failed to apply objects: %v", err)
+	}
+	if !results.AllApplied() {
+		h.Fatalf("not all objects were applied: %+v", results.Objects)
+	}
+	if got, want := results.RetryCount(), 2; got != want {
+		h.Errorf("unexpected retry count, got %d, want %d", got, want)
+	}
+	if got, want := results.Objects[0].Apply.Retries, 2; got != want {
+		h.Errorf("unexpected retries for object, got %d, want %d", got, want)
+	}
+}
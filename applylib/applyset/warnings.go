@@ -0,0 +1,96 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applyset
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+)
+
+// WarningRecorder is a rest.WarningHandler that collects the warnings returned by the apiserver,
+// eg for unknown fields with fieldValidation=Warn, or for deprecated APIs.
+type WarningRecorder struct {
+	mutex    sync.Mutex
+	warnings []string
+}
+
+var _ rest.WarningHandler = &WarningRecorder{}
+
+// NewWarningRecorder returns a WarningRecorder, to be set as the WarningHandler of the rest.Config of a client.
+func NewWarningRecorder() *WarningRecorder {
+	return &WarningRecorder{}
+}
+
+// HandleWarningHeader implements rest.WarningHandler.
+func (r *WarningRecorder) HandleWarningHeader(code int, agent string, text string) {
+	// Like rest.WarningLogger, we only handle the warnings defined by RFC 7234
+	if code != 299 || len(text) == 0 {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.warnings = append(r.warnings, text)
+}
+
+// Take returns the warnings recorded since the last call, clearing them.
+func (r *WarningRecorder) Take() []string {
+	if r == nil {
+		return nil
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	warnings := r.warnings
+	r.warnings = nil
+	return warnings
+}
+
+type warningRecorderKey struct{}
+
+// ContextWithWarningRecorder returns a context in which the warnings returned by the apiserver are recorded by recorder,
+// for requests made with a client whose transport is wrapped with WrapTransportForWarnings.
+func ContextWithWarningRecorder(ctx context.Context, recorder *WarningRecorder) context.Context {
+	return context.WithValue(ctx, warningRecorderKey{}, recorder)
+}
+
+// WrapTransportForWarnings wraps the transport of a client, so that the warnings returned by the apiserver are recorded
+// by the WarningRecorder of the request context, if any. This lets a cached client record the warnings of each apply;
+// it is typically installed with rest.Config.Wrap.
+func WrapTransportForWarnings(rt http.RoundTripper) http.RoundTripper {
+	return &warningTransport{next: rt}
+}
+
+type warningTransport struct {
+	next http.RoundTripper
+}
+
+func (t *warningTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	response, err := t.next.RoundTrip(req)
+	if err != nil {
+		return response, err
+	}
+	if recorder, ok := req.Context().Value(warningRecorderKey{}).(*WarningRecorder); ok && recorder != nil {
+		warnings, _ := utilnet.ParseWarningHeaders(response.Header["Warning"])
+		for _, warning := range warnings {
+			recorder.HandleWarningHeader(warning.Code, warning.Agent, warning.Text)
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applyset
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/testutils"
+)
+
+func TestWarningRecorder(t *testing.T) {
+	r := NewWarningRecorder()
+	r.HandleWarningHeader(299, "", `unknown field "spec.foo"`)
+	r.HandleWarningHeader(199, "", "miscellaneous warning")
+	r.HandleWarningHeader(299, "", "")
+
+	if got, want := r.Take(), []string{`unknown field "spec.foo"`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected warnings; got %q, want %q", got, want)
+	}
+	if got := r.Take(); got != nil {
+		t.Errorf("expected warnings to be cleared, got %q", got)
+	}
+
+	var nilRecorder *WarningRecorder
+	if got := nilRecorder.Take(); got != nil {
+		t.Errorf("expected no warnings from nil recorder, got %q", got)
+	}
+}
+
+// warningInjector adds a warning header to the responses to applies to a path, as the mock apiserver does not return warnings.
+type warningInjector struct {
+	next    http.RoundTripper
+	path    string
+	warning string
+}
+
+func (i *warningInjector) RoundTrip(req *http.Request) (*http.Response, error) {
+	response, err := i.next.RoundTrip(req)
+	if err == nil && req.Method == http.MethodPatch && req.URL.Path == i.path {
+		response.Header.Add("Warning", "299 - "+strconv.Quote(i.warning))
+	}
+	return response, err
+}
+
+func TestApplySetRecordsWarnings(t *testing.T) {
+	parentYAML := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  namespace: default
+`
+
+	apply := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+  namespace: default
+data:
+  foo: bar
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: bar
+  namespace: default
+data:
+  foo: bar
+`
+
+	for _, sharedClient := range []bool{false, true} {
+		name := "warning handler"
+		if sharedClient {
+			name = "shared client"
+		}
+		t.Run(name, func(t *testing.T) {
+			h := testutils.NewHarness(t)
+
+			h.WithObjects(h.ParseObjects(parentYAML)...)
+
+			parent := h.ParseObjects(parentYAML)[0]
+			parentGVK := parent.GroupVersionKind()
+			restmapping, err := h.RESTMapper().RESTMapping(parentGVK.GroupKind(), parentGVK.Version)
+			if err != nil {
+				h.Fatalf("error building parent restmapping: %v", err)
+			}
+
+			warnings := NewWarningRecorder()
+			restConfig := rest.CopyConfig(h.RESTConfig())
+			restConfig.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+				return &warningInjector{next: rt, path: "/api/v1/namespaces/default/configmaps/foo", warning: `unknown field "spec"`}
+			})
+			if sharedClient {
+				// The client is not built for the apply, so the warnings are recorded through the context
+				restConfig.Wrap(WrapTransportForWarnings)
+			} else {
+				restConfig.WarningHandler = warnings
+			}
+			dynamicClient, err := dynamic.NewForConfig(restConfig)
+			if err != nil {
+				h.Fatalf("error building dynamic client: %v", err)
+			}
+
+			force := true
+			s, err := New(Options{
+				Parent:       NewParentRef(parent, "test", "default", restmapping),
+				RESTMapper:   h.RESTMapper(),
+				Client:       dynamicClient,
+				ParentClient: h.Client(),
+				PatchOptions: metav1.PatchOptions{FieldManager: "test", Force: &force},
+				Warnings:     warnings,
+			})
+			if err != nil {
+				h.Fatalf("error building applyset object: %v", err)
+			}
+
+			var applyableObjects []ApplyableObject
+			for _, object := range h.ParseObjects(apply) {
+				applyableObjects = append(applyableObjects, object)
+			}
+			if err := s.SetDesiredObjects(applyableObjects); err != nil {
+				h.Fatalf("failed to set desired objects: %v", err)
+			}
+
+			results, err := s.ApplyOnce(h.Ctx)
+			if err != nil {
+				h.Fatalf("failed to apply objects: %v", err)
+			}
+			if !results.AllApplied() {
+				h.Fatalf("not all objects were applied: %+v", results.Objects)
+			}
+
+			got := make(map[string][]string)
+			for _, obj := range results.Objects {
+				got[obj.NameNamespace.Name] = obj.Apply.Warnings
+			}
+			want := map[string][]string{
+				"foo": {`unknown field "spec"`},
+				"bar": nil,
+			}
+			if !reflect.DeepEqual(got, want) {
+				h.Errorf("unexpected warnings; got %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -86,7 +86,7 @@ func (in *GuestbookList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GuestbookSpec) DeepCopyInto(out *GuestbookSpec) {
 	*out = *in
-	out.CommonSpec = in.CommonSpec
+	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
 	in.PatchSpec.DeepCopyInto(&out.PatchSpec)
 }
 
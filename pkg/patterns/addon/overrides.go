@@ -0,0 +1,241 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	addonsv1alpha1 "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/apis/v1alpha1"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+// ApplyComponentOverrides is an ObjectTransform to apply ComponentOverrides specified on the Addon object to the manifest.
+// It returns an error if an override refers to a workload or container that is not in the manifest.
+// This transform requires the DeclarativeObject to implement addonsv1alpha1.ComponentOverridable
+func ApplyComponentOverrides(ctx context.Context, object declarative.DeclarativeObject, objects *manifest.Objects) error {
+	log := log.FromContext(ctx)
+
+	var spec addonsv1alpha1.ComponentOverridesSpec
+	if unstruct, ok := object.(*unstructured.Unstructured); ok {
+		overrides, _, err := unstructured.NestedSlice(unstruct.Object, "spec", "componentOverrides")
+		if err != nil {
+			return fmt.Errorf("unable to get componentOverrides from unstructured: %v", err)
+		}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(map[string]interface{}{"componentOverrides": overrides}, &spec); err != nil {
+			return fmt.Errorf("unable to parse componentOverrides: %v", err)
+		}
+	} else if o, ok := object.(addonsv1alpha1.ComponentOverridable); ok {
+		spec = o.ComponentOverridesSpec()
+	} else {
+		return fmt.Errorf("provided object (%T) does not implement ComponentOverridable type", object)
+	}
+
+	var errs []string
+	for _, override := range spec.ComponentOverrides {
+		id := override.Name
+		if override.Namespace != "" {
+			id = override.Namespace + "/" + override.Name
+		}
+
+		var targets []*manifest.Object
+		for _, obj := range objects.Items {
+			if obj.Kind != override.Kind || obj.GetName() != override.Name {
+				continue
+			}
+			if override.Namespace != "" && obj.GetNamespace() != override.Namespace {
+				continue
+			}
+			targets = append(targets, obj)
+		}
+		if len(targets) == 0 {
+			errs = append(errs, fmt.Sprintf("%s %q not found in manifest", override.Kind, id))
+			continue
+		}
+		if len(targets) > 1 {
+			errs = append(errs, fmt.Sprintf("%s %q matches %d objects in manifest, set the namespace of the override", override.Kind, id, len(targets)))
+			continue
+		}
+
+		log.WithValues("kind", override.Kind, "namespace", override.Namespace, "name", override.Name).V(1).Info("applying component override")
+		if err := applyComponentOverride(targets[0], override); err != nil {
+			errs = append(errs, fmt.Sprintf("%s %q: %v", override.Kind, id, err))
+		}
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("error applying component overrides: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func applyComponentOverride(obj *manifest.Object, override addonsv1alpha1.ComponentOverride) error {
+	if override.Replicas != nil {
+		switch obj.Kind {
+		case "Deployment", "StatefulSet", "ReplicaSet", "ReplicationController":
+			if err := obj.SetNestedField(int64(*override.Replicas), "spec", "replicas"); err != nil {
+				return fmt.Errorf("error setting replicas: %v", err)
+			}
+		default:
+			return fmt.Errorf("replicas cannot be set on kind %s", obj.Kind)
+		}
+	}
+
+	if err := obj.MutatePodSpec(func(podSpec map[string]interface{}) error {
+		if len(override.NodeSelector) != 0 {
+			nodeSelector, _, err := unstructured.NestedStringMap(podSpec, "nodeSelector")
+			if err != nil {
+				return fmt.Errorf("error reading nodeSelector: %v", err)
+			}
+			if nodeSelector == nil {
+				nodeSelector = make(map[string]string)
+			}
+			for k, v := range override.NodeSelector {
+				nodeSelector[k] = v
+			}
+			if err := unstructured.SetNestedStringMap(podSpec, nodeSelector, "nodeSelector"); err != nil {
+				return fmt.Errorf("error setting nodeSelector: %v", err)
+			}
+		}
+
+		if len(override.Tolerations) != 0 {
+			tolerations, _, err := unstructured.NestedSlice(podSpec, "tolerations")
+			if err != nil {
+				return fmt.Errorf("error reading tolerations: %v", err)
+			}
+			for _, t := range override.Tolerations {
+				u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&t)
+				if err != nil {
+					return fmt.Errorf("error converting toleration: %v", err)
+				}
+				tolerations = append(tolerations, u)
+			}
+			if err := unstructured.SetNestedSlice(podSpec, tolerations, "tolerations"); err != nil {
+				return fmt.Errorf("error setting tolerations: %v", err)
+			}
+		}
+
+		if override.Affinity != nil {
+			u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(override.Affinity)
+			if err != nil {
+				return fmt.Errorf("error converting affinity: %v", err)
+			}
+			podSpec["affinity"] = u
+		}
+
+		if override.PriorityClassName != "" {
+			podSpec["priorityClassName"] = override.PriorityClassName
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if len(override.Containers) == 0 {
+		return nil
+	}
+
+	found := make(map[string]bool)
+	if err := obj.MutateContainers(func(container map[string]interface{}) error {
+		name, _, _ := unstructured.NestedString(container, "name")
+		for _, co := range override.Containers {
+			if co.Name != name {
+				continue
+			}
+			found[name] = true
+			if err := applyContainerOverride(container, co); err != nil {
+				return fmt.Errorf("container %q: %v", name, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, co := range override.Containers {
+		if !found[co.Name] {
+			return fmt.Errorf("container %q not found", co.Name)
+		}
+	}
+	return nil
+}
+
+func applyContainerOverride(container map[string]interface{}, override addonsv1alpha1.ContainerOverride) error {
+	if override.Resources != nil {
+		resources, err := runtime.DefaultUnstructuredConverter.ToUnstructured(override.Resources)
+		if err != nil {
+			return fmt.Errorf("error converting resources: %v", err)
+		}
+		for _, field := range []string{"limits", "requests"} {
+			values, _, err := unstructured.NestedMap(resources, field)
+			if err != nil {
+				return fmt.Errorf("error converting resources: %v", err)
+			}
+			if len(values) == 0 {
+				continue
+			}
+
+			existing, _, err := unstructured.NestedMap(container, "resources", field)
+			if err != nil {
+				return fmt.Errorf("error reading resources: %v", err)
+			}
+			if existing == nil {
+				existing = make(map[string]interface{})
+			}
+			for k, v := range values {
+				existing[k] = v
+			}
+			if err := unstructured.SetNestedMap(container, existing, "resources", field); err != nil {
+				return fmt.Errorf("error setting resources: %v", err)
+			}
+		}
+	}
+
+	if len(override.Env) != 0 {
+		env, _, err := unstructured.NestedSlice(container, "env")
+		if err != nil {
+			return fmt.Errorf("error reading env: %v", err)
+		}
+		for _, e := range override.Env {
+			u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&e)
+			if err != nil {
+				return fmt.Errorf("error converting env: %v", err)
+			}
+			replaced := false
+			for i, existing := range env {
+				if m, ok := existing.(map[string]interface{}); ok && m["name"] == e.Name {
+					env[i] = u
+					replaced = true
+				}
+			}
+			if !replaced {
+				env = append(env, u)
+			}
+		}
+		if err := unstructured.SetNestedSlice(container, env, "env"); err != nil {
+			return fmt.Errorf("error setting env: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,338 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+func TestApplyComponentOverrides(t *testing.T) {
+	inputManifest := `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller
+spec:
+  replicas: 1
+  template:
+    spec:
+      nodeSelector:
+        kubernetes.io/os: linux
+      containers:
+      - name: manager
+        image: controller:v1
+        env:
+        - name: LOG_LEVEL
+          value: info
+        resources:
+          requests:
+            cpu: 100m
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+spec:
+  template:
+    spec:
+      containers:
+      - name: agent
+        image: agent:v1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+  namespace: a
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: worker
+        image: worker:v1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+  namespace: b
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: worker
+        image: worker:v1`
+
+	var testCases = []struct {
+		name             string
+		spec             string
+		expectedManifest string
+		expectedError    string
+	}{
+		{
+			name: "overrides",
+			spec: `
+componentOverrides:
+- kind: Deployment
+  name: controller
+  replicas: 3
+  nodeSelector:
+    pool: system
+  tolerations:
+  - key: dedicated
+    operator: Equal
+    value: system
+    effect: NoSchedule
+  priorityClassName: system-cluster-critical
+  containers:
+  - name: manager
+    resources:
+      requests:
+        memory: 128Mi
+      limits:
+        memory: 256Mi
+    env:
+    - name: LOG_LEVEL
+      value: debug
+    - name: EXTRA
+      value: "1"
+`,
+			expectedManifest: `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller
+spec:
+  replicas: 3
+  template:
+    spec:
+      nodeSelector:
+        kubernetes.io/os: linux
+        pool: system
+      tolerations:
+      - key: dedicated
+        operator: Equal
+        value: system
+        effect: NoSchedule
+      priorityClassName: system-cluster-critical
+      containers:
+      - name: manager
+        image: controller:v1
+        env:
+        - name: LOG_LEVEL
+          value: debug
+        - name: EXTRA
+          value: "1"
+        resources:
+          requests:
+            cpu: 100m
+            memory: 128Mi
+          limits:
+            memory: 256Mi
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+spec:
+  template:
+    spec:
+      containers:
+      - name: agent
+        image: agent:v1`,
+		},
+		{
+			name: "namespaced override",
+			spec: `
+componentOverrides:
+- kind: Deployment
+  name: worker
+  namespace: b
+  replicas: 2
+`,
+			expectedManifest: `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller
+spec:
+  replicas: 1
+  template:
+    spec:
+      nodeSelector:
+        kubernetes.io/os: linux
+      containers:
+      - name: manager
+        image: controller:v1
+        env:
+        - name: LOG_LEVEL
+          value: info
+        resources:
+          requests:
+            cpu: 100m
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+spec:
+  template:
+    spec:
+      containers:
+      - name: agent
+        image: agent:v1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+  namespace: a
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: worker
+        image: worker:v1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+  namespace: b
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: worker
+        image: worker:v1`,
+		},
+		{
+			name: "ambiguous workload",
+			spec: `
+componentOverrides:
+- kind: Deployment
+  name: worker
+  replicas: 2
+`,
+			expectedError: `Deployment "worker" matches 2 objects in manifest`,
+		},
+		{
+			name: "workload in another namespace",
+			spec: `
+componentOverrides:
+- kind: Deployment
+  name: controller
+  namespace: b
+  replicas: 2
+`,
+			expectedError: `Deployment "b/controller" not found in manifest`,
+		},
+		{
+			name: "missing workload",
+			spec: `
+componentOverrides:
+- kind: Deployment
+  name: missing
+  replicas: 3
+`,
+			expectedError: `Deployment "missing" not found in manifest`,
+		},
+		{
+			name: "missing container",
+			spec: `
+componentOverrides:
+- kind: DaemonSet
+  name: agent
+  containers:
+  - name: sidecar
+    env:
+    - name: FOO
+      value: bar
+`,
+			expectedError: `container "sidecar" not found`,
+		},
+		{
+			name: "replicas on daemonset",
+			spec: `
+componentOverrides:
+- kind: DaemonSet
+  name: agent
+  replicas: 2
+`,
+			expectedError: "replicas cannot be set on kind DaemonSet",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			spec := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(tc.spec), &spec); err != nil {
+				t.Fatalf("error parsing spec: %v", err)
+			}
+			instance := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "addons.example.org/v1alpha1",
+				"kind":       "TestAddon",
+				"metadata":   map[string]interface{}{"name": "test"},
+				"spec":       spec,
+			}}
+
+			objects, err := manifest.ParseObjects(ctx, inputManifest)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err = ApplyComponentOverrides(ctx, instance, objects)
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expectedObjects, err := manifest.ParseObjects(ctx, tc.expectedManifest)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for idx := range expectedObjects.Items {
+				diff := cmp.Diff(
+					expectedObjects.Items[idx].UnstructuredObject().Object,
+					objects.Items[idx].UnstructuredObject().Object)
+				if diff != "" {
+					t.Errorf("result mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
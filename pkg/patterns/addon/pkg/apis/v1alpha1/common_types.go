@@ -34,23 +34,82 @@ type CommonObject interface {
 
 // CommonSpec defines the set of configuration attributes that must be exposed on all addons.
 type CommonSpec struct {
-	// Version specifies the exact addon version to be deployed, eg 1.2.3
-	// It should not be specified if Channel is specified
+	// Version specifies the exact addon version to be deployed, eg 1.2.3,
+	// or a constraint resolved to the highest satisfying version in the channel, eg ~1.2, ^2.0 or >=1.4 <1.6
 	Version string `json:"version,omitempty"`
 	// Channel specifies a channel that can be used to resolve a specific addon, eg: stable
-	// It will be ignored if Version is specified
+	// It will be ignored if Version is an exact version
 	Channel string `json:"channel,omitempty"`
+	// UpgradePolicy controls how versions from the channel are rolled out once the addon is deployed:
+	// Automatic (the default) upgrades as soon as a new version is available,
+	// Manual holds the deployed version until the available version is approved in ApprovedVersion,
+	// and Pinned never upgrades the deployed version.
+	// +kubebuilder:validation:Enum=Automatic;Manual;Pinned
+	UpgradePolicy UpgradePolicy `json:"upgradePolicy,omitempty"`
+	// ApprovedVersion approves upgrades up to and including this version, when UpgradePolicy is Manual.
+	// The addon is still upgraded one permissible step at a time.
+	ApprovedVersion string `json:"approvedVersion,omitempty"`
+	// Variant selects a flavor of the addon, eg aws or nginx.
+	// Only versions that declare the variant in the channel are installed.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([a-z0-9.-]*[a-z0-9])?$`
+	Variant string `json:"variant,omitempty"`
+	// Packages lists the packages of a composite addon, overriding or adding to the packages
+	// that the channel declares for the version.  When the channel does not declare packages,
+	// the addon's own package is included along with these.
+	// +listType=map
+	// +listMapKey=name
+	Packages []PackageSpec `json:"packages,omitempty"`
 }
 
+// PackageSpec selects a package of a composite addon
+type PackageSpec struct {
+	// Name is the name of the package
+	Name string `json:"name"`
+	// Version is the exact version of the package, or a constraint resolved against the channel, eg ~1.2.
+	// It defaults to the version declared by the channel, or else the latest version in the channel.
+	Version string `json:"version,omitempty"`
+	// Enabled includes or excludes the package; optional packages are only included when enabled
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// UpgradePolicy controls how versions from the channel are rolled out
+type UpgradePolicy string
+
+const (
+	UpgradePolicyAutomatic UpgradePolicy = "Automatic"
+	UpgradePolicyManual    UpgradePolicy = "Manual"
+	UpgradePolicyPinned    UpgradePolicy = "Pinned"
+)
+
 // CommonStatus is a set of status attributes that must be exposed on all addons.
 type CommonStatus struct {
 	Healthy bool     `json:"healthy"`
 	Errors  []string `json:"errors,omitempty"`
-	Phase   string   `json:"phase,omitempty"`
+	// Warnings are non-fatal problems encountered during the last reconciliation
+	Warnings []string `json:"warnings,omitempty"`
+	Phase    string   `json:"phase,omitempty"`
+	// DeployedVersion is the version of the addon that was last applied.
+	// When resolving from a channel, upgrades proceed one permissible step at a time from this version.
+	DeployedVersion string `json:"deployedVersion,omitempty"`
+	// DeployedRevision is the revision of the repository the last applied manifest was loaded from,
+	// eg the git commit SHA or OCI artifact digest, if the repository reports one
+	DeployedRevision string `json:"deployedRevision,omitempty"`
+	// AvailableVersion is the next version the addon can be upgraded to, if any
+	AvailableVersion string `json:"availableVersion,omitempty"`
+	// Packages are the versions of the packages of a composite addon that were last applied
+	// +listType=map
+	// +listMapKey=name
+	Packages []PackageStatus `json:"packages,omitempty"`
 	// +kubebuilder:default:=0
 	ObservedGeneration int64 `json:"observedGeneration"`
 }
 
+// PackageStatus is the version of a package of a composite addon
+type PackageStatus struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
 // Patchable is a trait for addon CRDs that expose a raw set of Patches to be
 // applied to the declarative manifest.
 type Patchable interface {
@@ -0,0 +1,67 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ComponentOverridable is implemented by addon objects that support ComponentOverrides
+type ComponentOverridable interface {
+	ComponentOverridesSpec() ComponentOverridesSpec
+}
+
+type ComponentOverridesSpec struct {
+	// ComponentOverrides customizes the workloads in the rendered manifest
+	ComponentOverrides []ComponentOverride `json:"componentOverrides,omitempty"`
+}
+
+// ComponentOverride customizes a single workload in the rendered manifest
+type ComponentOverride struct {
+	// Kind is the kind of the workload, eg Deployment
+	Kind string `json:"kind"`
+	// Name is the name of the workload
+	Name string `json:"name"`
+	// Namespace is the namespace of the workload; it is only needed if the manifest has workloads
+	// of the same kind and name in more than one namespace.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Replicas overrides the number of replicas
+	Replicas *int32 `json:"replicas,omitempty"`
+	// NodeSelector is merged into the pod nodeSelector
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations are appended to the pod tolerations
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// Affinity replaces the pod affinity
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// PriorityClassName overrides the pod priorityClassName
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Containers customizes individual containers (or init containers) of the workload
+	Containers []ContainerOverride `json:"containers,omitempty"`
+}
+
+// ContainerOverride customizes a single container in a workload
+type ContainerOverride struct {
+	// Name is the name of the container
+	Name string `json:"name"`
+
+	// Resources are merged into the container resources, replacing any requests or limits with the same resource name
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// Env is merged into the container env, replacing any variables with the same name
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+)
+
+// DefaultChannelTTL is how long a cached channel is used before it is revalidated
+const DefaultChannelTTL = 5 * time.Minute
+
+var (
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: "declarative_manifest_cache",
+		Name:      "requests_total",
+		Help:      "Requests to the manifest cache, by kind (channel or package) and result (hit, miss or stale)",
+	}, []string{"kind", "result"})
+
+	cacheMetricsRegisterOnce sync.Once
+)
+
+// CacheOptions configures a CachingRepository
+type CacheOptions struct {
+	// Dir, if set, persists package contents on disk so they survive restarts.
+	// Package contents are stored by digest, so Dir can be shared between repositories;
+	// the package index is keyed by name and version, so it should not be shared
+	// between repositories that publish different contents under the same version.
+	Dir string
+
+	// ChannelTTL is how long a channel is used before it is reloaded; defaults to DefaultChannelTTL.
+	ChannelTTL time.Duration
+}
+
+// CachingRepository wraps a Repository, caching channels and packages.
+//
+// Package versions are assumed to be immutable, so they are cached forever, by digest.
+// Channels are reloaded after ChannelTTL (HTTPRepository revalidates them with ETag / If-Modified-Since).
+// If reloading a channel fails, the stale channel is served and a warning is recorded.
+type CachingRepository struct {
+	repo    Repository
+	options CacheOptions
+
+	mutex    sync.Mutex
+	channels map[string]*cachedChannel
+	packages map[string]map[string]string
+	blobs    map[string]string
+}
+
+type cachedChannel struct {
+	channel *Channel
+	fetched time.Time
+}
+
+var _ Repository = &CachingRepository{}
+var _ RevisionReader = &CachingRepository{}
+
+// NewCachingRepository is the constructor for a CachingRepository
+func NewCachingRepository(repo Repository, options CacheOptions) (*CachingRepository, error) {
+	if options.ChannelTTL == 0 {
+		options.ChannelTTL = DefaultChannelTTL
+	}
+	if options.Dir != "" {
+		for _, dir := range []string{"blobs", "packages"} {
+			if err := os.MkdirAll(filepath.Join(options.Dir, dir), 0755); err != nil {
+				return nil, fmt.Errorf("error creating cache directory: %w", err)
+			}
+		}
+	}
+
+	cacheMetricsRegisterOnce.Do(func() {
+		if err := metrics.Registry.Register(cacheRequests); err != nil {
+			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+				panic(err)
+			}
+		}
+	})
+
+	return &CachingRepository{
+		repo:     repo,
+		options:  options,
+		channels: make(map[string]*cachedChannel),
+		packages: make(map[string]map[string]string),
+		blobs:    make(map[string]string),
+	}, nil
+}
+
+// Revision returns the revision of the wrapped repository, or "" if it does not report one
+func (r *CachingRepository) Revision(ctx context.Context) (string, error) {
+	reader, ok := r.repo.(RevisionReader)
+	if !ok {
+		return "", nil
+	}
+	return reader.Revision(ctx)
+}
+
+func (r *CachingRepository) LoadChannel(ctx context.Context, name string) (*Channel, error) {
+	r.mutex.Lock()
+	cached := r.channels[name]
+	r.mutex.Unlock()
+
+	if cached != nil && time.Since(cached.fetched) < r.options.ChannelTTL {
+		cacheRequests.WithLabelValues("channel", "hit").Inc()
+		return cached.channel, nil
+	}
+
+	channel, err := r.repo.LoadChannel(ctx, name)
+	if err != nil {
+		if cached != nil {
+			cacheRequests.WithLabelValues("channel", "stale").Inc()
+			declarative.RecordWarning(ctx, "serving cached channel %q from %v, as it could not be reloaded: %v", name, cached.fetched.Format(time.RFC3339), err)
+			return cached.channel, nil
+		}
+		return nil, err
+	}
+	cacheRequests.WithLabelValues("channel", "miss").Inc()
+
+	r.mutex.Lock()
+	r.channels[name] = &cachedChannel{channel: channel, fetched: time.Now()}
+	r.mutex.Unlock()
+
+	return channel, nil
+}
+
+func (r *CachingRepository) LoadManifest(ctx context.Context, packageName string, id string) (map[string]string, error) {
+	if !allowedManifestId(packageName) {
+		return nil, fmt.Errorf("invalid package name: %q", packageName)
+	}
+
+	if !allowedManifestId(id) {
+		return nil, fmt.Errorf("invalid manifest id: %q", id)
+	}
+
+	key := packageName + "/" + id
+
+	if result, ok := r.lookupPackage(ctx, key); ok {
+		cacheRequests.WithLabelValues("package", "hit").Inc()
+		return result, nil
+	}
+
+	result, err := r.repo.LoadManifest(ctx, packageName, id)
+	if err != nil {
+		return nil, err
+	}
+	cacheRequests.WithLabelValues("package", "miss").Inc()
+
+	if err := r.storePackage(key, result); err != nil {
+		// The cache is an optimization, so we don't fail the load
+		log.FromContext(ctx).Error(err, "error writing package to cache", "package", key)
+	}
+
+	return result, nil
+}
+
+// lookupPackage returns the package contents from memory or disk
+func (r *CachingRepository) lookupPackage(ctx context.Context, key string) (map[string]string, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	index, ok := r.packages[key]
+	if !ok && r.options.Dir != "" {
+		b, err := os.ReadFile(r.indexPath(key))
+		if err != nil {
+			return nil, false
+		}
+		if err := json.Unmarshal(b, &index); err != nil {
+			log.FromContext(ctx).Error(err, "ignoring invalid package index in cache", "package", key)
+			return nil, false
+		}
+	}
+	if index == nil {
+		return nil, false
+	}
+
+	result := make(map[string]string)
+	for p, digest := range index {
+		contents, ok := r.blobs[digest]
+		if !ok {
+			if r.options.Dir == "" {
+				return nil, false
+			}
+			b, err := os.ReadFile(filepath.Join(r.options.Dir, "blobs", digest))
+			if err != nil {
+				return nil, false
+			}
+			if actual := sha256Hex(b); actual != digest {
+				log.FromContext(ctx).Info("ignoring corrupt blob in cache", "digest", digest, "actual", actual)
+				return nil, false
+			}
+			contents = string(b)
+			r.blobs[digest] = contents
+		}
+		result[p] = contents
+	}
+	r.packages[key] = index
+	return result, true
+}
+
+// storePackage records the package contents in memory and on disk
+func (r *CachingRepository) storePackage(key string, contents map[string]string) error {
+	index := make(map[string]string)
+
+	r.mutex.Lock()
+	for p, s := range contents {
+		digest := sha256Hex([]byte(s))
+		index[p] = digest
+		r.blobs[digest] = s
+	}
+	r.packages[key] = index
+	r.mutex.Unlock()
+
+	if r.options.Dir == "" {
+		return nil
+	}
+
+	for p, s := range contents {
+		blobPath := filepath.Join(r.options.Dir, "blobs", index[p])
+		if _, err := os.Stat(blobPath); err == nil {
+			continue
+		}
+		if err := writeFileAtomic(blobPath, []byte(s)); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("error serializing package index: %w", err)
+	}
+	indexPath := r.indexPath(key)
+	if err := os.MkdirAll(filepath.Dir(indexPath), 0755); err != nil {
+		return fmt.Errorf("error creating cache directory: %w", err)
+	}
+	return writeFileAtomic(indexPath, b)
+}
+
+func (r *CachingRepository) indexPath(key string) string {
+	return filepath.Join(r.options.Dir, "packages", filepath.FromSlash(key)+".json")
+}
+
+// writeFileAtomic writes to a temporary file and renames it, so readers never see partial contents
+func writeFileAtomic(p string, b []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(p), ".tmp-")
+	if err != nil {
+		return fmt.Errorf("error creating temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing %s: %w", f.Name(), err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error writing %s: %w", f.Name(), err)
+	}
+	if err := os.Rename(f.Name(), p); err != nil {
+		return fmt.Errorf("error renaming %s to %s: %w", f.Name(), p, err)
+	}
+	return nil
+}
+
+func sha256Hex(b []byte) string {
+	digest := sha256.Sum256(b)
+	return hex.EncodeToString(digest[:])
+}
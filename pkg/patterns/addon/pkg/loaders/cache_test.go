@@ -0,0 +1,135 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeHTTPServer serves a channel (with an ETag) and a package, counting requests
+type fakeHTTPServer struct {
+	mutex       sync.Mutex
+	requests    map[string]int
+	notModified int
+	down        bool
+}
+
+func (s *fakeHTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests[r.URL.Path]++
+	if s.down {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/stable":
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			s.notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("manifests:\n- name: nginx\n  version: 0.1.0\n"))
+	case "/packages/nginx/0.1.0/manifest.yaml":
+		w.Write([]byte("kind: ConfigMap\n"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestCachingRepository(t *testing.T) {
+	ctx := context.Background()
+
+	server := &fakeHTTPServer{requests: make(map[string]int)}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	// The server being down is the point of the test, so we don't retry
+	httpRepo, err := NewHTTPRepositoryWithOptions(ts.URL, HTTPOptions{MaxRetries: -1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dir := t.TempDir()
+	repo, err := NewCachingRepository(httpRepo, CacheOptions{Dir: dir, ChannelTTL: time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// First load fetches the channel, second load (after the TTL) revalidates it
+	for i := 0; i < 2; i++ {
+		channel, err := repo.LoadChannel(ctx, "stable")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(channel.Manifests) != 1 {
+			t.Fatalf("unexpected channel %+v", channel)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	if server.notModified != 1 {
+		t.Errorf("expected channel to be revalidated once, got %d", server.notModified)
+	}
+
+	// Packages are only fetched once
+	want := map[string]string{ts.URL + "/packages/nginx/0.1.0/manifest.yaml": "kind: ConfigMap\n"}
+	for i := 0; i < 2; i++ {
+		got, err := repo.LoadManifest(ctx, "nginx", "0.1.0")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+	if n := server.requests["/packages/nginx/0.1.0/manifest.yaml"]; n != 1 {
+		t.Errorf("expected package to be fetched once, got %d", n)
+	}
+
+	// When the server is down, the stale channel is served
+	server.mutex.Lock()
+	server.down = true
+	server.mutex.Unlock()
+	if _, err := repo.LoadChannel(ctx, "stable"); err != nil {
+		t.Errorf("expected stale channel to be served, got error %v", err)
+	}
+
+	// A new repository (eg after a restart) can serve packages from disk
+	restarted, err := NewCachingRepository(httpRepo, CacheOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := restarted.LoadManifest(ctx, "nginx", "0.1.0")
+	if err != nil {
+		t.Fatalf("unexpected error loading package from disk cache: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if _, err := restarted.LoadChannel(ctx, "stable"); err == nil {
+		t.Errorf("expected error loading uncached channel while server is down")
+	}
+}
@@ -0,0 +1,351 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	semver "github.com/blang/semver/v4"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// UpgradeConstraints describe the environment that a version will be installed into.
+// Empty fields are not checked.
+type UpgradeConstraints struct {
+	// OperatorVersion is checked against Version.MinOperatorVersion
+	OperatorVersion string
+
+	// KubernetesVersion is checked against Version.KubernetesVersions
+	KubernetesVersion string
+
+	// Variant, if set, requires versions to declare the variant in Version.Variants
+	Variant string
+
+	// Versions, if set, is a version constraint that versions must satisfy, eg "~1.2", "^2.0" or ">=1.4 <1.6"
+	Versions string
+}
+
+// Next returns the version of packageName to deploy, given the currently deployed version.
+//
+// If nothing is deployed, this is the latest version that is compatible with the constraints.
+// Otherwise it is the latest compatible version that the deployed version may upgrade to directly,
+// so reaching the latest version may take several steps.  If there is no permissible upgrade,
+// the deployed version is returned.
+// Deprecated versions are only returned when no non-deprecated version is permissible.
+func (c *Channel) Next(ctx context.Context, packageName string, deployedVersion string, constraints UpgradeConstraints) (*Version, error) {
+	log := log.FromContext(ctx)
+
+	var deployed *semver.Version
+	if deployedVersion != "" {
+		v, err := semver.ParseTolerant(deployedVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deployed version %q: %w", deployedVersion, err)
+		}
+		deployed = &v
+	}
+
+	var best, bestDeprecated *Version
+	var current *Version
+	for i := range c.Manifests {
+		v := &c.Manifests[i]
+		if v.Package != "" && v.Package != packageName {
+			continue
+		}
+
+		candidate, err := semver.ParseTolerant(v.Version)
+		if err != nil {
+			log.Info("invalid semver in version", "version", v)
+			continue
+		}
+
+		if deployed != nil {
+			if candidate.EQ(*deployed) {
+				current = v
+				continue
+			}
+			if candidate.LT(*deployed) {
+				continue
+			}
+			ok, err := c.canUpgrade(*deployed, candidate, v)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				log.V(2).Info("upgrade not permitted", "from", deployedVersion, "to", v.Version)
+				continue
+			}
+		}
+
+		if ok, reason, err := v.compatible(constraints); err != nil {
+			return nil, err
+		} else if !ok {
+			log.V(2).Info("skipping incompatible version", "version", v.Version, "reason", reason)
+			continue
+		}
+
+		if v.Deprecated {
+			if bestDeprecated == nil || bestDeprecated.Compare(ctx, v) < 0 {
+				bestDeprecated = v
+			}
+		} else if best == nil || best.Compare(ctx, v) < 0 {
+			best = v
+		}
+	}
+
+	if best == nil {
+		best = bestDeprecated
+	}
+	if best != nil {
+		return best, nil
+	}
+
+	if deployed != nil {
+		if current != nil {
+			return current, nil
+		}
+		return &Version{Package: packageName, Version: deployedVersion}, nil
+	}
+	return nil, nil
+}
+
+// Find returns the channel entry for the version of packageName,
+// or a Version without metadata if the channel no longer lists it.
+func (c *Channel) Find(packageName string, version string) *Version {
+	if v := c.lookup(packageName, version); v != nil {
+		return v
+	}
+	return &Version{Package: packageName, Version: version}
+}
+
+// lookup returns the channel entry for the version of packageName, or nil if the channel does not list it
+func (c *Channel) lookup(packageName string, version string) *Version {
+	for i := range c.Manifests {
+		v := &c.Manifests[i]
+		if v.Package != "" && v.Package != packageName {
+			continue
+		}
+		if v.Version == version {
+			return v
+		}
+	}
+	return nil
+}
+
+// canUpgrade returns true if the channel allows upgrading directly from the deployed version to target
+func (c *Channel) canUpgrade(deployed, target semver.Version, v *Version) (bool, error) {
+	if v.UpgradeFrom != "" {
+		r, err := semver.ParseRange(v.UpgradeFrom)
+		if err != nil {
+			return false, fmt.Errorf("invalid upgradeFrom %q for version %s: %w", v.UpgradeFrom, v.Version, err)
+		}
+		return r(deployed), nil
+	}
+
+	if c.SequentialMinorUpgrades {
+		return target.Major == deployed.Major && target.Minor <= deployed.Minor+1, nil
+	}
+	return true, nil
+}
+
+// compatible checks the version against the constraints, returning the reason if it is not compatible
+func (v *Version) compatible(constraints UpgradeConstraints) (bool, string, error) {
+	if v.MinOperatorVersion != "" && constraints.OperatorVersion != "" {
+		min, err := semver.ParseTolerant(v.MinOperatorVersion)
+		if err != nil {
+			return false, "", fmt.Errorf("invalid minOperatorVersion %q for version %s: %w", v.MinOperatorVersion, v.Version, err)
+		}
+		operator, err := semver.ParseTolerant(constraints.OperatorVersion)
+		if err != nil {
+			return false, "", fmt.Errorf("invalid operator version %q: %w", constraints.OperatorVersion, err)
+		}
+		if operator.LT(min) {
+			return false, fmt.Sprintf("requires operator version %s", v.MinOperatorVersion), nil
+		}
+	}
+
+	if constraints.Versions != "" {
+		ok, err := versionSatisfies(v.Version, constraints.Versions)
+		if err != nil {
+			return false, "", err
+		}
+		if !ok {
+			return false, fmt.Sprintf("does not satisfy %s", constraints.Versions), nil
+		}
+	}
+
+	if constraints.Variant != "" && v.FindVariant(constraints.Variant) == nil {
+		return false, fmt.Sprintf("does not provide variant %s", constraints.Variant), nil
+	}
+
+	if v.KubernetesVersions != "" && constraints.KubernetesVersion != "" {
+		r, err := semver.ParseRange(v.KubernetesVersions)
+		if err != nil {
+			return false, "", fmt.Errorf("invalid kubernetesVersions %q for version %s: %w", v.KubernetesVersions, v.Version, err)
+		}
+		kubernetes, err := semver.ParseTolerant(constraints.KubernetesVersion)
+		if err != nil {
+			return false, "", fmt.Errorf("invalid kubernetes version %q: %w", constraints.KubernetesVersion, err)
+		}
+		// Ignore pre-release and build metadata (eg v1.29.3-gke.100), which would otherwise sort before the release
+		kubernetes.Pre = nil
+		kubernetes.Build = nil
+		if !r(kubernetes) {
+			return false, fmt.Sprintf("supports kubernetes %s", v.KubernetesVersions), nil
+		}
+	}
+
+	return true, "", nil
+}
+
+// IsVersionConstraint returns true if version is a constraint such as "~1.2", "^2.0", "1.2.x" or ">=1.4 <1.6",
+// rather than an exact version (or other package id) that is loaded directly.
+// Only values with an operator, a space or a wildcard are constraints, so a package id such as "1.2" is loaded as-is.
+func IsVersionConstraint(version string) bool {
+	if !strings.ContainsAny(version, "~^<>= |xX*") {
+		return false
+	}
+	if _, err := semver.Parse(strings.TrimPrefix(version, "v")); err == nil {
+		return false
+	}
+	_, err := parseVersionConstraint(version)
+	return err == nil
+}
+
+// versionSatisfies returns true if version satisfies the constraint
+func versionSatisfies(version string, constraint string) (bool, error) {
+	r, err := parseVersionConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+	v, err := semver.ParseTolerant(version)
+	if err != nil {
+		return false, nil
+	}
+	return r(v), nil
+}
+
+// parseVersionConstraint parses a version constraint into a semver range.
+//
+// In addition to the comparisons supported by semver.ParseRange, versions may be partial (">=1.4" is ">=1.4.0"),
+// "~1.2" allows patch updates (">=1.2.0 <1.3.0"), "^2.0" allows updates that do not change
+// the left-most non-zero component (">=2.0.0 <3.0.0"), and a partial version on its own matches
+// any version with that prefix ("1.2" is ">=1.2.0 <1.3.0").
+func parseVersionConstraint(constraint string) (semver.Range, error) {
+	var alternatives []string
+	for _, alternative := range strings.Split(constraint, "||") {
+		var terms []string
+		op := ""
+		for _, field := range strings.Fields(alternative) {
+			// Allow a space between the operator and the version, eg ">= 1.4"
+			if strings.Trim(field, "<>=!~^") == "" {
+				op += field
+				continue
+			}
+			term, err := expandConstraintTerm(op + field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+			}
+			terms = append(terms, term)
+			op = ""
+		}
+		if op != "" || len(terms) == 0 {
+			return nil, fmt.Errorf("invalid version constraint %q", constraint)
+		}
+		alternatives = append(alternatives, strings.Join(terms, " "))
+	}
+
+	r, err := semver.ParseRange(strings.Join(alternatives, " || "))
+	if err != nil {
+		return nil, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+	return r, nil
+}
+
+// expandConstraintTerm rewrites a single comparison into the syntax understood by semver.ParseRange
+func expandConstraintTerm(term string) (string, error) {
+	op := term[:len(term)-len(strings.TrimLeft(term, "<>=!~^"))]
+	version := strings.TrimPrefix(term[len(op):], "v")
+
+	// Wildcards (1.2.x) are handled by semver.ParseRange
+	if strings.ContainsAny(version, "xX*") {
+		return op + version, nil
+	}
+
+	// Pre-release and build metadata only apply to complete versions
+	if _, err := semver.Parse(version); err == nil {
+		switch op {
+		case "~", "^":
+		default:
+			return op + version, nil
+		}
+	}
+
+	parts := strings.SplitN(version, ".", 3)
+	var n [3]uint64
+	for i, part := range parts {
+		v, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid version %q", version)
+		}
+		n[i] = v
+	}
+	lower := fmt.Sprintf("%d.%d.%d", n[0], n[1], n[2])
+
+	// next is the first version after those matching the partial version, eg 1.3.0 for 1.2
+	var next string
+	switch len(parts) {
+	case 1:
+		next = fmt.Sprintf("%d.0.0", n[0]+1)
+	case 2:
+		next = fmt.Sprintf("%d.%d.0", n[0], n[1]+1)
+	default:
+		next = fmt.Sprintf("%d.%d.%d", n[0], n[1], n[2]+1)
+	}
+
+	switch op {
+	case "", "=":
+		if len(parts) == 3 {
+			return "=" + lower, nil
+		}
+		return ">=" + lower + " <" + next, nil
+	case "~":
+		if len(parts) == 1 {
+			return ">=" + lower + " <" + next, nil
+		}
+		return fmt.Sprintf(">=%s <%d.%d.0", lower, n[0], n[1]+1), nil
+	case "^":
+		switch {
+		case n[0] > 0 || len(parts) == 1:
+			return fmt.Sprintf(">=%s <%d.0.0", lower, n[0]+1), nil
+		case n[1] > 0 || len(parts) == 2:
+			return fmt.Sprintf(">=%s <0.%d.0", lower, n[1]+1), nil
+		default:
+			return ">=" + lower + " <" + next, nil
+		}
+	case ">":
+		return ">=" + next, nil
+	case "<=":
+		return "<" + next, nil
+	case ">=", "<", "!=":
+		return op + lower, nil
+	default:
+		return "", fmt.Errorf("invalid operator %q", op)
+	}
+}
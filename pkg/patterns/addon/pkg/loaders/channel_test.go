@@ -0,0 +1,171 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"context"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+const testChannelV2 = `
+sequentialMinorUpgrades: true
+manifests:
+- name: nginx
+  version: 1.0.0
+- name: nginx
+  version: 1.0.1
+- name: nginx
+  version: 1.1.0
+  deprecated: true
+  deprecationMessage: use 1.1.1
+- name: nginx
+  version: 1.1.1
+  releaseNotes: https://example.com/nginx/1.1.1
+- name: nginx
+  version: 1.2.0
+  kubernetesVersions: ">=1.28.0"
+- name: nginx
+  version: 1.3.0
+  minOperatorVersion: 2.0.0
+- name: nginx
+  version: 2.0.0
+  upgradeFrom: ">=1.2.0"
+  variants:
+  - name: aws
+- name: other
+  version: 9.0.0
+`
+
+func TestChannelNext(t *testing.T) {
+	ctx := context.Background()
+
+	channel := &Channel{}
+	if err := yaml.Unmarshal([]byte(testChannelV2), channel); err != nil {
+		t.Fatalf("error parsing channel: %v", err)
+	}
+
+	grid := []struct {
+		name        string
+		deployed    string
+		constraints UpgradeConstraints
+		want        string
+	}{
+		{name: "fresh install without constraints", want: "2.0.0"},
+		{name: "fresh install on old kubernetes", constraints: UpgradeConstraints{KubernetesVersion: "v1.27.4"}, want: "2.0.0"},
+		{name: "patch and minor upgrade", deployed: "1.0.0", want: "1.1.1"},
+		{name: "deprecated version is avoided", deployed: "1.0.1", want: "1.1.1"},
+		{name: "next minor", deployed: "1.1.1", want: "1.2.0"},
+		{name: "kubernetes version too old", deployed: "1.1.1", constraints: UpgradeConstraints{KubernetesVersion: "v1.27.4-gke.100"}, want: "1.1.1"},
+		{name: "kubernetes version supported", deployed: "1.1.1", constraints: UpgradeConstraints{KubernetesVersion: "v1.28.1-gke.100"}, want: "1.2.0"},
+		{name: "operator too old", deployed: "1.2.0", constraints: UpgradeConstraints{OperatorVersion: "1.9.0"}, want: "2.0.0"},
+		{name: "explicit upgrade edge", deployed: "1.2.0", want: "2.0.0"},
+		{name: "latest deployed", deployed: "2.0.0", want: "2.0.0"},
+		{name: "deployed version not in channel", deployed: "3.0.0", want: "3.0.0"},
+		{name: "only versions providing the variant", constraints: UpgradeConstraints{Variant: "aws"}, want: "2.0.0"},
+		{name: "no upgrade without the variant", deployed: "1.0.0", constraints: UpgradeConstraints{Variant: "aws"}, want: "1.0.0"},
+	}
+
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			v, err := channel.Next(ctx, "nginx", g.deployed, g.constraints)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if v == nil || v.Version != g.want {
+				t.Errorf("expected %s, got %+v", g.want, v)
+			}
+		})
+	}
+}
+
+func TestChannelNextV1(t *testing.T) {
+	ctx := context.Background()
+
+	channel := &Channel{}
+	if err := yaml.Unmarshal([]byte("manifests:\n- version: 0.1.0\n- version: 0.3.0\n- version: 0.2.0\n"), channel); err != nil {
+		t.Fatalf("error parsing channel: %v", err)
+	}
+
+	// Without upgrade metadata, we upgrade straight to the latest version, as Latest does
+	for _, deployed := range []string{"", "0.1.0"} {
+		v, err := channel.Next(ctx, "nginx", deployed, UpgradeConstraints{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		latest, err := channel.Latest(ctx, "nginx")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if v.Version != "0.3.0" || latest.Version != v.Version {
+			t.Errorf("expected 0.3.0 from %q, got %+v (latest %+v)", deployed, v, latest)
+		}
+	}
+
+	if v, err := (&Channel{}).Next(ctx, "nginx", "", UpgradeConstraints{}); err != nil || v != nil {
+		t.Errorf("expected no version from empty channel, got %+v, %v", v, err)
+	}
+	if _, err := channel.Next(ctx, "nginx", "not-a-version", UpgradeConstraints{}); err == nil {
+		t.Errorf("expected error for invalid deployed version")
+	}
+}
+
+func TestParseVersionConstraint(t *testing.T) {
+	grid := []struct {
+		constraint string
+		match      []string
+		noMatch    []string
+	}{
+		{constraint: "~1.2", match: []string{"1.2.0", "1.2.9"}, noMatch: []string{"1.1.9", "1.3.0"}},
+		{constraint: "~1.2.3", match: []string{"1.2.3", "1.2.9"}, noMatch: []string{"1.2.2", "1.3.0"}},
+		{constraint: "~1", match: []string{"1.0.0", "1.9.0"}, noMatch: []string{"2.0.0"}},
+		{constraint: "^2.0", match: []string{"2.0.0", "2.9.1"}, noMatch: []string{"1.9.9", "3.0.0"}},
+		{constraint: "^0.2.3", match: []string{"0.2.3", "0.2.9"}, noMatch: []string{"0.3.0"}},
+		{constraint: "^0.0.3", match: []string{"0.0.3"}, noMatch: []string{"0.0.4"}},
+		{constraint: ">=1.4 <1.6", match: []string{"1.4.0", "1.5.9"}, noMatch: []string{"1.3.9", "1.6.0"}},
+		{constraint: ">= 1.4 < 1.6", match: []string{"1.5.0"}, noMatch: []string{"1.6.0"}},
+		{constraint: ">1.4 <=1.6", match: []string{"1.5.0", "1.6.3"}, noMatch: []string{"1.4.9", "1.7.0"}},
+		{constraint: "=1.2", match: []string{"1.2.0", "1.2.5"}, noMatch: []string{"1.3.0"}},
+		{constraint: "1.2.x", match: []string{"1.2.5"}, noMatch: []string{"1.3.0"}},
+		{constraint: "~1.2 || ^3", match: []string{"1.2.1", "3.4.0"}, noMatch: []string{"2.0.0"}},
+		{constraint: "=v1.2", match: []string{"1.2.1"}, noMatch: []string{"1.3.0"}},
+	}
+	for _, g := range grid {
+		t.Run(g.constraint, func(t *testing.T) {
+			if !IsVersionConstraint(g.constraint) {
+				t.Errorf("expected %q to be a version constraint", g.constraint)
+			}
+			for _, v := range g.match {
+				if ok, err := versionSatisfies(v, g.constraint); err != nil || !ok {
+					t.Errorf("expected %s to satisfy %q (%v)", v, g.constraint, err)
+				}
+			}
+			for _, v := range g.noMatch {
+				if ok, err := versionSatisfies(v, g.constraint); err != nil || ok {
+					t.Errorf("expected %s not to satisfy %q (%v)", v, g.constraint, err)
+				}
+			}
+		})
+	}
+
+	for _, s := range []string{"1.2.3", "v1.2.3", "1.2", "v1.2", "1", "1.1.2-aws", "latest", "~", ">=", "^a.b", ""} {
+		if IsVersionConstraint(s) {
+			t.Errorf("expected %q not to be a version constraint", s)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	addonsv1alpha1 "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/apis/v1alpha1"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+// compositePackages returns the packages to load for version id of the addon, or nil if it is not a composite addon.
+//
+// A version is a composite if the channel declares packages for it, or if spec.packages is set;
+// spec.packages overrides the version or enables / disables the packages declared by the channel, and adds to them.
+// If the channel does not declare packages, the addon's own package is included.
+func compositePackages(channel *Channel, componentName string, id string, spec addonsv1alpha1.CommonSpec) []PackageRef {
+	var declared []PackageRef
+	if channel != nil {
+		if v := channel.lookup(componentName, id); v != nil {
+			declared = v.Packages
+		}
+	}
+	if len(declared) == 0 && len(spec.Packages) == 0 {
+		return nil
+	}
+
+	var refs []PackageRef
+	if len(declared) != 0 {
+		refs = append(refs, declared...)
+	} else {
+		refs = append(refs, PackageRef{Name: componentName, Version: id})
+	}
+
+	enabled := make(map[string]bool)
+	for _, ref := range refs {
+		enabled[ref.Name] = !ref.Optional
+	}
+
+	for _, p := range spec.Packages {
+		i := -1
+		for j := range refs {
+			if refs[j].Name == p.Name {
+				i = j
+				break
+			}
+		}
+		if i == -1 {
+			refs = append(refs, PackageRef{Name: p.Name})
+			i = len(refs) - 1
+			enabled[p.Name] = true
+		}
+		if p.Version != "" {
+			refs[i].Version = p.Version
+		}
+		if p.Enabled != nil {
+			enabled[p.Name] = *p.Enabled
+		}
+	}
+
+	var packages []PackageRef
+	for _, ref := range refs {
+		if enabled[ref.Name] {
+			packages = append(packages, ref)
+		}
+	}
+	return packages
+}
+
+// loadComposite resolves the version of each package and merges them into one manifest,
+// failing if two packages define the same object.
+func (c *ManifestLoader) loadComposite(ctx context.Context, channel *Channel, componentName string, packages []PackageRef, variant string, constraints UpgradeConstraints, resolution *declarative.ManifestResolution) (map[string]string, error) {
+	log := log.FromContext(ctx)
+
+	result := make(map[string]string)
+	owners := make(map[string]string)
+	for _, p := range packages {
+		version := p.Version
+		if version == "" || IsVersionConstraint(version) {
+			if channel == nil {
+				return nil, fmt.Errorf("unable to resolve version of package %s without a channel", p.Name)
+			}
+			packageConstraints := constraints
+			packageConstraints.Versions = version
+			packageConstraints.Variant = ""
+			resolved, err := channel.Next(ctx, p.Name, "", packageConstraints)
+			if err != nil {
+				return nil, fmt.Errorf("error resolving version of package %s: %w", p.Name, err)
+			}
+			if resolved == nil {
+				return nil, fmt.Errorf("no compatible version of package %s in channel satisfies %q", p.Name, version)
+			}
+			version = resolved.Version
+		}
+		log.WithValues("package", p.Name).WithValues("version", version).V(2).Info("resolved package of composite addon")
+
+		var manifests map[string]string
+		var err error
+		if p.Name == componentName {
+			manifests, err = c.loadVariant(ctx, channel, p.Name, version, variant)
+		} else {
+			manifests, err = c.repo.LoadManifest(ctx, p.Name, version)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error loading package %s version %s: %w", p.Name, version, err)
+		}
+
+		for k, v := range manifests {
+			objects, err := manifest.ParseObjects(ctx, v)
+			if err != nil {
+				// Not every file is a manifest (eg templates), those are left to the reconciler
+				log.V(2).Info("unable to parse file for collision detection", "file", k, "error", err.Error())
+			} else {
+				for _, obj := range objects.Items {
+					if obj.GetName() == "" {
+						continue
+					}
+					key := fmt.Sprintf("%s %s/%s", obj.GroupKind(), obj.GetNamespace(), obj.GetName())
+					if owner, found := owners[key]; found && owner != p.Name {
+						return nil, fmt.Errorf("packages %s and %s both define %s", owner, p.Name, key)
+					}
+					owners[key] = p.Name
+				}
+			}
+			if _, found := result[k]; found {
+				return nil, fmt.Errorf("file %s is defined by more than one package", k)
+			}
+			result[k] = v
+		}
+
+		resolution.Packages = append(resolution.Packages, declarative.PackageResolution{Name: p.Name, Version: version})
+	}
+
+	sort.Slice(resolution.Packages, func(i, j int) bool {
+		return resolution.Packages[i].Name < resolution.Packages[j].Name
+	})
+	return result, nil
+}
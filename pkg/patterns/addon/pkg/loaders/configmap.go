@@ -0,0 +1,337 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sigs.k8s.io/yaml"
+
+	addonsv1alpha1 "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/apis/v1alpha1"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/utils"
+)
+
+const (
+	// ConfigMapChannelLabel marks a ConfigMap as holding the channel named by the label value
+	ConfigMapChannelLabel = "addons.k8s.io/channel"
+
+	// ConfigMapChannelKey is the key of the channel in a channel ConfigMap
+	ConfigMapChannelKey = "channel"
+
+	// ConfigMapPackageLabel marks a ConfigMap as holding files of the package named by the label value
+	ConfigMapPackageLabel = "addons.k8s.io/package"
+
+	// ConfigMapPackageVersionLabel is the version (manifest id) of the package held by the ConfigMap, eg 1.2.3 or 1.2.3-aws
+	ConfigMapPackageVersionLabel = "addons.k8s.io/package-version"
+
+	// ConfigMapChunkAnnotation splits a package version across several ConfigMaps, for manifests
+	// that are larger than the ConfigMap size limit.  The value is "<index>/<count>", eg "2/3",
+	// and the contents of each key are concatenated in index order.
+	ConfigMapChunkAnnotation = "addons.k8s.io/chunk"
+)
+
+// ConfigMapRepository is a Repository backed by ConfigMaps in a namespace, for clusters
+// that cannot reach a remote repository.  Each package version is stored in a ConfigMap
+// labeled with ConfigMapPackageLabel and ConfigMapPackageVersionLabel, with one key per file,
+// or in several ConfigMaps annotated with ConfigMapChunkAnnotation.
+// Each channel is stored in a ConfigMap labeled with ConfigMapChannelLabel, under ConfigMapChannelKey.
+//
+// When a ManifestLoader uses a ConfigMapRepository (directly or as a layer), declarative.WatchChildren
+// reconciles addons when the ConfigMaps change; otherwise use WatchConfigMapRepository.
+type ConfigMapRepository struct {
+	client    client.Reader
+	namespace string
+}
+
+var _ Repository = &ConfigMapRepository{}
+
+// NewConfigMapRepository is the constructor for a ConfigMapRepository.
+// The manager's client is recommended, so that ConfigMaps are read from the informer cache.
+func NewConfigMapRepository(c client.Reader, namespace string) *ConfigMapRepository {
+	return &ConfigMapRepository{
+		client:    c,
+		namespace: namespace,
+	}
+}
+
+func (r *ConfigMapRepository) LoadChannel(ctx context.Context, name string) (*Channel, error) {
+	if !allowedChannelName(name) {
+		return nil, fmt.Errorf("invalid channel name: %q", name)
+	}
+
+	log := log.FromContext(ctx)
+	log.WithValues("channel", name).WithValues("namespace", r.namespace).V(2).Info("loading channel from configmap")
+
+	configMaps := &corev1.ConfigMapList{}
+	if err := r.client.List(ctx, configMaps, client.InNamespace(r.namespace), client.MatchingLabels{ConfigMapChannelLabel: name}); err != nil {
+		return nil, fmt.Errorf("error listing configmaps for channel %s: %w", name, err)
+	}
+	if len(configMaps.Items) == 0 {
+		return nil, fmt.Errorf("channel %s not found in namespace %s: %w", name, r.namespace, os.ErrNotExist)
+	}
+	if len(configMaps.Items) > 1 {
+		return nil, fmt.Errorf("found %d configmaps for channel %s in namespace %s", len(configMaps.Items), name, r.namespace)
+	}
+
+	cm := &configMaps.Items[0]
+	b, ok := cm.Data[ConfigMapChannelKey]
+	if !ok {
+		return nil, fmt.Errorf("configmap %s/%s does not have key %q", cm.Namespace, cm.Name, ConfigMapChannelKey)
+	}
+
+	channel := &Channel{}
+	if err := yaml.Unmarshal([]byte(b), channel); err != nil {
+		return nil, fmt.Errorf("error parsing channel in configmap %s/%s: %v", cm.Namespace, cm.Name, err)
+	}
+
+	return channel, nil
+}
+
+func (r *ConfigMapRepository) LoadManifest(ctx context.Context, packageName string, id string) (map[string]string, error) {
+	if !allowedManifestId(packageName) {
+		return nil, fmt.Errorf("invalid package name: %q", id)
+	}
+
+	if !allowedManifestId(id) {
+		return nil, fmt.Errorf("invalid manifest id: %q", id)
+	}
+
+	log := log.FromContext(ctx)
+	log.WithValues("package", packageName).WithValues("id", id).V(2).Info("loading package from configmaps")
+
+	configMaps := &corev1.ConfigMapList{}
+	if err := r.client.List(ctx, configMaps, client.InNamespace(r.namespace), client.MatchingLabels{
+		ConfigMapPackageLabel:        packageName,
+		ConfigMapPackageVersionLabel: id,
+	}); err != nil {
+		return nil, fmt.Errorf("error listing configmaps for package %s/%s: %w", packageName, id, err)
+	}
+	if len(configMaps.Items) == 0 {
+		return nil, fmt.Errorf("package %s/%s not found in namespace %s: %w", packageName, id, r.namespace, os.ErrNotExist)
+	}
+
+	chunks, err := orderChunks(configMaps.Items)
+	if err != nil {
+		return nil, fmt.Errorf("package %s/%s: %w", packageName, id, err)
+	}
+
+	contents := make(map[string]*strings.Builder)
+	for _, cm := range chunks {
+		for k, v := range cm.Data {
+			sb := contents[k]
+			if sb == nil {
+				sb = &strings.Builder{}
+				contents[k] = sb
+			}
+			sb.WriteString(v)
+		}
+	}
+
+	dirPath := path.Join("packages", packageName, id)
+	result := make(map[string]string)
+	for k, sb := range contents {
+		result[path.Join(dirPath, k)] = sb.String()
+	}
+	return result, nil
+}
+
+// orderChunks sorts the ConfigMaps of a package version by their chunk index, checking that all chunks are present
+func orderChunks(configMaps []corev1.ConfigMap) ([]*corev1.ConfigMap, error) {
+	count := -1
+	chunks := make(map[int]*corev1.ConfigMap)
+	for i := range configMaps {
+		cm := &configMaps[i]
+
+		index, n := 1, 1
+		if v, ok := cm.Annotations[ConfigMapChunkAnnotation]; ok {
+			var err error
+			index, n, err = parseChunk(v)
+			if err != nil {
+				return nil, fmt.Errorf("configmap %s/%s has invalid %s annotation %q: %w", cm.Namespace, cm.Name, ConfigMapChunkAnnotation, v, err)
+			}
+		}
+
+		if count == -1 {
+			count = n
+		} else if count != n {
+			return nil, fmt.Errorf("configmap %s/%s has chunk count %d, expected %d", cm.Namespace, cm.Name, n, count)
+		}
+		if existing := chunks[index]; existing != nil {
+			return nil, fmt.Errorf("configmaps %s and %s are both chunk %d", existing.Name, cm.Name, index)
+		}
+		chunks[index] = cm
+	}
+
+	var ordered []*corev1.ConfigMap
+	for i := 1; i <= count; i++ {
+		cm := chunks[i]
+		if cm == nil {
+			return nil, fmt.Errorf("chunk %d of %d not found", i, count)
+		}
+		ordered = append(ordered, cm)
+	}
+	return ordered, nil
+}
+
+// parseChunk parses a ConfigMapChunkAnnotation value
+func parseChunk(s string) (int, int, error) {
+	indexString, countString, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected <index>/<count>")
+	}
+	index, err := strconv.Atoi(indexString)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid index: %w", err)
+	}
+	count, err := strconv.Atoi(countString)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid count: %w", err)
+	}
+	if count < 1 || index < 1 || index > count {
+		return 0, 0, fmt.Errorf("index must be between 1 and count")
+	}
+	return index, count, nil
+}
+
+// WatchConfigMapRepository reconciles addon objects when the ConfigMaps of repo change,
+// so that publishing a channel or package version is picked up without waiting for a resync.
+// Objects are reconciled when their channel (stable by default) changes, or a package they use changes:
+// their own package, a package in spec.packages, or a package the channel declares for a composite addon.
+// list is an empty list of the addon type reconciled by the controller, eg &api.GuestbookList{}.
+// This is called by declarative.WatchChildren if the ManifestLoader uses the repository.
+func WatchConfigMapRepository(mgr ctrl.Manager, c controller.Controller, repo *ConfigMapRepository, list client.ObjectList) error {
+	isRepositoryConfigMap := predicate.NewTypedPredicateFuncs(func(cm *corev1.ConfigMap) bool {
+		if cm.GetNamespace() != repo.namespace {
+			return false
+		}
+		_, isChannel := cm.GetLabels()[ConfigMapChannelLabel]
+		_, isPackage := cm.GetLabels()[ConfigMapPackageLabel]
+		return isChannel || isPackage
+	})
+
+	enqueue := handler.TypedEnqueueRequestsFromMapFunc(func(ctx context.Context, cm *corev1.ConfigMap) []reconcile.Request {
+		requests, err := requestsForConfigMap(ctx, mgr.GetClient(), repo, list, cm)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "finding objects to reconcile for configmap", "configmap", cm.GetNamespace()+"/"+cm.GetName())
+		}
+		return requests
+	})
+
+	src := source.Kind(mgr.GetCache(), &corev1.ConfigMap{}, enqueue, isRepositoryConfigMap)
+	if err := c.Watch(src); err != nil {
+		return fmt.Errorf("setting up configmap repository watch on the controller: %w", err)
+	}
+	return nil
+}
+
+// requestsForConfigMap returns the objects in list that use the channel or package in cm.
+// The channels are loaded from repo to find the packages of composite addons; repo may be nil.
+func requestsForConfigMap(ctx context.Context, reader client.Reader, repo Repository, list client.ObjectList, cm *corev1.ConfigMap) ([]reconcile.Request, error) {
+	channelName, isChannel := cm.GetLabels()[ConfigMapChannelLabel]
+	packageName, isPackage := cm.GetLabels()[ConfigMapPackageLabel]
+	if !isChannel && !isPackage {
+		return nil, nil
+	}
+
+	objects := list.DeepCopyObject().(client.ObjectList)
+	if err := reader.List(ctx, objects); err != nil {
+		return nil, err
+	}
+	items, err := meta.ExtractList(objects)
+	if err != nil {
+		return nil, err
+	}
+
+	// channelPackages caches the packages each channel declares for each addon
+	channelPackages := make(map[string]map[string]bool)
+	usesPackage := func(name string, channel string, spec addonsv1alpha1.CommonSpec) bool {
+		if name == packageName {
+			return true
+		}
+		for _, p := range spec.Packages {
+			if p.Name == packageName {
+				return true
+			}
+		}
+		if repo == nil {
+			return false
+		}
+		key := channel + "/" + name
+		packages, found := channelPackages[key]
+		if !found {
+			packages = make(map[string]bool)
+			if c, err := repo.LoadChannel(ctx, channel); err != nil {
+				log.FromContext(ctx).V(2).Info("unable to load channel to find composite packages", "channel", channel, "error", err.Error())
+			} else {
+				for _, v := range c.Manifests {
+					if v.Package != "" && v.Package != name {
+						continue
+					}
+					for _, p := range v.Packages {
+						packages[p.Name] = true
+					}
+				}
+			}
+			channelPackages[key] = packages
+		}
+		return packages[packageName]
+	}
+
+	var requests []reconcile.Request
+	for _, item := range items {
+		spec, err := utils.GetCommonSpec(item)
+		if err != nil {
+			return nil, err
+		}
+		name, err := utils.GetCommonName(item)
+		if err != nil {
+			return nil, err
+		}
+		channel := spec.Channel
+		if channel == "" {
+			channel = "stable"
+		}
+		if (isChannel && channel == channelName) || (isPackage && usesPackage(name, channel, spec)) {
+			accessor, err := meta.Accessor(item)
+			if err != nil {
+				return nil, err
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}})
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].String() < requests[j].String()
+	})
+	return requests, nil
+}
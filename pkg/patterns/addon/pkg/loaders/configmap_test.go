@@ -0,0 +1,199 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func packageConfigMap(name, pkg, id, chunk string, data map[string]string) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "addons",
+			Name:      name,
+			Labels:    map[string]string{ConfigMapPackageLabel: pkg, ConfigMapPackageVersionLabel: id},
+		},
+		Data: data,
+	}
+	if chunk != "" {
+		cm.Annotations = map[string]string{ConfigMapChunkAnnotation: chunk}
+	}
+	return cm
+}
+
+func TestConfigMapRepository(t *testing.T) {
+	ctx := context.Background()
+
+	c := fake.NewClientBuilder().WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "addons", Name: "stable", Labels: map[string]string{ConfigMapChannelLabel: "stable"}},
+			Data:       map[string]string{ConfigMapChannelKey: "manifests:\n- name: nginx\n  version: 0.1.0\n"},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "stable", Labels: map[string]string{ConfigMapChannelLabel: "stable"}},
+			Data:       map[string]string{ConfigMapChannelKey: "manifests:\n- name: nginx\n  version: 9.9.9\n"},
+		},
+		packageConfigMap("nginx-0.1.0", "nginx", "0.1.0", "", map[string]string{"manifest.yaml": "kind: ConfigMap\n", "service.yaml": "kind: Service\n"}),
+		packageConfigMap("nginx-0.2.0-b", "nginx", "0.2.0", "2/2", map[string]string{"manifest.yaml": "second\n"}),
+		packageConfigMap("nginx-0.2.0-a", "nginx", "0.2.0", "1/2", map[string]string{"manifest.yaml": "first\n", "service.yaml": "kind: Service\n"}),
+		packageConfigMap("nginx-0.3.0-a", "nginx", "0.3.0", "1/2", map[string]string{"manifest.yaml": "first\n"}),
+		packageConfigMap("nginx-0.4.0-a", "nginx", "0.4.0", "", map[string]string{"manifest.yaml": "first\n"}),
+		packageConfigMap("nginx-0.4.0-b", "nginx", "0.4.0", "", map[string]string{"service.yaml": "kind: Service\n"}),
+	).Build()
+	repo := NewConfigMapRepository(c, "addons")
+
+	channel, err := repo.LoadChannel(ctx, "stable")
+	if err != nil {
+		t.Fatalf("unexpected error loading channel: %v", err)
+	}
+	if len(channel.Manifests) != 1 || channel.Manifests[0].Version != "0.1.0" {
+		t.Errorf("unexpected channel %+v", channel)
+	}
+	if _, err := repo.LoadChannel(ctx, "beta"); err == nil {
+		t.Errorf("expected error loading missing channel")
+	}
+
+	grid := []struct {
+		id       string
+		expected map[string]string
+	}{
+		{
+			id: "0.1.0",
+			expected: map[string]string{
+				"packages/nginx/0.1.0/manifest.yaml": "kind: ConfigMap\n",
+				"packages/nginx/0.1.0/service.yaml":  "kind: Service\n",
+			},
+		},
+		{
+			id: "0.2.0",
+			expected: map[string]string{
+				"packages/nginx/0.2.0/manifest.yaml": "first\nsecond\n",
+				"packages/nginx/0.2.0/service.yaml":  "kind: Service\n",
+			},
+		},
+		// Missing chunk
+		{id: "0.3.0"},
+		// Several configmaps without chunking
+		{id: "0.4.0"},
+		{id: "9.9.9"},
+		{id: "../0.1.0"},
+	}
+	for _, g := range grid {
+		t.Run(g.id, func(t *testing.T) {
+			manifests, err := repo.LoadManifest(ctx, "nginx", g.id)
+			if g.expected == nil {
+				if err == nil {
+					t.Fatalf("expected error, got %v", manifests)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error loading manifest: %v", err)
+			}
+			if !reflect.DeepEqual(manifests, g.expected) {
+				t.Errorf("expected %v, got %v", g.expected, manifests)
+			}
+		})
+	}
+}
+
+func TestRequestsForConfigMap(t *testing.T) {
+	ctx := context.Background()
+
+	gvk := schema.GroupVersionKind{Group: "addons.example.org", Version: "v1alpha1", Kind: "Nginx"}
+	addon := func(namespace, name string, spec map[string]interface{}) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		u.SetNamespace(namespace)
+		u.SetName(name)
+		u.Object["spec"] = spec
+		return u
+	}
+
+	c := fake.NewClientBuilder().WithRuntimeObjects(
+		addon("a", "default-channel", map[string]interface{}{}),
+		addon("b", "beta-channel", map[string]interface{}{"channel": "beta"}),
+		addon("c", "pinned", map[string]interface{}{"version": "0.1.0"}),
+		addon("d", "composite", map[string]interface{}{"packages": []interface{}{map[string]interface{}{"name": "dashboard"}}}),
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "addons", Name: "beta", Labels: map[string]string{ConfigMapChannelLabel: "beta"}},
+			Data:       map[string]string{ConfigMapChannelKey: "manifests:\n- name: nginx\n  version: 0.2.0\n  packages:\n  - name: metrics\n"},
+		},
+	).Build()
+	repo := NewConfigMapRepository(c, "addons")
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind("NginxList"))
+
+	grid := []struct {
+		name     string
+		labels   map[string]string
+		expected []string
+	}{
+		{name: "stable channel", labels: map[string]string{ConfigMapChannelLabel: "stable"}, expected: []string{"a/default-channel", "c/pinned", "d/composite"}},
+		{name: "beta channel", labels: map[string]string{ConfigMapChannelLabel: "beta"}, expected: []string{"b/beta-channel"}},
+		{name: "package", labels: map[string]string{ConfigMapPackageLabel: "nginx", ConfigMapPackageVersionLabel: "0.2.0"}, expected: []string{"a/default-channel", "b/beta-channel", "c/pinned", "d/composite"}},
+		{name: "package in spec.packages", labels: map[string]string{ConfigMapPackageLabel: "dashboard"}, expected: []string{"d/composite"}},
+		{name: "package declared by channel", labels: map[string]string{ConfigMapPackageLabel: "metrics"}, expected: []string{"b/beta-channel"}},
+		{name: "other package", labels: map[string]string{ConfigMapPackageLabel: "other"}},
+		{name: "unrelated configmap"},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "addons", Name: "cm", Labels: g.labels}}
+			requests, err := requestsForConfigMap(ctx, c, repo, list, cm)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, r := range requests {
+				got = append(got, r.String())
+			}
+			if !reflect.DeepEqual(got, g.expected) {
+				t.Errorf("expected %v, got %v", g.expected, got)
+			}
+		})
+	}
+}
+
+func TestFindConfigMapRepository(t *testing.T) {
+	repo := NewConfigMapRepository(fake.NewClientBuilder().Build(), "addons")
+
+	cached, err := NewCachingRepository(repo, CacheOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	layered, err := NewLayeredRepository(NewFSRepository(t.TempDir()), cached)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := findConfigMapRepository(layered); got != repo {
+		t.Errorf("expected to find the configmap repository in %T, got %v", layered, got)
+	}
+	if got := findConfigMapRepository(NewFSRepository(t.TempDir())); got != nil {
+		t.Errorf("expected no configmap repository, got %v", got)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+)
+
+// EmbeddedRepository is a Repository backed by an fs.FS, typically an embed.FS compiled into the operator.
+// The layout is the same as for FSRepository: channel files at the root, and packages in packages/<name>/<version>.
+//
+// For example, with the channels in a channels directory next to main.go:
+//
+//	//go:embed channels
+//	var channels embed.FS
+//
+//	fsys, _ := fs.Sub(channels, "channels")
+//	repo := loaders.NewEmbeddedRepository(fsys)
+type EmbeddedRepository struct {
+	fsys fs.FS
+}
+
+var _ Repository = &EmbeddedRepository{}
+var _ RawFileReader = &EmbeddedRepository{}
+
+// NewEmbeddedRepository is the constructor for an EmbeddedRepository
+func NewEmbeddedRepository(fsys fs.FS) *EmbeddedRepository {
+	return &EmbeddedRepository{
+		fsys: fsys,
+	}
+}
+
+func (r *EmbeddedRepository) LoadChannel(ctx context.Context, name string) (*Channel, error) {
+	if !allowedChannelName(name) {
+		return nil, fmt.Errorf("invalid channel name: %q", name)
+	}
+
+	log := log.FromContext(ctx)
+	log.WithValues("channel", name).V(2).Info("loading embedded channel")
+
+	b, err := fs.ReadFile(r.fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading embedded channel %s: %w", name, err)
+	}
+
+	channel := &Channel{}
+	if err := yaml.Unmarshal(b, channel); err != nil {
+		return nil, fmt.Errorf("error parsing embedded channel %s: %v", name, err)
+	}
+
+	return channel, nil
+}
+
+func (r *EmbeddedRepository) LoadManifest(ctx context.Context, packageName string, id string) (map[string]string, error) {
+	if !allowedManifestId(packageName) {
+		return nil, fmt.Errorf("invalid package name: %q", id)
+	}
+
+	if !allowedManifestId(id) {
+		return nil, fmt.Errorf("invalid manifest id: %q", id)
+	}
+
+	log := log.FromContext(ctx)
+	log.WithValues("package", packageName).V(2).Info("loading embedded package")
+
+	dirPath := path.Join("packages", packageName, id)
+	entries, err := fs.ReadDir(r.fsys, dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading embedded directory %s: %w", dirPath, err)
+	}
+	result := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			log.V(2).Info("skipping directory", "directory", entry.Name())
+			continue
+		}
+
+		filePath := path.Join(dirPath, entry.Name())
+		b, err := fs.ReadFile(r.fsys, filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading embedded file %s: %w", filePath, err)
+		}
+		result[filePath] = string(b)
+	}
+
+	return result, nil
+}
+
+// ReadRawFile reads a file relative to the repository root, for signature verification
+func (r *EmbeddedRepository) ReadRawFile(ctx context.Context, p string) ([]byte, error) {
+	if !allowedRawPath(p) {
+		return nil, fmt.Errorf("invalid path: %q", p)
+	}
+	return fs.ReadFile(r.fsys, p)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+var testEmbeddedFS = fstest.MapFS{
+	"stable":                                   {Data: []byte("manifests:\n- name: nginx\n  version: 0.1.0\n")},
+	"packages/nginx/0.1.0/manifest.yaml":       {Data: []byte("kind: ConfigMap\n")},
+	"packages/nginx/0.1.0/service.yaml":        {Data: []byte("kind: Service\n")},
+	"packages/nginx/0.1.0/nested/ignored.yaml": {Data: []byte("kind: Secret\n")},
+	"packages/other/1.0.0/manifest.yaml":       {Data: []byte("kind: Deployment\n")},
+}
+
+func TestEmbeddedRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewEmbeddedRepository(testEmbeddedFS)
+
+	channel, err := repo.LoadChannel(ctx, "stable")
+	if err != nil {
+		t.Fatalf("unexpected error loading channel: %v", err)
+	}
+	if len(channel.Manifests) != 1 || channel.Manifests[0].Version != "0.1.0" {
+		t.Errorf("unexpected channel %+v", channel)
+	}
+
+	manifests, err := repo.LoadManifest(ctx, "nginx", "0.1.0")
+	if err != nil {
+		t.Fatalf("unexpected error loading manifest: %v", err)
+	}
+	expected := map[string]string{
+		"packages/nginx/0.1.0/manifest.yaml": "kind: ConfigMap\n",
+		"packages/nginx/0.1.0/service.yaml":  "kind: Service\n",
+	}
+	if !reflect.DeepEqual(manifests, expected) {
+		t.Errorf("expected %v, got %v", expected, manifests)
+	}
+
+	for _, g := range []struct {
+		channel string
+		pkg     string
+		id      string
+	}{
+		{channel: "unstable"},
+		{channel: "../stable"},
+		{pkg: "nginx", id: "9.9.9"},
+		{pkg: "nginx", id: "../other"},
+		{pkg: "../nginx", id: "0.1.0"},
+	} {
+		if g.channel != "" {
+			if _, err := repo.LoadChannel(ctx, g.channel); err == nil {
+				t.Errorf("expected error loading channel %q", g.channel)
+			}
+			continue
+		}
+		if _, err := repo.LoadManifest(ctx, g.pkg, g.id); err == nil {
+			t.Errorf("expected error loading package %q %q", g.pkg, g.id)
+		}
+	}
+
+	if _, err := repo.ReadRawFile(ctx, "packages/nginx/0.1.0/service.yaml"); err != nil {
+		t.Errorf("unexpected error reading raw file: %v", err)
+	}
+	if _, err := repo.ReadRawFile(ctx, "../stable"); err == nil {
+		t.Errorf("expected error reading path outside the repository")
+	}
+}
@@ -18,13 +18,23 @@ package loaders
 
 import (
 	"context"
+	"crypto"
 	"flag"
 	"fmt"
 	"strings"
 
+	semver "github.com/blang/semver/v4"
+
+	addonsv1alpha1 "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/apis/v1alpha1"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/utils"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -36,27 +46,159 @@ func init() {
 }
 
 type ManifestLoader struct {
-	repo Repository
+	repo        Repository
+	constraints UpgradeConstraints
+}
+
+// ManifestLoaderOption configures a ManifestLoader
+type ManifestLoaderOption func(l *ManifestLoader) error
+
+// WithPublicKeys requires channels and packages to be signed by one of the keys,
+// see SignedRepository.
+func WithPublicKeys(keys ...crypto.PublicKey) ManifestLoaderOption {
+	return func(l *ManifestLoader) error {
+		repo, err := NewSignedRepository(l.repo, keys...)
+		if err != nil {
+			return err
+		}
+		l.repo = repo
+		return nil
+	}
+}
+
+// WithCache caches channels and packages, see CachingRepository.
+// When combined with WithPublicKeys, WithPublicKeys should be specified first so that only verified content is cached.
+func WithCache(options CacheOptions) ManifestLoaderOption {
+	return func(l *ManifestLoader) error {
+		repo, err := NewCachingRepository(l.repo, options)
+		if err != nil {
+			return err
+		}
+		l.repo = repo
+		return nil
+	}
+}
+
+// WithFallbackRepository layers the repository under fallback, typically an EmbeddedRepository,
+// so that channels and packages the repository does not publish are loaded from fallback; see LayeredRepository.
+// It should be specified before WithPublicKeys and WithCache, so that the fallback is also verified and cached.
+func WithFallbackRepository(fallback Repository) ManifestLoaderOption {
+	return func(l *ManifestLoader) error {
+		repo, err := NewLayeredRepository(l.repo, fallback)
+		if err != nil {
+			return err
+		}
+		l.repo = repo
+		return nil
+	}
+}
+
+// WithOperatorVersion sets the version of the running operator,
+// so that channel versions that require a later operator are not installed.
+func WithOperatorVersion(version string) ManifestLoaderOption {
+	return func(l *ManifestLoader) error {
+		l.constraints.OperatorVersion = version
+		return nil
+	}
+}
+
+// WithKubernetesVersion sets the version of the cluster (eg from the discovery client's ServerVersion),
+// so that channel versions that do not support it are not installed.
+func WithKubernetesVersion(version string) ManifestLoaderOption {
+	return func(l *ManifestLoader) error {
+		l.constraints.KubernetesVersion = version
+		return nil
+	}
 }
 
 // NewManifestLoader provides a Repository that resolves versions based on an Addon object
 // and loads manifests from the filesystem.
-func NewManifestLoader(channel string) (*ManifestLoader, error) {
-	if strings.HasPrefix(channel, "http://") || strings.HasPrefix(channel, "https://") {
-		repo := NewHTTPRepository(channel)
-		return &ManifestLoader{repo: repo}, nil
+func NewManifestLoader(channel string, opts ...ManifestLoaderOption) (*ManifestLoader, error) {
+	var repo Repository
+	if strings.HasPrefix(channel, OCIPrefix) {
+		ociRepo, err := NewOCIRepository(channel)
+		if err != nil {
+			return nil, err
+		}
+		repo = ociRepo
+	} else if strings.HasPrefix(channel, "http://") || strings.HasPrefix(channel, "https://") {
+		repo = NewHTTPRepository(channel)
+	} else if strings.Contains(channel, "git//") || strings.Contains(channel, ".git") {
+		repo = NewGitRepository(channel)
+	} else {
+		repo = NewFSRepository(channel)
 	}
 
-	if strings.Contains(channel, "git//") || strings.Contains(channel, ".git") {
-		repo := NewGitRepository(channel)
-		return &ManifestLoader{repo: repo}, nil
-	}
+	return NewManifestLoaderForRepository(repo, opts...)
+}
 
-	repo := NewFSRepository(channel)
-	return &ManifestLoader{repo: repo}, nil
+// NewManifestLoaderForRepository provides a ManifestLoader that loads manifests from repo
+func NewManifestLoaderForRepository(repo Repository, opts ...ManifestLoaderOption) (*ManifestLoader, error) {
+	l := &ManifestLoader{repo: repo}
+	for _, opt := range opts {
+		if err := opt(l); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
 }
 
 func (c *ManifestLoader) ResolveManifest(ctx context.Context, object runtime.Object) (map[string]string, error) {
+	s, _, err := c.ResolveManifestWithMetadata(ctx, object)
+	return s, err
+}
+
+var _ declarative.ManifestResolver = &ManifestLoader{}
+var _ declarative.ManifestSourceWatcher = &ManifestLoader{}
+
+// WatchManifestSource reconciles objects when the ConfigMaps of a ConfigMapRepository change,
+// if the loader uses one (directly, or as a layer); see WatchConfigMapRepository.
+// It is called by declarative.WatchChildren.
+func (c *ManifestLoader) WatchManifestSource(mgr ctrl.Manager, ctl controller.Controller, prototype declarative.DeclarativeObject) error {
+	repo := findConfigMapRepository(c.repo)
+	if repo == nil {
+		return nil
+	}
+
+	gvk, err := apiutil.GVKForObject(prototype, mgr.GetScheme())
+	if err != nil {
+		return err
+	}
+	listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
+	var list client.ObjectList
+	if obj, err := mgr.GetScheme().New(listGVK); err == nil {
+		list, _ = obj.(client.ObjectList)
+	}
+	if list == nil {
+		u := &unstructured.UnstructuredList{}
+		u.SetGroupVersionKind(listGVK)
+		list = u
+	}
+	return WatchConfigMapRepository(mgr, ctl, repo, list)
+}
+
+// findConfigMapRepository returns the ConfigMapRepository that repo is or wraps, if any
+func findConfigMapRepository(repo Repository) *ConfigMapRepository {
+	switch r := repo.(type) {
+	case *ConfigMapRepository:
+		return r
+	case *LayeredRepository:
+		for _, layer := range r.layers {
+			if found := findConfigMapRepository(layer); found != nil {
+				return found
+			}
+		}
+	case *SignedRepository:
+		return findConfigMapRepository(r.repo)
+	case *CachingRepository:
+		return findConfigMapRepository(r.repo)
+	}
+	return nil
+}
+
+// ResolveManifestWithMetadata resolves and loads the manifest like ResolveManifest,
+// also returning how the version was chosen and whether an upgrade is available.
+func (c *ManifestLoader) ResolveManifestWithMetadata(ctx context.Context, object runtime.Object) (map[string]string, *declarative.ManifestResolution, error) {
 	log := log.FromContext(ctx)
 
 	var (
@@ -67,51 +209,271 @@ func (c *ManifestLoader) ResolveManifest(ctx context.Context, object runtime.Obj
 
 	spec, err := utils.GetCommonSpec(object)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	version = spec.Version
 	channelName = spec.Channel
 
 	componentName, err = utils.GetCommonName(object)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// TODO: We should actually do id (1.1.2-aws or 1.1.1-nginx). But maybe YAGNI
 	id := version
 
+	resolution := &declarative.ManifestResolution{Variant: spec.Variant}
+	constraints := c.constraints
+	constraints.Variant = spec.Variant
+
+	// channel is used to find the variants of the version, if it could be loaded
+	var channel *Channel
+
+	// A version constraint is resolved against the channel, like a channel without a version
+	if id != "" && IsVersionConstraint(id) {
+		constraints.Versions = id
+		id = ""
+	}
+
 	if id == "" {
 		// TODO: Put channel in spec
 		if channelName == "" {
 			channelName = "stable"
 		}
 
-		channel, err := c.repo.LoadChannel(ctx, channelName)
+		channel, err = c.repo.LoadChannel(ctx, channelName)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		var deployedVersion string
+		if status, err := utils.GetCommonStatus(object); err == nil {
+			deployedVersion = status.DeployedVersion
 		}
 
-		version, err := channel.Latest(ctx, componentName)
+		// If the constraint no longer allows the deployed version, we resolve as for a fresh install
+		if constraints.Versions != "" && deployedVersion != "" {
+			if ok, err := versionSatisfies(deployedVersion, constraints.Versions); err != nil {
+				return nil, nil, err
+			} else if !ok {
+				log.WithValues("version", constraints.Versions).WithValues("deployedVersion", deployedVersion).Info("deployed version does not satisfy version constraint")
+				deployedVersion = ""
+			}
+		}
+
+		version, err := c.resolveChannelVersion(ctx, channel, componentName, deployedVersion, spec, constraints, resolution)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		// TODO: We should probably copy the kubelet componentconfig
 
 		if version == nil {
-			return nil, fmt.Errorf("could not find latest version in channel %q", channelName)
+			if constraints.Versions != "" {
+				return nil, nil, fmt.Errorf("no compatible version in channel %q satisfies version constraint %q", channelName, constraints.Versions)
+			}
+			return nil, nil, fmt.Errorf("could not find a compatible version in channel %q", channelName)
 		}
 		id = version.Version
+		resolution.Channel = channelName
+
+		if version.Deprecated {
+			declarative.RecordWarning(ctx, "version %s of %s is deprecated: %s", id, componentName, version.DeprecationMessage)
+		}
 
-		log.WithValues("channel", channelName).WithValues("version", id).Info("resolved version from channel")
+		log.WithValues("channel", channelName).WithValues("version", id).WithValues("deployedVersion", deployedVersion).Info("resolved version from channel")
 	} else {
 		log.WithValues("version", version).V(2).Info("using specified version")
+		resolution.Pinned = true
+
+		// Report newer versions even though we won't install them, if the channel is available
+		if channelName == "" {
+			channelName = "stable"
+		}
+		if loaded, err := c.repo.LoadChannel(ctx, channelName); err != nil {
+			log.WithValues("channel", channelName).V(2).Info("unable to load channel for pinned version", "error", err.Error())
+		} else {
+			channel = loaded
+			if latest, err := channel.Next(ctx, componentName, "", constraints); err != nil {
+				log.WithValues("channel", channelName).V(2).Info("unable to resolve latest version", "error", err.Error())
+			} else if latest != nil {
+				resolution.Channel = channelName
+				resolution.LatestVersion = latest.Version
+				if latest.Compare(ctx, &Version{Package: latest.Package, Version: version}) > 0 {
+					resolution.AvailableVersion = latest.Version
+				}
+			}
+		}
+	}
+	resolution.Version = id
+
+	if packages := compositePackages(channel, componentName, id, spec); packages != nil {
+		s, err := c.loadComposite(ctx, channel, componentName, packages, spec.Variant, constraints, resolution)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error loading composite manifest: %v", err)
+		}
+		c.recordRevision(ctx, resolution)
+		return s, resolution, nil
+	}
+
+	s, err := c.loadVariant(ctx, channel, componentName, id, spec.Variant)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading manifest: %v", err)
+	}
+
+	c.recordRevision(ctx, resolution)
+	return s, resolution, nil
+}
+
+// recordRevision records the revision the manifest was loaded from, if the repository reports one
+func (c *ManifestLoader) recordRevision(ctx context.Context, resolution *declarative.ManifestResolution) {
+	reader, ok := c.repo.(RevisionReader)
+	if !ok {
+		return
+	}
+	revision, err := reader.Revision(ctx)
+	if err != nil {
+		log.FromContext(ctx).V(2).Info("unable to read repository revision", "error", err.Error())
+		return
+	}
+	resolution.Revision = revision
+}
+
+// loadVariant loads the package for version id, selecting the variant if one is specified.
+//
+// A variant is stored as the package <id>-<variant>, which holds either the complete package,
+// or, if the channel declares it as an overlay, the files to replace or add to the base version.
+// If the channel does not list the version (eg a version specified explicitly that is not in the channel),
+// the variant is expected to be a complete package.
+func (c *ManifestLoader) loadVariant(ctx context.Context, channel *Channel, componentName string, id string, variant string) (map[string]string, error) {
+	if variant == "" {
+		return c.repo.LoadManifest(ctx, componentName, id)
+	}
+
+	var declared *Variant
+	if channel != nil {
+		if v := channel.lookup(componentName, id); v != nil {
+			declared = v.FindVariant(variant)
+			if declared == nil {
+				var names []string
+				for _, d := range v.Variants {
+					names = append(names, d.Name)
+				}
+				return nil, fmt.Errorf("version %s of %s does not provide variant %q (available variants: %v)", id, componentName, variant, names)
+			}
+		}
+	}
+
+	variantID := id + "-" + variant
+	overlay, err := c.repo.LoadManifest(ctx, componentName, variantID)
+	if err != nil {
+		return nil, err
+	}
+	if declared == nil || !declared.Overlay {
+		return overlay, nil
 	}
-	s := make(map[string]string)
-	s, err = c.repo.LoadManifest(ctx, componentName, id)
+
+	base, err := c.repo.LoadManifest(ctx, componentName, id)
 	if err != nil {
-		return nil, fmt.Errorf("error loading manifest: %v", err)
+		return nil, err
+	}
+	return overlayManifests(base, overlay), nil
+}
+
+// overlayManifests replaces the files in base with the files in overlay that have the same name,
+// and adds the other files in overlay.  Keys are paths or URLs, which differ in the package directory,
+// so files are matched by their final path component.
+func overlayManifests(base, overlay map[string]string) map[string]string {
+	fileName := func(key string) string {
+		key = strings.ReplaceAll(key, "\\", "/")
+		return key[strings.LastIndex(key, "/")+1:]
 	}
 
-	return s, nil
+	overridden := make(map[string]bool)
+	for k := range overlay {
+		overridden[fileName(k)] = true
+	}
+
+	result := make(map[string]string)
+	for k, v := range base {
+		if !overridden[fileName(k)] {
+			result[k] = v
+		}
+	}
+	for k, v := range overlay {
+		result[k] = v
+	}
+	return result
+}
+
+// approvesVersion returns true if approving approvedVersion allows upgrading to version,
+// that is, approval covers every step up to and including the approved version.
+func approvesVersion(approvedVersion string, version string) bool {
+	if approvedVersion == "" {
+		return false
+	}
+	if approvedVersion == version {
+		return true
+	}
+	approved, err := semver.ParseTolerant(approvedVersion)
+	if err != nil {
+		return false
+	}
+	v, err := semver.ParseTolerant(version)
+	if err != nil {
+		return false
+	}
+	return v.LTE(approved)
+}
+
+// resolveChannelVersion chooses the version to deploy from the channel according to the upgrade policy,
+// recording the latest and available versions in resolution.
+func (c *ManifestLoader) resolveChannelVersion(ctx context.Context, channel *Channel, componentName string, deployedVersion string, spec addonsv1alpha1.CommonSpec, constraints UpgradeConstraints, resolution *declarative.ManifestResolution) (*Version, error) {
+	latest, err := channel.Next(ctx, componentName, "", constraints)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil {
+		resolution.LatestVersion = latest.Version
+	}
+
+	// We upgrade one permissible step at a time from the deployed version
+	next, err := channel.Next(ctx, componentName, deployedVersion, constraints)
+	if err != nil || next == nil || deployedVersion == "" || next.Version == deployedVersion {
+		return next, err
+	}
+
+	held := false
+	switch spec.UpgradePolicy {
+	case addonsv1alpha1.UpgradePolicyPinned:
+		resolution.Pinned = true
+		held = true
+	case addonsv1alpha1.UpgradePolicyManual:
+		if !approvesVersion(spec.ApprovedVersion, next.Version) {
+			resolution.AwaitingApproval = true
+			held = true
+		}
+	case addonsv1alpha1.UpgradePolicyAutomatic, "":
+	default:
+		return nil, fmt.Errorf("unknown upgrade policy %q", spec.UpgradePolicy)
+	}
+
+	if held {
+		resolution.AvailableVersion = next.Version
+		deployed := channel.lookup(componentName, deployedVersion)
+		if deployed == nil {
+			declarative.RecordWarning(ctx, "deployed version %s of %s is no longer in the channel, keeping it until the upgrade to %s is allowed", deployedVersion, componentName, next.Version)
+			deployed = &Version{Package: componentName, Version: deployedVersion}
+		}
+		return deployed, nil
+	}
+
+	// Report the step after this one, which will be applied once this version is deployed
+	after, err := channel.Next(ctx, componentName, next.Version, constraints)
+	if err != nil {
+		return nil, err
+	}
+	if after != nil && after.Version != next.Version {
+		resolution.AvailableVersion = after.Version
+	}
+	return next, nil
 }
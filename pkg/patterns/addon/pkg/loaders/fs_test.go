@@ -18,12 +18,16 @@ package loaders
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	addonv1alpha1 "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/apis/v1alpha1"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
 )
 
 func Test_NewManifestLoader(t *testing.T) {
@@ -57,6 +61,11 @@ func Test_NewManifestLoader(t *testing.T) {
 			channel:  "example.com/dummy.git",
 			expected: &GitRepository{},
 		},
+		{
+			name:     "oci pattern",
+			channel:  "oci://example.com/addons:v1",
+			expected: &OCIRepository{},
+		},
 	}
 	for _, test := range testcast {
 		t.Run(test.name, func(t *testing.T) {
@@ -128,6 +137,389 @@ func Test_ResolveManifest(t *testing.T) {
 	}
 }
 
+func Test_ResolveManifestWithMetadata(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"stable": `
+sequentialMinorUpgrades: true
+manifests:
+- name: testresource
+  version: 1.0.0
+- name: testresource
+  version: 1.1.0
+- name: testresource
+  version: 2.0.0
+  upgradeFrom: ">=1.1.0"
+`,
+		"packages/testresource/1.0.0/manifest.yaml": "v1.0.0",
+		"packages/testresource/1.1.0/manifest.yaml": "v1.1.0",
+		"packages/testresource/2.0.0/manifest.yaml": "v2.0.0",
+		"packages/testresource/1.2/manifest.yaml":   "v1.2",
+	}
+	for name, contents := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("error creating directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+	}
+
+	var testcases = []struct {
+		name     string
+		spec     addonv1alpha1.CommonSpec
+		deployed string
+		expected declarative.ManifestResolution
+		warning  string
+	}{
+		{
+			name:     "fresh install",
+			expected: declarative.ManifestResolution{Version: "2.0.0", Channel: "stable", LatestVersion: "2.0.0"},
+		},
+		{
+			name:     "automatic upgrade",
+			deployed: "1.0.0",
+			expected: declarative.ManifestResolution{Version: "1.1.0", Channel: "stable", LatestVersion: "2.0.0", AvailableVersion: "2.0.0"},
+		},
+		{
+			name:     "manual upgrade without approval",
+			spec:     addonv1alpha1.CommonSpec{UpgradePolicy: addonv1alpha1.UpgradePolicyManual},
+			deployed: "1.0.0",
+			expected: declarative.ManifestResolution{Version: "1.0.0", Channel: "stable", LatestVersion: "2.0.0", AvailableVersion: "1.1.0", AwaitingApproval: true},
+		},
+		{
+			name:     "manual upgrade with approval",
+			spec:     addonv1alpha1.CommonSpec{UpgradePolicy: addonv1alpha1.UpgradePolicyManual, ApprovedVersion: "1.1.0"},
+			deployed: "1.0.0",
+			expected: declarative.ManifestResolution{Version: "1.1.0", Channel: "stable", LatestVersion: "2.0.0", AvailableVersion: "2.0.0"},
+		},
+		{
+			name:     "manual upgrade steps towards a later approved version",
+			spec:     addonv1alpha1.CommonSpec{UpgradePolicy: addonv1alpha1.UpgradePolicyManual, ApprovedVersion: "2.0.0"},
+			deployed: "1.0.0",
+			expected: declarative.ManifestResolution{Version: "1.1.0", Channel: "stable", LatestVersion: "2.0.0", AvailableVersion: "2.0.0"},
+		},
+		{
+			name:     "manual upgrade holds beyond the approved version",
+			spec:     addonv1alpha1.CommonSpec{UpgradePolicy: addonv1alpha1.UpgradePolicyManual, ApprovedVersion: "1.1.0"},
+			deployed: "1.1.0",
+			expected: declarative.ManifestResolution{Version: "1.1.0", Channel: "stable", LatestVersion: "2.0.0", AvailableVersion: "2.0.0", AwaitingApproval: true},
+		},
+		{
+			name:     "held version removed from the channel",
+			spec:     addonv1alpha1.CommonSpec{UpgradePolicy: addonv1alpha1.UpgradePolicyPinned},
+			deployed: "1.2",
+			expected: declarative.ManifestResolution{Version: "1.2", Channel: "stable", LatestVersion: "2.0.0", AvailableVersion: "2.0.0", Pinned: true},
+			warning:  "deployed version 1.2 of testresource is no longer in the channel",
+		},
+		{
+			name:     "pinned policy",
+			spec:     addonv1alpha1.CommonSpec{UpgradePolicy: addonv1alpha1.UpgradePolicyPinned},
+			deployed: "1.1.0",
+			expected: declarative.ManifestResolution{Version: "1.1.0", Channel: "stable", LatestVersion: "2.0.0", AvailableVersion: "2.0.0", Pinned: true},
+		},
+		{
+			name:     "version constraint",
+			spec:     addonv1alpha1.CommonSpec{Version: "~1.0"},
+			expected: declarative.ManifestResolution{Version: "1.0.0", Channel: "stable", LatestVersion: "1.0.0"},
+		},
+		{
+			name:     "version constraint upgrades within the range",
+			spec:     addonv1alpha1.CommonSpec{Version: ">=1.0 <2"},
+			deployed: "1.0.0",
+			expected: declarative.ManifestResolution{Version: "1.1.0", Channel: "stable", LatestVersion: "1.1.0"},
+		},
+		{
+			name:     "version constraint excludes the deployed version",
+			spec:     addonv1alpha1.CommonSpec{Version: "^2.0"},
+			deployed: "1.0.0",
+			expected: declarative.ManifestResolution{Version: "2.0.0", Channel: "stable", LatestVersion: "2.0.0"},
+		},
+		{
+			name:     "explicit version",
+			spec:     addonv1alpha1.CommonSpec{Version: "1.0.0"},
+			expected: declarative.ManifestResolution{Version: "1.0.0", Channel: "stable", LatestVersion: "2.0.0", AvailableVersion: "2.0.0", Pinned: true},
+		},
+		{
+			name:     "partial version is loaded as a package id",
+			spec:     addonv1alpha1.CommonSpec{Version: "1.2"},
+			expected: declarative.ManifestResolution{Version: "1.2", Channel: "stable", LatestVersion: "2.0.0", AvailableVersion: "2.0.0", Pinned: true},
+		},
+	}
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, warnings := warningLogger(context.Background())
+			l, err := NewManifestLoader(dir)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			obj := &TestResource{
+				Spec:   TestResourceSpec{CommonSpec: test.spec},
+				Status: TestResourceStatus{CommonStatus: addonv1alpha1.CommonStatus{DeployedVersion: test.deployed}},
+			}
+			manifests, resolution, err := l.ResolveManifestWithMetadata(ctx, obj)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(*resolution, test.expected) {
+				t.Errorf("expected resolution %+v but got %+v", test.expected, *resolution)
+			}
+			if test.warning != "" && !strings.Contains(strings.Join(warnings(), "\n"), test.warning) {
+				t.Errorf("expected warning %q but got %v", test.warning, warnings())
+			}
+			want := "v" + test.expected.Version
+			if got := manifests[filepath.Join(dir, "packages/testresource", test.expected.Version, "manifest.yaml")]; got != want {
+				t.Errorf("expected manifest %q but got %v", want, manifests)
+			}
+		})
+	}
+}
+
+func Test_ResolveManifestVariants(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"stable": `
+manifests:
+- name: testresource
+  version: 1.0.0
+  variants:
+  - name: aws
+  - name: nginx
+    overlay: true
+- name: testresource
+  version: 1.1.0
+`,
+		"packages/testresource/1.0.0/manifest.yaml":       "base",
+		"packages/testresource/1.0.0/service.yaml":        "service",
+		"packages/testresource/1.0.0-aws/manifest.yaml":   "aws",
+		"packages/testresource/1.0.0-nginx/manifest.yaml": "nginx",
+		"packages/testresource/1.0.0-nginx/ingress.yaml":  "ingress",
+		"packages/testresource/1.1.0/manifest.yaml":       "base",
+		"packages/testresource/1.1.0-gcp/manifest.yaml":   "gcp",
+	}
+	for name, contents := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("error creating directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+	}
+
+	var testcases = []struct {
+		name     string
+		spec     addonv1alpha1.CommonSpec
+		expected map[string]string
+		err      bool
+	}{
+		{
+			name: "no variant",
+			spec: addonv1alpha1.CommonSpec{},
+			expected: map[string]string{
+				"packages/testresource/1.1.0/manifest.yaml": "base",
+			},
+		},
+		{
+			name: "complete variant, resolved to the latest version providing it",
+			spec: addonv1alpha1.CommonSpec{Variant: "aws"},
+			expected: map[string]string{
+				"packages/testresource/1.0.0-aws/manifest.yaml": "aws",
+			},
+		},
+		{
+			name: "overlay variant",
+			spec: addonv1alpha1.CommonSpec{Variant: "nginx"},
+			expected: map[string]string{
+				"packages/testresource/1.0.0-nginx/manifest.yaml": "nginx",
+				"packages/testresource/1.0.0-nginx/ingress.yaml":  "ingress",
+				"packages/testresource/1.0.0/service.yaml":        "service",
+			},
+		},
+		{
+			name: "variant not declared by the explicit version",
+			spec: addonv1alpha1.CommonSpec{Version: "1.1.0", Variant: "gcp"},
+			err:  true,
+		},
+		{
+			name: "variant not provided by any version",
+			spec: addonv1alpha1.CommonSpec{Variant: "azure"},
+			err:  true,
+		},
+	}
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			l, err := NewManifestLoader(dir)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			obj := &TestResource{Spec: TestResourceSpec{CommonSpec: test.spec}}
+			manifests, resolution, err := l.ResolveManifestWithMetadata(ctx, obj)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error, got %v", manifests)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resolution.Variant != test.spec.Variant {
+				t.Errorf("expected variant %q, got %q", test.spec.Variant, resolution.Variant)
+			}
+			expected := make(map[string]string)
+			for k, v := range test.expected {
+				expected[filepath.Join(dir, k)] = v
+			}
+			if !reflect.DeepEqual(manifests, expected) {
+				t.Errorf("expected manifests %v but got %v", expected, manifests)
+			}
+		})
+	}
+}
+
+func Test_ResolveManifestComposite(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"stable": `
+manifests:
+- name: testresource
+  version: 1.0.0
+  packages:
+  - name: crds
+    version: 1.0.0
+  - name: controller
+    version: ~1.2
+  - name: dashboard
+    optional: true
+- name: testresource
+  version: 0.9.0
+- name: crds
+  version: 1.0.0
+- name: controller
+  version: 1.2.0
+- name: controller
+  version: 1.2.1
+- name: controller
+  version: 1.3.0
+- name: dashboard
+  version: 0.1.0
+- name: dashboard
+  version: 0.2.0
+`,
+		"packages/crds/1.0.0/crds.yaml":             "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: tests.example.com\n",
+		"packages/controller/1.2.0/manifest.yaml":   "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: controller-1.2.0\n",
+		"packages/controller/1.2.1/manifest.yaml":   "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: controller\n",
+		"packages/controller/1.3.0/manifest.yaml":   "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: controller-1.3.0\n",
+		"packages/dashboard/0.1.0/manifest.yaml":    "apiVersion: v1\nkind: Service\nmetadata:\n  name: dashboard\n",
+		"packages/dashboard/0.2.0/manifest.yaml":    "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: controller\n",
+		"packages/testresource/0.9.0/manifest.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: testresource\n",
+	}
+	for name, contents := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("error creating directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+	}
+
+	enabled, disabled := true, false
+	var testcases = []struct {
+		name     string
+		spec     addonv1alpha1.CommonSpec
+		expected []declarative.PackageResolution
+		err      string
+	}{
+		{
+			name:     "packages declared by the channel",
+			expected: []declarative.PackageResolution{{Name: "controller", Version: "1.2.1"}, {Name: "crds", Version: "1.0.0"}},
+		},
+		{
+			name: "optional package enabled, package disabled",
+			spec: addonv1alpha1.CommonSpec{Packages: []addonv1alpha1.PackageSpec{
+				{Name: "dashboard", Version: "0.1.0", Enabled: &enabled},
+				{Name: "crds", Enabled: &disabled},
+			}},
+			expected: []declarative.PackageResolution{{Name: "controller", Version: "1.2.1"}, {Name: "dashboard", Version: "0.1.0"}},
+		},
+		{
+			name:     "version overridden",
+			spec:     addonv1alpha1.CommonSpec{Packages: []addonv1alpha1.PackageSpec{{Name: "controller", Version: "1.3.0"}}},
+			expected: []declarative.PackageResolution{{Name: "controller", Version: "1.3.0"}, {Name: "crds", Version: "1.0.0"}},
+		},
+		{
+			name:     "addon's own package included when the channel does not declare packages",
+			spec:     addonv1alpha1.CommonSpec{Version: "0.9.0", Packages: []addonv1alpha1.PackageSpec{{Name: "crds"}}},
+			expected: []declarative.PackageResolution{{Name: "crds", Version: "1.0.0"}, {Name: "testresource", Version: "0.9.0"}},
+		},
+		{
+			name: "collision",
+			spec: addonv1alpha1.CommonSpec{Packages: []addonv1alpha1.PackageSpec{{Name: "dashboard", Enabled: &enabled}}},
+			err:  "packages controller and dashboard both define Deployment.apps /controller",
+		},
+		{
+			name: "unsatisfiable package version",
+			spec: addonv1alpha1.CommonSpec{Packages: []addonv1alpha1.PackageSpec{{Name: "controller", Version: "^2"}}},
+			err:  "no compatible version of package controller",
+		},
+	}
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			l, err := NewManifestLoader(dir)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			obj := &TestResource{Spec: TestResourceSpec{CommonSpec: test.spec}}
+			manifests, resolution, err := l.ResolveManifestWithMetadata(ctx, obj)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := test.expected
+			if !reflect.DeepEqual(resolution.Packages, expected) {
+				t.Errorf("expected packages %+v but got %+v", expected, resolution.Packages)
+			}
+			for _, p := range expected {
+				found := false
+				for k := range manifests {
+					if strings.Contains(k, filepath.Join("packages", p.Name, p.Version)) {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("expected manifests from %s %s, got %v", p.Name, p.Version, manifests)
+				}
+			}
+			if len(manifests) != len(expected) {
+				t.Errorf("expected one file per package, got %v", manifests)
+			}
+		})
+	}
+}
+
+func Test_ResolveManifestVersionConstraintError(t *testing.T) {
+	ctx := context.Background()
+	l, err := NewManifestLoaderForRepository(&TestRepository{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	obj := &TestResource{Spec: TestResourceSpec{CommonSpec: addonv1alpha1.CommonSpec{Version: "^2.0"}}}
+	_, err = l.ResolveManifest(ctx, obj)
+	if err == nil || !strings.Contains(err.Error(), `satisfies version constraint "^2.0"`) {
+		t.Errorf("expected error for unsatisfiable constraint, got %v", err)
+	}
+}
+
 // Below define struct for testing.
 
 func newTestLoader() *ManifestLoader {
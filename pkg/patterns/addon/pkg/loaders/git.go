@@ -2,31 +2,118 @@ package loaders
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"golang.org/x/crypto/ssh"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
 )
 
+// DefaultGitRefreshInterval is how often a git repository is fetched in the background
+const DefaultGitRefreshInterval = 5 * time.Minute
+
+// GitOptions configures a GitRepository
+type GitOptions struct {
+	// WorkspaceDir is the directory under which a workspace is checked out for each repository URL and ref.
+	// Defaults to a directory under os.TempDir().
+	WorkspaceDir string
+
+	// RefreshInterval is how often the repository is fetched in the background;
+	// defaults to DefaultGitRefreshInterval, a negative value disables refreshing.
+	// A ref that is a commit SHA is never refreshed.
+	RefreshInterval time.Duration
+
+	// Auth is used to authenticate to the remote; if nil, ~/.ssh/id_rsa is used if it exists.
+	Auth transport.AuthMethod
+}
+
+// GitRepository is a Repository backed by a git repository.
+//
+// The URL can select a subdirectory and a ref (tag, branch or full commit SHA), for example
+// git::https://github.com/example/addons.git//channels?ref=v1.2.0
+// If no ref is specified, the default branch is tracked.
+//
+// The repository is fetched on first use, and then refreshed in the background,
+// so reconciliation only reads from the local checkout.
 type GitRepository struct {
 	baseURL string
 	subDir  string
-	branch  string
+	ref     string
+
+	options   GitOptions
+	workspace *gitWorkspace
 }
 
 var _ Repository = &GitRepository{}
+var _ RevisionReader = &GitRepository{}
+
+// gitWorkspace is a local checkout of a repository at a ref
+type gitWorkspace struct {
+	dir string
+
+	// mutex is held for writing while syncing, and for reading while reading files
+	mutex    sync.RWMutex
+	commit   string
+	lastSync time.Time
+
+	// stateMutex protects the background refresh state
+	stateMutex  sync.Mutex
+	refreshing  bool
+	refreshErr  error
+	refreshedAt time.Time
+}
+
+// gitWorkspaces holds a workspace per directory, so that repositories with the same URL and ref share a checkout
+var gitWorkspaces = struct {
+	sync.Mutex
+	m map[string]*gitWorkspace
+}{m: make(map[string]*gitWorkspace)}
 
 // NewGitRepository constructs an GitRepository
 func NewGitRepository(baseurl string) *GitRepository {
+	return NewGitRepositoryWithOptions(baseurl, GitOptions{})
+}
+
+// NewGitRepositoryWithOptions constructs an GitRepository with the specified options
+func NewGitRepositoryWithOptions(baseurl string, options GitOptions) *GitRepository {
 	repo := parseGitURL(baseurl)
+
+	if options.WorkspaceDir == "" {
+		options.WorkspaceDir = filepath.Join(os.TempDir(), "kubebuilder-declarative-pattern", "git")
+	}
+	if options.RefreshInterval == 0 {
+		options.RefreshInterval = DefaultGitRefreshInterval
+	}
+	repo.options = options
+
+	key := sha256.Sum256([]byte(repo.baseURL + "?ref=" + repo.ref))
+	dir := filepath.Join(options.WorkspaceDir, hex.EncodeToString(key[:8]))
+
+	gitWorkspaces.Lock()
+	defer gitWorkspaces.Unlock()
+	ws := gitWorkspaces.m[dir]
+	if ws == nil {
+		ws = &gitWorkspace{dir: dir}
+		gitWorkspaces.m[dir] = ws
+	}
+	repo.workspace = ws
+
 	return &repo
 }
 
@@ -36,13 +123,9 @@ func (r *GitRepository) LoadChannel(ctx context.Context, name string) (*Channel,
 	}
 
 	log := log.FromContext(ctx)
-	log.WithValues("baseURL", r.baseURL).Info("loading channel")
-	log.WithValues("baseURL", r.baseURL).Info("cloning git repository")
+	log.WithValues("baseURL", r.baseURL).WithValues("ref", r.ref).Info("loading channel")
 
-	if r.subDir != "" {
-		name = r.subDir + "/" + name
-	}
-	b, err := r.readURL(name)
+	b, err := r.ReadRawFile(ctx, name)
 	if err != nil {
 		log.WithValues("path", name).Error(err, "error reading channel")
 		return nil, err
@@ -68,119 +151,293 @@ func (r *GitRepository) LoadManifest(ctx context.Context, packageName string, id
 	log := log.FromContext(ctx)
 	log.WithValues("package", packageName).V(2).Info("loading package")
 
-	var filePath string
-	if r.subDir == "" {
-		filePath = path.Join("packages", packageName, id, "manifest.yaml")
-	} else {
-		filePath = path.Join(r.subDir, "packages", packageName, id, "manifest.yaml")
+	if err := r.ensureSynced(ctx); err != nil {
+		return nil, err
 	}
 
-	b, err := r.readURL(filePath)
+	r.workspace.mutex.RLock()
+	defer r.workspace.mutex.RUnlock()
 
+	dirPath := path.Join(r.subDir, "packages", packageName, id)
+	entries, err := os.ReadDir(filepath.Join(r.workspace.dir, filepath.FromSlash(dirPath)))
 	if err != nil {
-		return nil, fmt.Errorf("error reading package %s: %v", filePath, err)
+		return nil, fmt.Errorf("error reading package %s: %w", dirPath, err)
 	}
-	result := map[string]string{
-		filePath: string(b),
+	result := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			log.V(2).Info("skipping directory", "directory", entry.Name())
+			continue
+		}
+
+		filePath := path.Join(dirPath, entry.Name())
+		b, err := os.ReadFile(filepath.Join(r.workspace.dir, filepath.FromSlash(filePath)))
+		if err != nil {
+			return nil, fmt.Errorf("error reading file %s: %v", filePath, err)
+		}
+		result[filePath] = string(b)
 	}
 
 	return result, nil
 }
 
-func (r *GitRepository) readURL(url string) ([]byte, error) {
-	repoDir := "/tmp/repo"
-	filePath := filepath.Join(repoDir, url)
-	fmt.Println(r.baseURL)
-
-	auth, err := getAuthMethod()
-	if err != nil {
+// ReadRawFile reads a file relative to the repository root, for signature verification
+func (r *GitRepository) ReadRawFile(ctx context.Context, p string) ([]byte, error) {
+	if !allowedRawPath(p) {
+		return nil, fmt.Errorf("invalid path: %q", p)
+	}
+	if err := r.ensureSynced(ctx); err != nil {
 		return nil, err
 	}
 
-	_, err = git.PlainClone(repoDir, false, &git.CloneOptions{
-		URL:               r.baseURL,
-		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
-		Auth:              auth,
-	})
+	r.workspace.mutex.RLock()
+	defer r.workspace.mutex.RUnlock()
 
-	if err != nil && err != git.ErrRepositoryAlreadyExists {
-		return nil, err
+	return os.ReadFile(filepath.Join(r.workspace.dir, filepath.FromSlash(path.Join(r.subDir, p))))
+}
+
+// Revision returns the commit SHA that manifests are currently loaded from, fetching the repository if needed
+func (r *GitRepository) Revision(ctx context.Context) (string, error) {
+	if err := r.ensureSynced(ctx); err != nil {
+		return "", err
 	}
 
-	if err == git.ErrRepositoryAlreadyExists {
-		err := handleExistingRepo(repoDir, auth)
-		if err != nil {
-			return nil, err
+	r.workspace.mutex.RLock()
+	defer r.workspace.mutex.RUnlock()
+	return r.workspace.commit, nil
+}
+
+// ensureSynced fetches the repository if it has never been fetched,
+// and otherwise starts a background refresh if the checkout is older than the RefreshInterval.
+func (r *GitRepository) ensureSynced(ctx context.Context) error {
+	ws := r.workspace
+
+	ws.mutex.RLock()
+	commit := ws.commit
+	lastSync := ws.lastSync
+	ws.mutex.RUnlock()
+
+	if commit == "" {
+		ws.mutex.Lock()
+		defer ws.mutex.Unlock()
+		if ws.commit != "" {
+			return nil
 		}
+		return r.sync(ctx)
 	}
 
-	b, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, err
+	ws.stateMutex.Lock()
+	defer ws.stateMutex.Unlock()
+	if ws.refreshErr != nil {
+		declarative.RecordWarning(ctx, "using git commit %s from %v, as refreshing %s failed: %v", commit, lastSync.Format(time.RFC3339), r.baseURL, ws.refreshErr)
+	}
+
+	if r.options.RefreshInterval < 0 || isCommitSHA(r.ref) || ws.refreshing {
+		return nil
+	}
+	if time.Since(lastSync) < r.options.RefreshInterval && time.Since(ws.refreshedAt) < r.options.RefreshInterval {
+		return nil
 	}
 
-	return b, nil
+	ws.refreshing = true
+	go func() {
+		ctx := log.IntoContext(context.Background(), log.FromContext(ctx))
+
+		ws.mutex.Lock()
+		err := r.sync(ctx)
+		ws.mutex.Unlock()
+		if err != nil {
+			log.FromContext(ctx).Error(err, "error refreshing git repository", "url", r.baseURL, "ref", r.ref)
+		}
+
+		ws.stateMutex.Lock()
+		ws.refreshing = false
+		ws.refreshErr = err
+		ws.refreshedAt = time.Now()
+		ws.stateMutex.Unlock()
+	}()
+	return nil
 }
 
-func parseGitURL(url string) GitRepository {
-	// checks for git:: suffix
-	var subdir string
-	if strings.HasPrefix(url, "git::") {
-		url = strings.TrimPrefix(url, "git::")
-	}
+// sync fetches the ref and checks it out into the workspace; the caller must hold the workspace lock for writing.
+func (r *GitRepository) sync(ctx context.Context) error {
+	log := log.FromContext(ctx)
+	ws := r.workspace
 
-	// checks for subdirectories
-	if strings.Contains(url, ".git//") {
-		urlComponent := strings.SplitN(url, ".git//", 2)
-		url = urlComponent[0] + ".git"
-		subdir = urlComponent[1]
+	auth := r.options.Auth
+	if auth == nil {
+		a, err := getAuthMethod()
+		if err != nil {
+			return err
+		}
+		auth = a
 	}
 
-	return GitRepository{
-		baseURL: url,
-		subDir:  subdir,
+	repo, err := git.PlainOpen(ws.dir)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		if err := os.MkdirAll(ws.dir, 0755); err != nil {
+			return fmt.Errorf("error creating git workspace: %w", err)
+		}
+		repo, err = git.PlainInit(ws.dir, false)
+		if err != nil {
+			return fmt.Errorf("error initializing git workspace: %w", err)
+		}
+		if _, err := repo.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{r.baseURL}}); err != nil {
+			return fmt.Errorf("error configuring git remote: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("error opening git workspace %s: %w", ws.dir, err)
 	}
-}
 
-func handleExistingRepo(path string, auth transport.AuthMethod) error {
-	gitRepo, err := git.PlainOpen(path)
+	remote, err := repo.Remote(git.DefaultRemoteName)
 	if err != nil {
-		return err
+		return fmt.Errorf("error getting git remote: %w", err)
 	}
 
-	remote, err := gitRepo.Remote("origin")
-	if err != nil {
-		return err
+	log.WithValues("url", r.baseURL).WithValues("ref", r.ref).Info("fetching git repository")
+
+	var hash plumbing.Hash
+	if isCommitSHA(r.ref) {
+		// Commits can't be fetched directly, so we fetch all branches and tags
+		err := remote.FetchContext(ctx, &git.FetchOptions{
+			RefSpecs: []config.RefSpec{"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"},
+			Auth:     auth,
+			Force:    true,
+		})
+		if err != nil && err != git.NoErrAlreadyUpToDate {
+			return fmt.Errorf("error fetching %s: %w", r.baseURL, err)
+		}
+		hash = plumbing.NewHash(r.ref)
+	} else {
+		refs, err := remote.List(&git.ListOptions{Auth: auth})
+		if err != nil {
+			return fmt.Errorf("error listing refs of %s: %w", r.baseURL, err)
+		}
+		refName, err := resolveRemoteRef(refs, r.ref)
+		if err != nil {
+			return fmt.Errorf("error resolving ref of %s: %w", r.baseURL, err)
+		}
+
+		err = remote.FetchContext(ctx, &git.FetchOptions{
+			RefSpecs: []config.RefSpec{config.RefSpec("+" + refName + ":" + refName)},
+			Depth:    1,
+			Auth:     auth,
+			Tags:     git.NoTags,
+			Force:    true,
+		})
+		if err != nil && err != git.NoErrAlreadyUpToDate {
+			return fmt.Errorf("error fetching %s from %s: %w", refName, r.baseURL, err)
+		}
+
+		ref, err := repo.Reference(plumbing.ReferenceName(refName), true)
+		if err != nil {
+			return fmt.Errorf("error reading fetched ref %s: %w", refName, err)
+		}
+		hash = ref.Hash()
+
+		// Peel annotated tags
+		if tag, err := repo.TagObject(hash); err == nil {
+			commit, err := tag.Commit()
+			if err != nil {
+				return fmt.Errorf("error reading commit for tag %s: %w", refName, err)
+			}
+			hash = commit.Hash
+		}
 	}
 
-	err = remote.Fetch(&git.FetchOptions{
-		Force: true,
-		Auth:  auth,
-	})
-	if err != nil && err != git.NoErrAlreadyUpToDate {
-		return err
+	if _, err := repo.CommitObject(hash); err != nil {
+		return fmt.Errorf("commit %s not found in %s: %w", hash, r.baseURL, err)
 	}
 
-	w, err := gitRepo.Worktree()
+	w, err := repo.Worktree()
 	if err != nil {
 		return err
 	}
+	if err := w.Checkout(&git.CheckoutOptions{Hash: hash, Force: true}); err != nil {
+		return fmt.Errorf("error checking out %s: %w", hash, err)
+	}
+	if err := w.Clean(&git.CleanOptions{Dir: true}); err != nil {
+		return fmt.Errorf("error cleaning git workspace: %w", err)
+	}
 
-	err = w.Checkout(&git.CheckoutOptions{
-		Branch: "refs/heads/master",
-	})
-	if err != nil {
-		return err
+	if ws.commit != hash.String() {
+		log.WithValues("url", r.baseURL).WithValues("ref", r.ref).WithValues("commit", hash.String()).Info("checked out git repository")
 	}
+	ws.commit = hash.String()
+	ws.lastSync = time.Now()
+	return nil
+}
 
-	err = w.Reset(&git.ResetOptions{
-		Mode: git.HardReset,
-	})
-	if err != nil {
-		return err
+// resolveRemoteRef finds the full name of ref (a tag or branch) in the advertised refs,
+// or of the default branch if ref is empty
+func resolveRemoteRef(refs []*plumbing.Reference, ref string) (string, error) {
+	byName := make(map[plumbing.ReferenceName]*plumbing.Reference)
+	for _, r := range refs {
+		byName[r.Name()] = r
 	}
 
-	return nil
+	if ref == "" {
+		head, ok := byName[plumbing.HEAD]
+		if !ok {
+			return "", fmt.Errorf("remote does not advertise HEAD")
+		}
+		if head.Type() == plumbing.SymbolicReference {
+			return head.Target().String(), nil
+		}
+		for _, r := range refs {
+			if r.Name().IsBranch() && r.Hash() == head.Hash() {
+				return r.Name().String(), nil
+			}
+		}
+		return "", fmt.Errorf("unable to determine default branch")
+	}
+
+	for _, name := range []plumbing.ReferenceName{
+		plumbing.NewTagReferenceName(ref),
+		plumbing.NewBranchReferenceName(ref),
+	} {
+		if _, ok := byName[name]; ok {
+			return name.String(), nil
+		}
+	}
+	return "", fmt.Errorf("ref %q not found (expected a tag, branch or full commit SHA)", ref)
+}
+
+// isCommitSHA returns true if ref is a full hex commit SHA
+func isCommitSHA(ref string) bool {
+	if len(ref) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(ref)
+	return err == nil
+}
+
+func parseGitURL(url string) GitRepository {
+	// checks for git:: suffix
+	var subdir string
+	if strings.HasPrefix(url, "git::") {
+		url = strings.TrimPrefix(url, "git::")
+	}
+
+	// checks for a ref
+	var ref string
+	if i := strings.LastIndex(url, "?ref="); i != -1 {
+		ref = url[i+len("?ref="):]
+		url = url[:i]
+	}
+
+	// checks for subdirectories
+	if strings.Contains(url, ".git//") {
+		urlComponent := strings.SplitN(url, ".git//", 2)
+		url = urlComponent[0] + ".git"
+		subdir = urlComponent[1]
+	}
+
+	return GitRepository{
+		baseURL: url,
+		subDir:  subdir,
+		ref:     ref,
+	}
 }
 
 func getAuthMethod() (transport.AuthMethod, error) {
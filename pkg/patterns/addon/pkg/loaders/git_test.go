@@ -1,7 +1,16 @@
 package loaders
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 func TestParseGitURL(t *testing.T) {
@@ -9,6 +18,7 @@ func TestParseGitURL(t *testing.T) {
 		rawURL  string
 		baseURL string
 		subDir  string
+		ref     string
 	}{
 		{
 			rawURL:  "https://github.com/testRepository.git",
@@ -25,6 +35,17 @@ func TestParseGitURL(t *testing.T) {
 			baseURL: "https://github.com/testRepository.git",
 			subDir:  "subDir/package",
 		},
+		{
+			rawURL:  "git::https://github.com/testRepository.git//subDir?ref=v1.2.0",
+			baseURL: "https://github.com/testRepository.git",
+			subDir:  "subDir",
+			ref:     "v1.2.0",
+		},
+		{
+			rawURL:  "https://github.com/testRepository.git?ref=main",
+			baseURL: "https://github.com/testRepository.git",
+			ref:     "main",
+		},
 	}
 
 	for _, tt := range tests {
@@ -36,5 +57,257 @@ func TestParseGitURL(t *testing.T) {
 		if gitRepo.subDir != tt.subDir {
 			t.Errorf("Expected base url: %v, got %v", tt.subDir, gitRepo.subDir)
 		}
+
+		if gitRepo.ref != tt.ref {
+			t.Errorf("Expected ref: %v, got %v", tt.ref, gitRepo.ref)
+		}
+	}
+}
+
+// commitFiles writes files to the worktree and commits them, returning the commit hash
+func commitFiles(t *testing.T, repo *git.Repository, dir string, files map[string]string) plumbing.Hash {
+	t.Helper()
+
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("error getting worktree: %v", err)
+	}
+	for name, contents := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("error creating directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+		if _, err := w.Add(name); err != nil {
+			t.Fatalf("error adding file: %v", err)
+		}
+	}
+	hash, err := w.Commit("update", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("error committing: %v", err)
+	}
+	return hash
+}
+
+// testRemote is a bare repository that commits are pushed to, like a git server
+type testRemote struct {
+	dir     string
+	work    *git.Repository
+	workDir string
+}
+
+func newTestRemote(t *testing.T) *testRemote {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "addons.git")
+	if _, err := git.PlainInit(dir, true); err != nil {
+		t.Fatalf("error creating bare repository: %v", err)
+	}
+
+	workDir := t.TempDir()
+	work, err := git.PlainInit(workDir, false)
+	if err != nil {
+		t.Fatalf("error creating repository: %v", err)
+	}
+	if _, err := work.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{dir}}); err != nil {
+		t.Fatalf("error creating remote: %v", err)
+	}
+	return &testRemote{dir: dir, work: work, workDir: workDir}
+}
+
+// commit commits the files and pushes them to the bare repository
+func (r *testRemote) commit(t *testing.T, files map[string]string) plumbing.Hash {
+	t.Helper()
+
+	hash := commitFiles(t, r.work, r.workDir, files)
+	r.push(t)
+	return hash
+}
+
+// push pushes all branches and tags to the bare repository
+func (r *testRemote) push(t *testing.T) {
+	t.Helper()
+
+	err := r.work.Push(&git.PushOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{"refs/heads/*:refs/heads/*", "refs/tags/*:refs/tags/*"},
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		t.Fatalf("error pushing: %v", err)
+	}
+}
+
+func TestGitRepository(t *testing.T) {
+	ctx := context.Background()
+
+	remote := newTestRemote(t)
+	remoteDir := remote.dir
+
+	v1 := remote.commit(t, map[string]string{
+		"channels/stable": "manifests:\n- name: nginx\n  version: 0.1.0\n",
+		"channels/packages/nginx/0.1.0/manifest.yaml": "kind: ConfigMap\n",
+		"channels/packages/nginx/0.1.0/service.yaml":  "kind: Service\n",
+	})
+	if _, err := remote.work.CreateTag("v1", v1, &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		Message: "v1",
+	}); err != nil {
+		t.Fatalf("error creating tag: %v", err)
+	}
+	v2 := remote.commit(t, map[string]string{
+		"channels/stable": "manifests:\n- name: nginx\n  version: 0.2.0\n",
+		"channels/packages/nginx/0.2.0/manifest.yaml": "kind: ConfigMap\n",
+	})
+
+	head, err := remote.work.Head()
+	if err != nil {
+		t.Fatalf("error reading HEAD: %v", err)
+	}
+	branch := head.Name().Short()
+
+	grid := []struct {
+		ref     string
+		version string
+		commit  plumbing.Hash
+	}{
+		{ref: "", version: "0.2.0", commit: v2},
+		{ref: branch, version: "0.2.0", commit: v2},
+		{ref: "v1", version: "0.1.0", commit: v1},
+		{ref: v1.String(), version: "0.1.0", commit: v1},
+	}
+
+	workspaceDir := t.TempDir()
+	for _, g := range grid {
+		t.Run("ref="+g.ref, func(t *testing.T) {
+			url := "git::" + remoteDir + "//channels"
+			if g.ref != "" {
+				url += "?ref=" + g.ref
+			}
+			repo := NewGitRepositoryWithOptions(url, GitOptions{WorkspaceDir: workspaceDir, RefreshInterval: -1})
+
+			channel, err := repo.LoadChannel(ctx, "stable")
+			if err != nil {
+				t.Fatalf("unexpected error loading channel: %v", err)
+			}
+			if len(channel.Manifests) != 1 || channel.Manifests[0].Version != g.version {
+				t.Errorf("unexpected channel %+v", channel)
+			}
+
+			revision, err := repo.Revision(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error getting revision: %v", err)
+			}
+			if revision != g.commit.String() {
+				t.Errorf("expected revision %s, got %s", g.commit, revision)
+			}
+		})
+	}
+
+	// All files in the package are loaded
+	repo := NewGitRepositoryWithOptions("git::"+remoteDir+"//channels?ref=v1", GitOptions{WorkspaceDir: workspaceDir, RefreshInterval: -1})
+	manifests, err := repo.LoadManifest(ctx, "nginx", "0.1.0")
+	if err != nil {
+		t.Fatalf("unexpected error loading manifest: %v", err)
+	}
+	if len(manifests) != 2 {
+		t.Errorf("expected 2 manifests, got %v", manifests)
+	}
+
+	// Unknown refs are reported
+	repo = NewGitRepositoryWithOptions("git::"+remoteDir+"?ref=nosuchref", GitOptions{WorkspaceDir: workspaceDir, RefreshInterval: -1})
+	if _, err := repo.LoadChannel(ctx, "stable"); err == nil {
+		t.Errorf("expected error for unknown ref")
+	}
+}
+
+func TestGitRepositoryRefresh(t *testing.T) {
+	ctx := context.Background()
+
+	remote := newTestRemote(t)
+	remote.commit(t, map[string]string{
+		"stable": "manifests:\n- name: nginx\n  version: 0.1.0\n",
+	})
+
+	repo := NewGitRepositoryWithOptions(remote.dir, GitOptions{WorkspaceDir: t.TempDir(), RefreshInterval: time.Millisecond})
+	if _, err := repo.LoadChannel(ctx, "stable"); err != nil {
+		t.Fatalf("unexpected error loading channel: %v", err)
+	}
+
+	v2 := remote.commit(t, map[string]string{
+		"stable": "manifests:\n- name: nginx\n  version: 0.2.0\n",
+	})
+
+	// Loads trigger a background refresh, and are served from the existing checkout until it completes
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		time.Sleep(10 * time.Millisecond)
+		channel, err := repo.LoadChannel(ctx, "stable")
+		if err != nil {
+			t.Fatalf("unexpected error loading channel: %v", err)
+		}
+		if channel.Manifests[0].Version == "0.2.0" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for refresh")
+		}
+	}
+
+	revision, err := repo.Revision(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error getting revision: %v", err)
+	}
+	if revision != v2.String() {
+		t.Errorf("expected revision %s, got %s", v2, revision)
+	}
+}
+
+func TestGitRepositoryResolutionRevision(t *testing.T) {
+	ctx := context.Background()
+
+	remote := newTestRemote(t)
+	commit := remote.commit(t, map[string]string{
+		"stable": "manifests:\n- name: testresource\n  version: 0.1.0\n",
+		"packages/testresource/0.1.0/manifest.yaml": "kind: ConfigMap\n",
+	})
+
+	// The revision is also reported through the repositories that wrap the git repository
+	wrappers := map[string]func(repo Repository) (Repository, error){
+		"git": func(repo Repository) (Repository, error) {
+			return repo, nil
+		},
+		"cached": func(repo Repository) (Repository, error) {
+			return NewCachingRepository(repo, CacheOptions{})
+		},
+		"layered": func(repo Repository) (Repository, error) {
+			return NewLayeredRepository(repo, NewFSRepository(t.TempDir()))
+		},
+	}
+	for name, wrap := range wrappers {
+		t.Run(name, func(t *testing.T) {
+			repo, err := wrap(NewGitRepositoryWithOptions(remote.dir, GitOptions{WorkspaceDir: t.TempDir(), RefreshInterval: -1}))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			l, err := NewManifestLoaderForRepository(repo)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, resolution, err := l.ResolveManifestWithMetadata(ctx, &TestResource{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resolution.Version != "0.1.0" {
+				t.Errorf("expected version 0.1.0, got %q", resolution.Version)
+			}
+			if resolution.Revision != commit.String() {
+				t.Errorf("expected revision %s, got %q", commit, resolution.Revision)
+			}
+		})
 	}
 }
@@ -18,27 +18,151 @@ package loaders
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/yaml"
 )
 
+const (
+	// DefaultHTTPTimeout is the default timeout for each HTTP request
+	DefaultHTTPTimeout = 30 * time.Second
+
+	// DefaultHTTPMaxRetries is the default number of times a request is retried after a server error
+	DefaultHTTPMaxRetries = 3
+
+	// DefaultHTTPRetryBackoff is the default delay before the first retry; it doubles on each retry
+	DefaultHTTPRetryBackoff = 500 * time.Millisecond
+
+	// DefaultHTTPMaxResponseSize is the default limit on the size of a channel or package file
+	DefaultHTTPMaxResponseSize = 16 << 20
+)
+
+// HTTPOptions configures an HTTPRepository
+type HTTPOptions struct {
+	// Credentials, if set, are sent with every request; see HTTPCredentialsFromSecret.
+	Credentials *HTTPCredentials
+
+	// CABundle is PEM encoded certificates that are trusted in addition to the system roots
+	CABundle []byte
+
+	// Timeout bounds each request; defaults to DefaultHTTPTimeout.
+	Timeout time.Duration
+
+	// MaxRetries is how many times a request is retried on network errors and 5xx responses;
+	// defaults to DefaultHTTPMaxRetries, a negative value disables retries.
+	MaxRetries int
+
+	// RetryBackoff is the delay before the first retry, doubling on each retry; defaults to DefaultHTTPRetryBackoff.
+	RetryBackoff time.Duration
+
+	// MaxResponseSize limits the size of each response; defaults to DefaultHTTPMaxResponseSize.
+	MaxResponseSize int64
+}
+
+// HTTPCredentials holds a bearer token or basic auth credentials
+type HTTPCredentials struct {
+	BearerToken string
+	Username    string
+	Password    string
+}
+
+// HTTPCredentialsFromSecret reads credentials from a Secret, either a bearer token in the "token" key,
+// or "username" and "password" keys (as in a kubernetes.io/basic-auth Secret).
+func HTTPCredentialsFromSecret(secret *corev1.Secret) (*HTTPCredentials, error) {
+	if token, ok := secret.Data[corev1.ServiceAccountTokenKey]; ok {
+		return &HTTPCredentials{BearerToken: strings.TrimSpace(string(token))}, nil
+	}
+	username, hasUsername := secret.Data[corev1.BasicAuthUsernameKey]
+	password, hasPassword := secret.Data[corev1.BasicAuthPasswordKey]
+	if hasUsername && hasPassword {
+		return &HTTPCredentials{Username: string(username), Password: string(password)}, nil
+	}
+	return nil, fmt.Errorf("secret %s/%s does not contain %q or %q and %q", secret.Namespace, secret.Name, corev1.ServiceAccountTokenKey, corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey)
+}
+
 // HTTPRepository supports loading from http / https
+//
+// If a channel declares the sha256 of a package version, the package is verified against it when it is downloaded.
+// The channels are loaded to find the declared sha256 if they have not been loaded already (by default the stable channel),
+// so that packages are verified even if the channel was served by another repository or from a cache.
 type HTTPRepository struct {
 	baseURL string
+	options HTTPOptions
+	client  *http.Client
+
+	// validators holds the ETag / Last-Modified of previously fetched channels, for conditional requests
+	mutex      sync.Mutex
+	validators map[string]*httpValidator
+
+	// checksums holds the sha256 of package versions, as declared in the channels we have loaded;
+	// versions declared without a sha256 are recorded with an empty value.
+	checksums map[string]string
+	// channels are the names of the channels we have loaded
+	channels map[string]bool
+}
+
+// httpValidator records the validators and body of a previous response
+type httpValidator struct {
+	etag         string
+	lastModified string
+	body         []byte
 }
 
 var _ Repository = &HTTPRepository{}
 
 // NewHTTPRepository constructs an HTTPRepository
 func NewHTTPRepository(baseURL string) *HTTPRepository {
+	// The default options do not include a CA bundle, so construction cannot fail
+	r, _ := NewHTTPRepositoryWithOptions(baseURL, HTTPOptions{})
+	return r
+}
+
+// NewHTTPRepositoryWithOptions constructs an HTTPRepository with the specified options
+func NewHTTPRepositoryWithOptions(baseURL string, options HTTPOptions) (*HTTPRepository, error) {
+	if options.Timeout == 0 {
+		options.Timeout = DefaultHTTPTimeout
+	}
+	if options.MaxRetries == 0 {
+		options.MaxRetries = DefaultHTTPMaxRetries
+	}
+	if options.RetryBackoff == 0 {
+		options.RetryBackoff = DefaultHTTPRetryBackoff
+	}
+	if options.MaxResponseSize == 0 {
+		options.MaxResponseSize = DefaultHTTPMaxResponseSize
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(options.CABundle) != 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(options.CABundle) {
+			return nil, fmt.Errorf("no certificates found in CA bundle")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
 	return &HTTPRepository{
 		baseURL: baseURL,
-	}
+		options: options,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   options.Timeout,
+		},
+	}, nil
 }
 
 func (r *HTTPRepository) LoadChannel(ctx context.Context, name string) (*Channel, error) {
@@ -50,10 +174,10 @@ func (r *HTTPRepository) LoadChannel(ctx context.Context, name string) (*Channel
 	log.WithValues("channel", name).WithValues("baseURL", r.baseURL).Info("loading channel")
 
 	p := r.makeURL(name)
-	b, err := r.readURL(ctx, p)
+	b, err := r.readURLConditional(ctx, p)
 	if err != nil {
 		log.WithValues("path", p).Error(err, "error reading channel")
-		return nil, fmt.Errorf("error reading channel %s: %v", p, err)
+		return nil, fmt.Errorf("error reading channel %s: %w", p, err)
 	}
 
 	channel := &Channel{}
@@ -61,6 +185,23 @@ func (r *HTTPRepository) LoadChannel(ctx context.Context, name string) (*Channel
 		return nil, fmt.Errorf("error parsing channel %s: %v", p, err)
 	}
 
+	r.mutex.Lock()
+	if r.channels == nil {
+		r.channels = make(map[string]bool)
+	}
+	r.channels[name] = true
+	for _, v := range channel.Manifests {
+		if r.checksums == nil {
+			r.checksums = make(map[string]string)
+		}
+		key := v.Package + "/" + v.Version
+		if existing := r.checksums[key]; existing != "" && v.SHA256 == "" {
+			continue
+		}
+		r.checksums[key] = strings.ToLower(v.SHA256)
+	}
+	r.mutex.Unlock()
+
 	return channel, nil
 }
 
@@ -76,17 +217,95 @@ func (r *HTTPRepository) LoadManifest(ctx context.Context, packageName string, i
 	log := log.FromContext(ctx)
 	log.WithValues("package", packageName).V(2).Info("loading package")
 
+	expected, err := r.declaredChecksum(ctx, packageName, id)
+	if err != nil {
+		return nil, err
+	}
+
 	p := r.makeURL("packages", packageName, id, "manifest.yaml")
 	b, err := r.readURL(ctx, p)
 	if err != nil {
-		return nil, fmt.Errorf("error reading package %s: %v", p, err)
+		return nil, fmt.Errorf("error reading package %s: %w", p, err)
 	}
+
+	if expected != "" {
+		if actual := sha256Hex(b); actual != expected {
+			return nil, fmt.Errorf("checksum mismatch for package %s: channel declares sha256 %s, got %s", p, expected, actual)
+		}
+		log.WithValues("package", packageName).V(2).Info("verified package checksum")
+	}
+
 	result := map[string]string{
 		p: string(b),
 	}
 	return result, nil
 }
 
+// declaredChecksum returns the sha256 that the channels declare for the package version, or "" if they do not declare one.
+// If the version is not in the channels we have loaded, the channels are (re)loaded; only channels that do not exist are skipped,
+// so that a package is never accepted unverified because its channel could not be read.
+func (r *HTTPRepository) declaredChecksum(ctx context.Context, packageName string, id string) (string, error) {
+	lookup := func() (string, bool) {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		for _, key := range []string{packageName + "/" + id, "/" + id} {
+			if expected, found := r.checksums[key]; found {
+				return expected, true
+			}
+		}
+		return "", false
+	}
+
+	expected, found := lookup()
+	if !found {
+		r.mutex.Lock()
+		var names []string
+		for name := range r.channels {
+			names = append(names, name)
+		}
+		r.mutex.Unlock()
+		if len(names) == 0 {
+			names = []string{"stable"}
+		}
+
+		for _, name := range names {
+			if _, err := r.LoadChannel(ctx, name); err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				return "", fmt.Errorf("error loading channel %s to verify package %s/%s: %w", name, packageName, id, err)
+			}
+		}
+		expected, _ = lookup()
+	}
+
+	if expected != "" && !isSHA256Hex(expected) {
+		return "", fmt.Errorf("channel declares invalid sha256 %q for package %s/%s", expected, packageName, id)
+	}
+	return expected, nil
+}
+
+// isSHA256Hex returns true if s is a lowercase hex sha256 digest
+func isSHA256Hex(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadRawFile reads a file relative to the repository root, for signature verification
+func (r *HTTPRepository) ReadRawFile(ctx context.Context, p string) ([]byte, error) {
+	if !allowedRawPath(p) {
+		return nil, fmt.Errorf("invalid path: %q", p)
+	}
+	return r.readURL(ctx, r.makeURL(strings.Split(p, "/")...))
+}
+
 // makeURL joins the paths to the baseURL
 func (r *HTTPRepository) makeURL(paths ...string) string {
 	u := r.baseURL
@@ -99,29 +318,151 @@ func (r *HTTPRepository) makeURL(paths ...string) string {
 	return u
 }
 
+// httpStatusError is returned for unexpected response codes; a 404 matches os.ErrNotExist
+type httpStatusError struct {
+	url        string
+	status     string
+	statusCode int
+	body       string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("unexpected response code %q fetching %q: %v", e.status, e.url, e.body)
+}
+
+func (e *httpStatusError) Is(target error) bool {
+	return target == os.ErrNotExist && e.statusCode == http.StatusNotFound
+}
+
 // readURL tries to fetch the specified url
 func (r *HTTPRepository) readURL(ctx context.Context, url string) ([]byte, error) {
+	response, body, err := r.do(ctx, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == 200 {
+		return body, nil
+	}
+
+	return nil, &httpStatusError{url: url, status: response.Status, statusCode: response.StatusCode, body: string(body)}
+}
+
+// readURLConditional fetches the specified url, revalidating any previous response with
+// If-None-Match / If-Modified-Since so that unchanged content is not transferred again.
+func (r *HTTPRepository) readURLConditional(ctx context.Context, url string) ([]byte, error) {
 	log := log.FromContext(ctx)
-	log.WithValues("url", url).Info("doing HTTP request")
-	req, err := http.NewRequest("GET", url, nil)
+
+	r.mutex.Lock()
+	previous := r.validators[url]
+	r.mutex.Unlock()
+
+	header := make(http.Header)
+	if previous != nil {
+		if previous.etag != "" {
+			header.Set("If-None-Match", previous.etag)
+		}
+		if previous.lastModified != "" {
+			header.Set("If-Modified-Since", previous.lastModified)
+		}
+	}
+
+	response, body, err := r.do(ctx, url, header)
 	if err != nil {
 		return nil, err
 	}
-	response, err := http.DefaultClient.Do(req)
+	if response.StatusCode == http.StatusNotModified && previous != nil {
+		log.WithValues("url", url).V(2).Info("not modified")
+		return previous.body, nil
+	}
+	if response.StatusCode != 200 {
+		return nil, &httpStatusError{url: url, status: response.Status, statusCode: response.StatusCode, body: string(body)}
+	}
+
+	validator := &httpValidator{
+		etag:         response.Header.Get("ETag"),
+		lastModified: response.Header.Get("Last-Modified"),
+		body:         body,
+	}
+	if validator.etag != "" || validator.lastModified != "" {
+		r.mutex.Lock()
+		if r.validators == nil {
+			r.validators = make(map[string]*httpValidator)
+		}
+		r.validators[url] = validator
+		r.mutex.Unlock()
+	}
+	return body, nil
+}
+
+// do performs a GET request with the configured credentials, retrying with backoff on network errors and 5xx responses.
+// The body of the final response is returned, up to MaxResponseSize.
+func (r *HTTPRepository) do(ctx context.Context, url string, header http.Header) (*http.Response, []byte, error) {
+	log := log.FromContext(ctx)
+
+	backoff := r.options.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if c := r.options.Credentials; c != nil {
+			if c.BearerToken != "" {
+				req.Header.Set("Authorization", "Bearer "+c.BearerToken)
+			} else {
+				req.SetBasicAuth(c.Username, c.Password)
+			}
+		}
+
+		log.WithValues("url", url).WithValues("attempt", attempt).Info("doing HTTP request")
+		response, body, err := r.doOnce(req)
+		retryable := (err != nil && !errors.Is(err, errResponseTooLarge)) || (err == nil && response.StatusCode >= 500)
+		if !retryable || attempt >= r.options.MaxRetries || ctx.Err() != nil {
+			if err != nil {
+				return nil, nil, err
+			}
+			return response, body, nil
+		}
+		if err != nil {
+			log.WithValues("url", url).Info("retrying HTTP request", "error", err.Error())
+		} else {
+			log.WithValues("url", url).Info("retrying HTTP request", "status", response.Status)
+		}
+	}
+}
+
+// errResponseTooLarge is returned when a response exceeds MaxResponseSize; such requests are not retried
+var errResponseTooLarge = errors.New("response too large")
+
+// doOnce performs a single request, reading the body up to MaxResponseSize
+func (r *HTTPRepository) doOnce(req *http.Request) (*http.Response, []byte, error) {
+	url := req.URL.String()
+	response, err := r.client.Do(req)
 	if response != nil {
 		defer response.Body.Close()
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error fetching %q: %v", url, err)
+		return nil, nil, fmt.Errorf("error fetching %q: %v", url, err)
 	}
-	body, err := io.ReadAll(response.Body)
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, r.options.MaxResponseSize+1))
 	if err != nil {
-		return nil, fmt.Errorf("error reading response for %q: %v", url, err)
+		return nil, nil, fmt.Errorf("error reading response for %q: %v", url, err)
 	}
-
-	if response.StatusCode == 200 {
-		return body, nil
+	if int64(len(body)) > r.options.MaxResponseSize {
+		return nil, nil, fmt.Errorf("response for %q exceeds maximum size of %d bytes: %w", url, r.options.MaxResponseSize, errResponseTooLarge)
 	}
-
-	return nil, fmt.Errorf("unexpected response code %q fetching %q: %v", response.Status, url, string(body))
+	return response, body, nil
 }
@@ -0,0 +1,274 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const testPackage = "kind: ConfigMap\napiVersion: v1\nmetadata:\n  name: nginx\n"
+
+// flakyHTTPServer serves a channel and a package, failing the first failures requests with a 503
+type flakyHTTPServer struct {
+	mutex    sync.Mutex
+	failures int
+	requests int
+	channel  string
+	auth     string
+}
+
+func (s *flakyHTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests++
+	if s.auth != "" && r.Header.Get("Authorization") != s.auth {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if s.failures > 0 {
+		s.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/stable":
+		w.Write([]byte(s.channel))
+	case "/packages/nginx/0.1.0/manifest.yaml":
+		w.Write([]byte(testPackage))
+	case "/large":
+		w.Write([]byte(strings.Repeat("x", 1024)))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestHTTPRepositoryRetries(t *testing.T) {
+	ctx := context.Background()
+
+	server := &flakyHTTPServer{failures: 2, channel: "manifests:\n- name: nginx\n  version: 0.1.0\n"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	repo, err := NewHTTPRepositoryWithOptions(ts.URL, HTTPOptions{RetryBackoff: time.Millisecond, MaxResponseSize: 512})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := repo.LoadChannel(ctx, "stable"); err != nil {
+		t.Fatalf("unexpected error loading channel: %v", err)
+	}
+	if server.requests != 3 {
+		t.Errorf("expected 3 requests, got %d", server.requests)
+	}
+
+	// Client errors are not retried
+	server.requests = 0
+	if _, err := repo.LoadChannel(ctx, "beta"); err == nil {
+		t.Errorf("expected error loading missing channel")
+	}
+	if server.requests != 1 {
+		t.Errorf("expected 1 request, got %d", server.requests)
+	}
+
+	// Retries are bounded
+	server.requests = 0
+	server.failures = 10
+	if _, err := repo.LoadChannel(ctx, "stable"); err == nil {
+		t.Errorf("expected error when server keeps failing")
+	}
+	if server.requests != DefaultHTTPMaxRetries+1 {
+		t.Errorf("expected %d requests, got %d", DefaultHTTPMaxRetries+1, server.requests)
+	}
+	server.failures = 0
+
+	// Responses are limited in size
+	if _, err := repo.ReadRawFile(ctx, "large"); err == nil || !strings.Contains(err.Error(), "maximum size") {
+		t.Errorf("expected size limit error, got %v", err)
+	}
+}
+
+func TestHTTPRepositoryAuthAndTLS(t *testing.T) {
+	ctx := context.Background()
+
+	server := &flakyHTTPServer{auth: "Bearer s3cr3t", channel: "manifests:\n- name: nginx\n  version: 0.1.0\n"}
+	ts := httptest.NewTLSServer(server)
+	defer ts.Close()
+
+	credentials, err := HTTPCredentialsFromSecret(&corev1.Secret{
+		Data: map[string][]byte{corev1.ServiceAccountTokenKey: []byte("s3cr3t\n")},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error reading secret: %v", err)
+	}
+
+	// The server certificate is not trusted without the CA bundle
+	untrusted, err := NewHTTPRepositoryWithOptions(ts.URL, HTTPOptions{Credentials: credentials, MaxRetries: -1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := untrusted.LoadChannel(ctx, "stable"); err == nil {
+		t.Errorf("expected TLS error without CA bundle")
+	}
+
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	repo, err := NewHTTPRepositoryWithOptions(ts.URL, HTTPOptions{Credentials: credentials, CABundle: caBundle})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := repo.LoadChannel(ctx, "stable"); err != nil {
+		t.Fatalf("unexpected error loading channel: %v", err)
+	}
+
+	basic, err := HTTPCredentialsFromSecret(&corev1.Secret{
+		Data: map[string][]byte{corev1.BasicAuthUsernameKey: []byte("user"), corev1.BasicAuthPasswordKey: []byte("pass")},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error reading secret: %v", err)
+	}
+	wrongAuth, err := NewHTTPRepositoryWithOptions(ts.URL, HTTPOptions{Credentials: basic, CABundle: caBundle})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := wrongAuth.LoadChannel(ctx, "stable"); err == nil {
+		t.Errorf("expected error with wrong credentials")
+	}
+
+	if _, err := HTTPCredentialsFromSecret(&corev1.Secret{}); err == nil {
+		t.Errorf("expected error for secret without credentials")
+	}
+	if _, err := NewHTTPRepositoryWithOptions(ts.URL, HTTPOptions{CABundle: []byte("not a certificate")}); err == nil {
+		t.Errorf("expected error for invalid CA bundle")
+	}
+}
+
+func TestHTTPRepositoryChecksums(t *testing.T) {
+	ctx := context.Background()
+
+	grid := []struct {
+		name    string
+		sha256  string
+		wantErr bool
+	}{
+		{name: "no checksum"},
+		{name: "matching checksum", sha256: sha256Hex([]byte(testPackage))},
+		{name: "matching uppercase checksum", sha256: strings.ToUpper(sha256Hex([]byte(testPackage)))},
+		{name: "mismatched checksum", sha256: sha256Hex([]byte("tampered")), wantErr: true},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			channel := "manifests:\n- name: nginx\n  version: 0.1.0\n"
+			if g.sha256 != "" {
+				channel += "  sha256: " + g.sha256 + "\n"
+			}
+			ts := httptest.NewServer(&flakyHTTPServer{channel: channel})
+			defer ts.Close()
+
+			repo := NewHTTPRepository(ts.URL)
+			if _, err := repo.LoadChannel(ctx, "stable"); err != nil {
+				t.Fatalf("unexpected error loading channel: %v", err)
+			}
+			_, err := repo.LoadManifest(ctx, "nginx", "0.1.0")
+			if g.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+					t.Errorf("expected checksum mismatch, got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error loading manifest: %v", err)
+			}
+		})
+	}
+}
+
+func TestHTTPRepositoryChecksumsWithoutChannel(t *testing.T) {
+	ctx := context.Background()
+
+	grid := []struct {
+		name    string
+		server  *flakyHTTPServer
+		noRetry bool
+		wantErr string
+	}{
+		{
+			name:    "tampered package",
+			server:  &flakyHTTPServer{channel: "manifests:\n- name: nginx\n  version: 0.1.0\n  sha256: " + sha256Hex([]byte("tampered")) + "\n"},
+			wantErr: "checksum mismatch",
+		},
+		{
+			name:   "matching package",
+			server: &flakyHTTPServer{channel: "manifests:\n- name: nginx\n  version: 0.1.0\n  sha256: " + sha256Hex([]byte(testPackage)) + "\n"},
+		},
+		{
+			name:    "invalid checksum",
+			server:  &flakyHTTPServer{channel: "manifests:\n- name: nginx\n  version: 0.1.0\n  sha256: abc\n"},
+			wantErr: "invalid sha256",
+		},
+		{
+			name:    "channel unavailable",
+			server:  &flakyHTTPServer{channel: "manifests: []\n", failures: 1},
+			noRetry: true,
+			wantErr: "to verify package",
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			ts := httptest.NewServer(g.server)
+			defer ts.Close()
+
+			options := HTTPOptions{}
+			if g.noRetry {
+				options.MaxRetries = -1
+			}
+			repo, err := NewHTTPRepositoryWithOptions(ts.URL, options)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, err = repo.LoadManifest(ctx, "nginx", "0.1.0")
+			if g.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), g.wantErr) {
+					t.Errorf("expected error containing %q, got %v", g.wantErr, err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error loading manifest: %v", err)
+			}
+		})
+	}
+
+	// A repository without channels serves packages without verification
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/packages/nginx/0.1.0/manifest.yaml" {
+			w.Write([]byte(testPackage))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+	if _, err := NewHTTPRepository(ts.URL).LoadManifest(ctx, "nginx", "0.1.0"); err != nil {
+		t.Errorf("unexpected error loading manifest without channel: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+)
+
+// LayeredRepository combines Repositories, in order of precedence.
+// Each channel and package is loaded from the first layer that provides it, so that
+// for example a remote channel overrides the defaults in an EmbeddedRepository,
+// and the embedded defaults are used for channels and packages the remote repository does not publish.
+//
+// A lower layer is only used when a layer reports that the channel or package does not exist;
+// any other error (eg a server error, timeout, or failed signature or checksum verification) is returned,
+// rather than silently falling back. A warning is recorded when a lower layer serves the content.
+type LayeredRepository struct {
+	layers []Repository
+}
+
+var _ Repository = &LayeredRepository{}
+var _ RawFileReader = &LayeredRepository{}
+var _ RevisionReader = &LayeredRepository{}
+
+// NewLayeredRepository is the constructor for a LayeredRepository; earlier layers take precedence.
+func NewLayeredRepository(layers ...Repository) (*LayeredRepository, error) {
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("at least one repository is required")
+	}
+	return &LayeredRepository{layers: layers}, nil
+}
+
+// Revision returns the revision of the first layer that reports one, or "" if none do
+func (r *LayeredRepository) Revision(ctx context.Context) (string, error) {
+	for _, layer := range r.layers {
+		reader, ok := layer.(RevisionReader)
+		if !ok {
+			continue
+		}
+		revision, err := reader.Revision(ctx)
+		if err != nil {
+			return "", err
+		}
+		if revision != "" {
+			return revision, nil
+		}
+	}
+	return "", nil
+}
+
+func (r *LayeredRepository) LoadChannel(ctx context.Context, name string) (*Channel, error) {
+	log := log.FromContext(ctx)
+
+	var errs []error
+	for i, layer := range r.layers {
+		channel, err := layer.LoadChannel(ctx, name)
+		if err == nil {
+			if i > 0 {
+				declarative.RecordWarning(ctx, "channel %s was loaded from repository layer %d, as it was not found in the earlier layers", name, i)
+			}
+			return channel, nil
+		}
+		if !isNotFound(err) {
+			return nil, err
+		}
+		log.V(2).Info("channel not found in layer", "channel", name, "layer", i, "error", err.Error())
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (r *LayeredRepository) LoadManifest(ctx context.Context, packageName string, id string) (map[string]string, error) {
+	log := log.FromContext(ctx)
+
+	var errs []error
+	for i, layer := range r.layers {
+		manifests, err := layer.LoadManifest(ctx, packageName, id)
+		if err == nil {
+			if i > 0 {
+				declarative.RecordWarning(ctx, "package %s/%s was loaded from repository layer %d, as it was not found in the earlier layers", packageName, id, i)
+			}
+			return manifests, nil
+		}
+		if !isNotFound(err) {
+			return nil, err
+		}
+		log.V(2).Info("package not found in layer", "package", packageName, "id", id, "layer", i, "error", err.Error())
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// ReadRawFile reads the file from the first layer that provides it, for signature verification.
+// Layers that do not implement RawFileReader are skipped.
+func (r *LayeredRepository) ReadRawFile(ctx context.Context, p string) ([]byte, error) {
+	var errs []error
+	for _, layer := range r.layers {
+		reader, ok := layer.(RawFileReader)
+		if !ok {
+			continue
+		}
+		b, err := reader.ReadRawFile(ctx, p)
+		if err == nil {
+			return b, nil
+		}
+		if !isNotFound(err) {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no repository layer supports reading %q", p)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// isNotFound returns true if err reports that a channel, package or file does not exist in a repository,
+// including an OCI artifact that is not in the registry.
+func isNotFound(err error) bool {
+	if errors.Is(err, os.ErrNotExist) {
+		return true
+	}
+	var transportErr *transport.Error
+	return errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound
+}
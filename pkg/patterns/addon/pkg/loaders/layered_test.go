@@ -0,0 +1,138 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// warningLogger returns a context that captures the warnings recorded with declarative.RecordWarning, which are logged
+func warningLogger(ctx context.Context) (context.Context, func() []string) {
+	var mutex sync.Mutex
+	var warnings []string
+	logger := funcr.New(func(prefix, args string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if strings.Contains(args, `"msg"="warning: `) {
+			warnings = append(warnings, args)
+		}
+	}, funcr.Options{})
+	return log.IntoContext(ctx, logger), func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), warnings...)
+	}
+}
+
+func TestLayeredRepository(t *testing.T) {
+	ctx := context.Background()
+
+	// The remote channel moves ahead of the embedded one, but only publishes the new package
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stable", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("manifests:\n- name: nginx\n  version: 0.2.0\n"))
+	})
+	mux.HandleFunc("/packages/nginx/0.2.0/manifest.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("kind: Remote\n"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	remote, err := NewHTTPRepositoryWithOptions(server.URL, HTTPOptions{MaxRetries: -1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	l, err := NewManifestLoaderForRepository(remote, WithFallbackRepository(NewEmbeddedRepository(testEmbeddedFS)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := l.repo.(*LayeredRepository); !ok {
+		t.Fatalf("expected LayeredRepository, got %T", l.repo)
+	}
+
+	channel, err := l.repo.LoadChannel(ctx, "stable")
+	if err != nil {
+		t.Fatalf("unexpected error loading channel: %v", err)
+	}
+	if channel.Manifests[0].Version != "0.2.0" {
+		t.Errorf("expected remote channel to take precedence, got %+v", channel)
+	}
+
+	manifests, err := l.repo.LoadManifest(ctx, "nginx", "0.2.0")
+	if err != nil {
+		t.Fatalf("unexpected error loading manifest: %v", err)
+	}
+	if len(manifests) != 1 || manifests[server.URL+"/packages/nginx/0.2.0/manifest.yaml"] != "kind: Remote\n" {
+		t.Errorf("expected remote package, got %v", manifests)
+	}
+
+	// Packages the remote repository does not publish are loaded from the embedded defaults, with a warning
+	warnCtx, warnings := warningLogger(ctx)
+	manifests, err = l.repo.LoadManifest(warnCtx, "other", "1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error loading manifest: %v", err)
+	}
+	if manifests["packages/other/1.0.0/manifest.yaml"] != "kind: Deployment\n" {
+		t.Errorf("expected embedded package, got %v", manifests)
+	}
+	if got := warnings(); len(got) != 1 || !strings.Contains(got[0], "package other/1.0.0 was loaded from repository layer 1") {
+		t.Errorf("expected warning for package loaded from fallback, got %v", got)
+	}
+
+	if _, err := l.repo.LoadManifest(ctx, "nginx", "9.9.9"); err == nil {
+		t.Errorf("expected error when no layer provides the package")
+	}
+
+	// The embedded defaults do not mask errors from the remote repository
+	server.Close()
+	if _, err := l.repo.LoadChannel(ctx, "stable"); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("expected connection error when the remote repository is unavailable, got %v", err)
+	}
+}
+
+func TestLayeredRepositoryServerError(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	remote, err := NewHTTPRepositoryWithOptions(server.URL, HTTPOptions{MaxRetries: -1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo, err := NewLayeredRepository(remote, NewEmbeddedRepository(testEmbeddedFS))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := repo.LoadChannel(ctx, "stable"); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("expected server error loading channel, got %v", err)
+	}
+	if _, err := repo.LoadManifest(ctx, "other", "1.0.0"); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("expected server error loading package, got %v", err)
+	}
+}
@@ -0,0 +1,410 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// OCIPrefix selects an OCIRepository in NewManifestLoader, eg oci://ghcr.io/example/addons:v1
+	OCIPrefix = "oci://"
+
+	// OCITitleAnnotation is the layer annotation naming the file a layer holds, as set by `oras push`
+	OCITitleAnnotation = "org.opencontainers.image.title"
+
+	// maxOCIArtifactSize bounds the total size of the files in an artifact
+	maxOCIArtifactSize = 64 << 20
+)
+
+// OCIOptions configures an OCIRepository
+type OCIOptions struct {
+	// Keychain provides registry credentials; defaults to authn.DefaultKeychain (~/.docker/config.json).
+	// Use NewKeychainFromSecret to authenticate with a docker config Secret.
+	Keychain authn.Keychain
+
+	// Insecure allows connecting to the registry over plain http
+	Insecure bool
+}
+
+// OCIRepository is a Repository backed by an OCI artifact in a container registry.
+//
+// The artifact holds the repository tree (channel files and packages/<name>/<version> directories),
+// either as one layer per file annotated with OCITitleAnnotation (as pushed by `oras push`),
+// or as tar layers (optionally gzipped) that are extracted at the root.
+//
+// The artifact is referenced by tag or digest.  A tag is resolved every time a channel is loaded,
+// and the artifact is only downloaded again if the digest has changed.
+type OCIRepository struct {
+	ref     name.Reference
+	options OCIOptions
+
+	mutex  sync.Mutex
+	digest v1.Hash
+	files  map[string][]byte
+}
+
+var _ Repository = &OCIRepository{}
+var _ RevisionReader = &OCIRepository{}
+
+// NewOCIRepository constructs an OCIRepository for a reference such as oci://ghcr.io/example/addons:v1
+func NewOCIRepository(ref string) (*OCIRepository, error) {
+	return NewOCIRepositoryWithOptions(ref, OCIOptions{})
+}
+
+// NewOCIRepositoryWithOptions constructs an OCIRepository with the specified options
+func NewOCIRepositoryWithOptions(ref string, options OCIOptions) (*OCIRepository, error) {
+	var nameOptions []name.Option
+	if options.Insecure {
+		nameOptions = append(nameOptions, name.Insecure)
+	}
+	parsed, err := name.ParseReference(strings.TrimPrefix(ref, OCIPrefix), nameOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing OCI reference %q: %w", ref, err)
+	}
+	if options.Keychain == nil {
+		options.Keychain = authn.DefaultKeychain
+	}
+
+	return &OCIRepository{
+		ref:     parsed,
+		options: options,
+	}, nil
+}
+
+func (r *OCIRepository) LoadChannel(ctx context.Context, name string) (*Channel, error) {
+	if !allowedChannelName(name) {
+		return nil, fmt.Errorf("invalid channel name: %q", name)
+	}
+
+	log := log.FromContext(ctx)
+	log.WithValues("channel", name).WithValues("ref", r.ref.String()).Info("loading channel")
+
+	// Channels are the entry point for version resolution, so we check for a new artifact here
+	files, err := r.load(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	b, ok := files[name]
+	if !ok {
+		return nil, fmt.Errorf("channel %q not found in %s: %w", name, r.ref, os.ErrNotExist)
+	}
+
+	channel := &Channel{}
+	if err := yaml.Unmarshal(b, channel); err != nil {
+		return nil, fmt.Errorf("error parsing channel %s: %v", name, err)
+	}
+
+	return channel, nil
+}
+
+func (r *OCIRepository) LoadManifest(ctx context.Context, packageName string, id string) (map[string]string, error) {
+	if !allowedManifestId(packageName) {
+		return nil, fmt.Errorf("invalid package name: %q", id)
+	}
+
+	if !allowedManifestId(id) {
+		return nil, fmt.Errorf("invalid manifest id: %q", id)
+	}
+
+	log := log.FromContext(ctx)
+	log.WithValues("package", packageName).V(2).Info("loading package")
+
+	files, err := r.load(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	dirPath := path.Join("packages", packageName, id)
+	result := make(map[string]string)
+	for p, b := range files {
+		// Only files directly in the package directory, as for FSRepository
+		if path.Dir(p) != dirPath {
+			continue
+		}
+		result[p] = string(b)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("package %s not found in %s: %w", dirPath, r.ref, os.ErrNotExist)
+	}
+
+	return result, nil
+}
+
+// ReadRawFile reads a file relative to the root of the artifact, for signature verification
+func (r *OCIRepository) ReadRawFile(ctx context.Context, p string) ([]byte, error) {
+	if !allowedRawPath(p) {
+		return nil, fmt.Errorf("invalid path: %q", p)
+	}
+	files, err := r.load(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := files[p]
+	if !ok {
+		return nil, fmt.Errorf("file %q not found in %s: %w", p, r.ref, os.ErrNotExist)
+	}
+	return b, nil
+}
+
+// Revision returns the digest of the artifact that manifests are currently loaded from
+func (r *OCIRepository) Revision(ctx context.Context) (string, error) {
+	if _, err := r.load(ctx, false); err != nil {
+		return "", err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.digest.String(), nil
+}
+
+// load returns the files in the artifact, downloading it if it has not been loaded
+// or if refresh is set and the tag now points to a different digest.
+func (r *OCIRepository) load(ctx context.Context, refresh bool) (map[string][]byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, isDigest := r.ref.(name.Digest)
+	if r.files != nil && (!refresh || isDigest) {
+		return r.files, nil
+	}
+
+	remoteOptions := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(r.options.Keychain),
+	}
+
+	if r.files != nil {
+		desc, err := remote.Head(r.ref, remoteOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving %s: %w", r.ref, err)
+		}
+		if desc.Digest == r.digest {
+			return r.files, nil
+		}
+	}
+
+	log := log.FromContext(ctx)
+	log.WithValues("ref", r.ref.String()).Info("pulling OCI artifact")
+
+	img, err := remote.Image(r.ref, remoteOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("error pulling %s: %w", r.ref, err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("error getting digest of %s: %w", r.ref, err)
+	}
+	files, err := readOCIArtifactFiles(img)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", r.ref, err)
+	}
+
+	log.WithValues("ref", r.ref.String()).WithValues("digest", digest.String()).Info("pulled OCI artifact")
+	r.digest = digest
+	r.files = files
+	return files, nil
+}
+
+// readOCIArtifactFiles returns the files in the layers of img, keyed by slash-separated path
+func readOCIArtifactFiles(img v1.Image) (map[string][]byte, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+
+	files := make(map[string][]byte)
+	var total int64
+	add := func(p string, r io.Reader) error {
+		p = strings.TrimPrefix(path.Clean(p), "./")
+		if !allowedRawPath(p) {
+			return fmt.Errorf("invalid file path %q", p)
+		}
+		b, err := io.ReadAll(io.LimitReader(r, maxOCIArtifactSize-total+1))
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", p, err)
+		}
+		total += int64(len(b))
+		if total > maxOCIArtifactSize {
+			return fmt.Errorf("artifact exceeds maximum size of %d bytes", maxOCIArtifactSize)
+		}
+		files[p] = b
+		return nil
+	}
+
+	for _, desc := range manifest.Layers {
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("error getting layer %s: %w", desc.Digest, err)
+		}
+		rc, err := layer.Compressed()
+		if err != nil {
+			return nil, fmt.Errorf("error reading layer %s: %w", desc.Digest, err)
+		}
+
+		switch desc.MediaType {
+		case types.OCILayer, types.DockerLayer:
+			err = readTarLayer(rc, true, add)
+		case types.OCIUncompressedLayer, types.DockerUncompressedLayer:
+			err = readTarLayer(rc, false, add)
+		default:
+			title := desc.Annotations[OCITitleAnnotation]
+			if title == "" {
+				err = fmt.Errorf("layer %s has no %s annotation", desc.Digest, OCITitleAnnotation)
+			} else {
+				err = add(title, rc)
+			}
+		}
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// readTarLayer calls add for each regular file in the tar stream r
+func readTarLayer(r io.Reader, compressed bool, add func(p string, r io.Reader) error) error {
+	if compressed {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("error decompressing layer: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading tar layer: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := add(header.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// NewKeychainFromSecret returns a Keychain with the credentials in a docker config Secret,
+// of type kubernetes.io/dockerconfigjson or kubernetes.io/dockercfg.
+func NewKeychainFromSecret(secret *corev1.Secret) (authn.Keychain, error) {
+	var auths map[string]dockerConfigEntry
+	if b, ok := secret.Data[corev1.DockerConfigJsonKey]; ok {
+		var config struct {
+			Auths map[string]dockerConfigEntry `json:"auths"`
+		}
+		if err := json.Unmarshal(b, &config); err != nil {
+			return nil, fmt.Errorf("error parsing %s in secret %s/%s: %w", corev1.DockerConfigJsonKey, secret.Namespace, secret.Name, err)
+		}
+		auths = config.Auths
+	} else if b, ok := secret.Data[corev1.DockerConfigKey]; ok {
+		if err := json.Unmarshal(b, &auths); err != nil {
+			return nil, fmt.Errorf("error parsing %s in secret %s/%s: %w", corev1.DockerConfigKey, secret.Namespace, secret.Name, err)
+		}
+	} else {
+		return nil, fmt.Errorf("secret %s/%s does not contain %s or %s", secret.Namespace, secret.Name, corev1.DockerConfigJsonKey, corev1.DockerConfigKey)
+	}
+
+	keychain := dockerConfigKeychain{}
+	for server, entry := range auths {
+		config := authn.AuthConfig{
+			Username:      entry.Username,
+			Password:      entry.Password,
+			IdentityToken: entry.IdentityToken,
+			RegistryToken: entry.RegistryToken,
+		}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding auth for %s in secret %s/%s: %w", server, secret.Namespace, secret.Name, err)
+			}
+			username, password, ok := strings.Cut(string(decoded), ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid auth for %s in secret %s/%s", server, secret.Namespace, secret.Name)
+			}
+			config.Username = username
+			config.Password = password
+		}
+		keychain[normalizeRegistryHost(server)] = config
+	}
+	return keychain, nil
+}
+
+type dockerConfigEntry struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	Auth          string `json:"auth,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+	RegistryToken string `json:"registrytoken,omitempty"`
+}
+
+// dockerConfigKeychain maps registry hosts to credentials
+type dockerConfigKeychain map[string]authn.AuthConfig
+
+func (k dockerConfigKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	config, ok := k[normalizeRegistryHost(target.RegistryStr())]
+	if !ok {
+		return authn.Anonymous, nil
+	}
+	return authn.FromConfig(config), nil
+}
+
+// normalizeRegistryHost strips the scheme and path from a docker config key,
+// and maps the Docker Hub aliases to index.docker.io
+func normalizeRegistryHost(server string) string {
+	host := server
+	if i := strings.Index(host, "://"); i != -1 {
+		host = host[i+len("://"):]
+	}
+	if i := strings.Index(host, "/"); i != -1 {
+		host = host[:i]
+	}
+	switch host {
+	case "docker.io", "registry-1.docker.io":
+		host = name.DefaultRegistry
+	}
+	return host
+}
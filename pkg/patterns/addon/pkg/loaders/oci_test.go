@@ -0,0 +1,232 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// pushArtifact pushes files to ref, the channel as a file layer and the packages as a tar layer
+func pushArtifact(t *testing.T, ref string, files map[string]string, keychain authn.Keychain) v1.Hash {
+	t.Helper()
+
+	var tarball bytes.Buffer
+	tw := tar.NewWriter(&tarball)
+	var adds []mutate.Addendum
+	for p, contents := range files {
+		if !strings.HasPrefix(p, "packages/") {
+			adds = append(adds, mutate.Addendum{
+				Layer:       static.NewLayer([]byte(contents), "application/vnd.example.addon.channel"),
+				Annotations: map[string]string{OCITitleAnnotation: p},
+			})
+			continue
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: "./" + p, Mode: 0644, Size: int64(len(contents)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("error writing tar: %v", err)
+		}
+		if _, err := tw.Write([]byte(contents)); err != nil {
+			t.Fatalf("error writing tar: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("error writing tar: %v", err)
+	}
+	adds = append(adds, mutate.Addendum{Layer: static.NewLayer(tarball.Bytes(), types.OCIUncompressedLayer)})
+
+	img, err := mutate.Append(empty.Image, adds...)
+	if err != nil {
+		t.Fatalf("error building artifact: %v", err)
+	}
+	parsed, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatalf("error parsing reference: %v", err)
+	}
+	if err := remote.Write(parsed, img, remote.WithAuthFromKeychain(keychain)); err != nil {
+		t.Fatalf("error pushing artifact: %v", err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatalf("error getting digest: %v", err)
+	}
+	return digest
+}
+
+// withBasicAuth requires the username and password for all requests to handler
+func withBasicAuth(handler http.Handler, username, password string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		if !ok || u != username || p != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func TestOCIRepository(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(withBasicAuth(registry.New(), "user", "secret"))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "registry"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(fmt.Sprintf(`{"auths":{"http://%s":{"auth":%q}}}`, host, base64.StdEncoding.EncodeToString([]byte("user:secret")))),
+		},
+	}
+	keychain, err := NewKeychainFromSecret(secret)
+	if err != nil {
+		t.Fatalf("unexpected error building keychain: %v", err)
+	}
+
+	v1Digest := pushArtifact(t, host+"/addons:stable", map[string]string{
+		"stable":                             "manifests:\n- name: nginx\n  version: 0.1.0\n",
+		"packages/nginx/0.1.0/manifest.yaml": "kind: ConfigMap\n",
+		"packages/nginx/0.1.0/service.yaml":  "kind: Service\n",
+	}, keychain)
+
+	// Anonymous access is refused
+	anonymous, err := NewOCIRepository(OCIPrefix + host + "/addons:stable")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	anonymous.options.Keychain = authn.NewMultiKeychain()
+	if _, err := anonymous.LoadChannel(ctx, "stable"); err == nil {
+		t.Errorf("expected error loading without credentials")
+	}
+
+	repo, err := NewOCIRepositoryWithOptions(OCIPrefix+host+"/addons:stable", OCIOptions{Keychain: keychain})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	channel, err := repo.LoadChannel(ctx, "stable")
+	if err != nil {
+		t.Fatalf("unexpected error loading channel: %v", err)
+	}
+	if len(channel.Manifests) != 1 || channel.Manifests[0].Version != "0.1.0" {
+		t.Errorf("unexpected channel %+v", channel)
+	}
+
+	manifests, err := repo.LoadManifest(ctx, "nginx", "0.1.0")
+	if err != nil {
+		t.Fatalf("unexpected error loading manifest: %v", err)
+	}
+	if len(manifests) != 2 || manifests["packages/nginx/0.1.0/service.yaml"] != "kind: Service\n" {
+		t.Errorf("unexpected manifests %v", manifests)
+	}
+
+	if _, err := repo.LoadManifest(ctx, "nginx", "9.9.9"); err == nil {
+		t.Errorf("expected error loading missing package")
+	}
+
+	// Moving the tag is picked up when the channel is next loaded
+	v2Digest := pushArtifact(t, host+"/addons:stable", map[string]string{
+		"stable":                             "manifests:\n- name: nginx\n  version: 0.2.0\n",
+		"packages/nginx/0.2.0/manifest.yaml": "kind: ConfigMap\n",
+	}, keychain)
+
+	channel, err = repo.LoadChannel(ctx, "stable")
+	if err != nil {
+		t.Fatalf("unexpected error loading channel: %v", err)
+	}
+	if channel.Manifests[0].Version != "0.2.0" {
+		t.Errorf("expected updated channel, got %+v", channel)
+	}
+	if revision, err := repo.Revision(ctx); err != nil || revision != v2Digest.String() {
+		t.Errorf("expected revision %s, got %s (%v)", v2Digest, revision, err)
+	}
+
+	// Digest references are pinned
+	pinned, err := NewOCIRepositoryWithOptions(OCIPrefix+host+"/addons@"+v1Digest.String(), OCIOptions{Keychain: keychain})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	channel, err = pinned.LoadChannel(ctx, "stable")
+	if err != nil {
+		t.Fatalf("unexpected error loading channel: %v", err)
+	}
+	if channel.Manifests[0].Version != "0.1.0" {
+		t.Errorf("expected pinned channel, got %+v", channel)
+	}
+}
+
+func TestNewKeychainFromSecret(t *testing.T) {
+	secret := &corev1.Secret{
+		Data: map[string][]byte{
+			corev1.DockerConfigKey: []byte(`{"https://index.docker.io/v1/":{"username":"hub","password":"hubpass"},"ghcr.io":{"auth":"` + base64.StdEncoding.EncodeToString([]byte("gh:ghpass")) + `"}}`),
+		},
+	}
+	keychain, err := NewKeychainFromSecret(secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	grid := []struct {
+		ref      string
+		username string
+	}{
+		{ref: "nginx", username: "hub"},
+		{ref: "ghcr.io/example/addons:v1", username: "gh"},
+		{ref: "quay.io/example/addons:v1", username: ""},
+	}
+	for _, g := range grid {
+		ref, err := name.ParseReference(g.ref)
+		if err != nil {
+			t.Fatalf("error parsing reference: %v", err)
+		}
+		auth, err := keychain.Resolve(ref.Context())
+		if err != nil {
+			t.Fatalf("unexpected error resolving %s: %v", g.ref, err)
+		}
+		config, err := auth.Authorization()
+		if err != nil {
+			t.Fatalf("unexpected error getting authorization: %v", err)
+		}
+		if config.Username != g.username {
+			t.Errorf("expected username %q for %s, got %q", g.username, g.ref, config.Username)
+		}
+	}
+
+	if _, err := NewKeychainFromSecret(&corev1.Secret{}); err == nil {
+		t.Errorf("expected error for secret without docker config")
+	}
+}
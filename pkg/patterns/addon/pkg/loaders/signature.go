@@ -0,0 +1,251 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DigestFileName is the name of the digest file in each package directory.
+	// It lists the SHA-256 of every file in the package, in the format of sha256sum:
+	// "<hex digest>  <file name>" on each line.
+	DigestFileName = "SHA256SUMS"
+
+	// SignatureSuffix is appended to the name of a signed file to find its detached signature,
+	// eg "stable.sig" or "packages/foo/1.0.0/SHA256SUMS.sig".
+	// Signatures can be raw bytes or base64 encoded, as produced by `cosign sign-blob`.
+	SignatureSuffix = ".sig"
+)
+
+// RawFileReader is implemented by Repositories that can read files relative to the repository root,
+// for example "stable" or "packages/foo/1.0.0/SHA256SUMS".
+type RawFileReader interface {
+	ReadRawFile(ctx context.Context, path string) ([]byte, error)
+}
+
+// SignedRepository wraps a Repository, verifying detached signatures on channels and packages.
+// Channels are signed directly; packages are verified by a signed digest file in the package directory.
+// Unsigned or tampered channels and packages are refused.
+type SignedRepository struct {
+	repo interface {
+		Repository
+		RawFileReader
+	}
+	keys []crypto.PublicKey
+}
+
+var _ Repository = &SignedRepository{}
+
+// NewSignedRepository is the constructor for a SignedRepository.
+// Content is accepted if it is signed by any of the keys, which must be ed25519 or ECDSA (cosign) public keys.
+func NewSignedRepository(repo Repository, keys ...crypto.PublicKey) (*SignedRepository, error) {
+	reader, ok := repo.(interface {
+		Repository
+		RawFileReader
+	})
+	if !ok {
+		return nil, fmt.Errorf("repository %T does not support signature verification", repo)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one public key is required for signature verification")
+	}
+	for _, key := range keys {
+		switch key.(type) {
+		case ed25519.PublicKey, *ecdsa.PublicKey:
+		default:
+			return nil, fmt.Errorf("unsupported public key type %T", key)
+		}
+	}
+	return &SignedRepository{repo: reader, keys: keys}, nil
+}
+
+// ParsePublicKey parses a PEM encoded (PKIX) ed25519 or ECDSA public key, such as cosign.pub
+func ParsePublicKey(b []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing public key: %w", err)
+	}
+	switch key.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// Revision returns the revision of the wrapped repository, or "" if it does not report one
+func (r *SignedRepository) Revision(ctx context.Context) (string, error) {
+	reader, ok := r.repo.(RevisionReader)
+	if !ok {
+		return "", nil
+	}
+	return reader.Revision(ctx)
+}
+
+func (r *SignedRepository) LoadChannel(ctx context.Context, name string) (*Channel, error) {
+	if !allowedChannelName(name) {
+		return nil, fmt.Errorf("invalid channel name: %q", name)
+	}
+
+	b, err := r.readVerified(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	channel := &Channel{}
+	if err := yaml.Unmarshal(b, channel); err != nil {
+		return nil, fmt.Errorf("error parsing channel %s: %v", name, err)
+	}
+	return channel, nil
+}
+
+func (r *SignedRepository) LoadManifest(ctx context.Context, packageName string, id string) (map[string]string, error) {
+	if !allowedManifestId(packageName) {
+		return nil, fmt.Errorf("invalid package name: %q", packageName)
+	}
+
+	if !allowedManifestId(id) {
+		return nil, fmt.Errorf("invalid manifest id: %q", id)
+	}
+
+	packagePath := path.Join("packages", packageName, id)
+	sums, err := r.readVerified(ctx, path.Join(packagePath, DigestFileName))
+	if err != nil {
+		return nil, err
+	}
+	expected, err := parseDigestFile(sums)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s for package %s: %w", DigestFileName, packagePath, err)
+	}
+
+	manifests, err := r.repo.LoadManifest(ctx, packageName, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string)
+	seen := make(map[string]bool)
+	for p, contents := range manifests {
+		name := path.Base(strings.ReplaceAll(p, "\\", "/"))
+		if name == DigestFileName || name == DigestFileName+SignatureSuffix {
+			continue
+		}
+		want, found := expected[name]
+		if !found {
+			return nil, fmt.Errorf("package %s contains file %q which is not listed in %s", packagePath, name, DigestFileName)
+		}
+		got := sha256.Sum256([]byte(contents))
+		if hex.EncodeToString(got[:]) != want {
+			return nil, fmt.Errorf("package %s has been tampered with: digest of %q does not match %s", packagePath, name, DigestFileName)
+		}
+		seen[name] = true
+		result[p] = contents
+	}
+	for name := range expected {
+		if !seen[name] {
+			return nil, fmt.Errorf("package %s is missing file %q listed in %s", packagePath, name, DigestFileName)
+		}
+	}
+
+	log.FromContext(ctx).WithValues("package", packagePath).V(2).Info("verified package signature")
+	return result, nil
+}
+
+// readVerified reads the file at p, and verifies it against the detached signature at p + SignatureSuffix
+func (r *SignedRepository) readVerified(ctx context.Context, p string) ([]byte, error) {
+	b, err := r.repo.ReadRawFile(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", p, err)
+	}
+	sig, err := r.repo.ReadRawFile(ctx, p+SignatureSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not signed: error reading signature %s: %v", p, p+SignatureSuffix, err)
+	}
+
+	if !r.verify(b, sig) {
+		return nil, fmt.Errorf("signature verification failed for %s: not signed by a trusted key", p)
+	}
+	return b, nil
+}
+
+// verify returns true if sig is a valid signature of message by any of the trusted keys
+func (r *SignedRepository) verify(message, sig []byte) bool {
+	candidates := [][]byte{sig}
+	if decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig))); err == nil {
+		candidates = append(candidates, decoded)
+	}
+
+	digest := sha256.Sum256(message)
+	for _, key := range r.keys {
+		for _, candidate := range candidates {
+			switch key := key.(type) {
+			case ed25519.PublicKey:
+				if ed25519.Verify(key, message, candidate) {
+					return true
+				}
+			case *ecdsa.PublicKey:
+				if ecdsa.VerifyASN1(key, digest[:], candidate) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// parseDigestFile parses sha256sum output into a map of file name to hex digest
+func parseDigestFile(b []byte) (map[string]string, error) {
+	digests := make(map[string]string)
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		digest := strings.ToLower(fields[0])
+		if _, err := hex.DecodeString(digest); err != nil || len(digest) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid sha256 digest %q", fields[0])
+		}
+		// sha256sum prefixes the name with * in binary mode
+		name := path.Base(strings.TrimPrefix(fields[1], "*"))
+		digests[name] = digest
+	}
+	return digests, nil
+}
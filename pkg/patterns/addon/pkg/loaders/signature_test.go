@@ -0,0 +1,180 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loaders
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSignedRepository writes a channel and package to dir, signed with sign
+func writeSignedRepository(t *testing.T, dir string, sign func([]byte) []byte) {
+	t.Helper()
+
+	files := map[string]string{
+		"stable":                                 "manifests:\n- name: nginx\n  version: 0.1.0\n",
+		"packages/nginx/0.1.0/manifest.yaml":     "kind: ConfigMap\napiVersion: v1\nmetadata:\n  name: nginx\n",
+		"packages/nginx/0.1.0/service.yaml":      "kind: Service\napiVersion: v1\nmetadata:\n  name: nginx\n",
+		"packages/nginx/0.1.0/" + DigestFileName: "",
+	}
+
+	var sums strings.Builder
+	for _, name := range []string{"manifest.yaml", "service.yaml"} {
+		digest := sha256.Sum256([]byte(files["packages/nginx/0.1.0/"+name]))
+		fmt.Fprintf(&sums, "%s  %s\n", hex.EncodeToString(digest[:]), name)
+	}
+	files["packages/nginx/0.1.0/"+DigestFileName] = sums.String()
+	files["stable"+SignatureSuffix] = string(sign([]byte(files["stable"])))
+	files["packages/nginx/0.1.0/"+DigestFileName+SignatureSuffix] = string(sign([]byte(sums.String())))
+
+	for name, contents := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("error creating directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+	}
+}
+
+func TestSignedRepository(t *testing.T) {
+	ctx := context.Background()
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	ecPrivate, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	signers := map[string]func([]byte) []byte{
+		"ed25519": func(b []byte) []byte {
+			return ed25519.Sign(edPrivate, b)
+		},
+		"cosign": func(b []byte) []byte {
+			digest := sha256.Sum256(b)
+			sig, err := ecdsa.SignASN1(rand.Reader, ecPrivate, digest[:])
+			if err != nil {
+				t.Fatalf("error signing: %v", err)
+			}
+			return []byte(base64.StdEncoding.EncodeToString(sig))
+		},
+	}
+
+	for name, sign := range signers {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeSignedRepository(t, dir, sign)
+
+			repo, err := NewSignedRepository(NewFSRepository(dir), otherPublic, edPublic, &ecPrivate.PublicKey)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			channel, err := repo.LoadChannel(ctx, "stable")
+			if err != nil {
+				t.Fatalf("unexpected error loading channel: %v", err)
+			}
+			if len(channel.Manifests) != 1 || channel.Manifests[0].Version != "0.1.0" {
+				t.Errorf("unexpected channel %+v", channel)
+			}
+
+			manifests, err := repo.LoadManifest(ctx, "nginx", "0.1.0")
+			if err != nil {
+				t.Fatalf("unexpected error loading manifest: %v", err)
+			}
+			if len(manifests) != 2 {
+				t.Errorf("expected 2 manifests (without the digest file and signature), got %d", len(manifests))
+			}
+
+			// Tamper with the package
+			if err := os.WriteFile(filepath.Join(dir, "packages/nginx/0.1.0/service.yaml"), []byte("kind: Secret"), 0644); err != nil {
+				t.Fatalf("error writing file: %v", err)
+			}
+			if _, err := repo.LoadManifest(ctx, "nginx", "0.1.0"); err == nil || !strings.Contains(err.Error(), "tampered") {
+				t.Errorf("expected tampered error, got %v", err)
+			}
+
+			// Add a file that is not in the digest file
+			if err := os.WriteFile(filepath.Join(dir, "packages/nginx/0.1.0/extra.yaml"), []byte("kind: Secret"), 0644); err != nil {
+				t.Fatalf("error writing file: %v", err)
+			}
+			if _, err := repo.LoadManifest(ctx, "nginx", "0.1.0"); err == nil {
+				t.Errorf("expected error for unlisted file")
+			}
+
+			// Tamper with the channel
+			if err := os.WriteFile(filepath.Join(dir, "stable"), []byte("manifests:\n- name: nginx\n  version: 6.6.6\n"), 0644); err != nil {
+				t.Fatalf("error writing file: %v", err)
+			}
+			if _, err := repo.LoadChannel(ctx, "stable"); err == nil || !strings.Contains(err.Error(), "signature verification failed") {
+				t.Errorf("expected signature verification error, got %v", err)
+			}
+
+			// Remove the signature
+			if err := os.Remove(filepath.Join(dir, "stable"+SignatureSuffix)); err != nil {
+				t.Fatalf("error removing file: %v", err)
+			}
+			if _, err := repo.LoadChannel(ctx, "stable"); err == nil || !strings.Contains(err.Error(), "is not signed") {
+				t.Errorf("expected not signed error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatalf("error marshalling key: %v", err)
+	}
+
+	key, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !public.Equal(key) {
+		t.Errorf("parsed key does not match")
+	}
+
+	if _, err := ParsePublicKey([]byte("not a key")); err == nil {
+		t.Errorf("expected error parsing invalid key")
+	}
+}
@@ -33,6 +33,12 @@ type Repository interface {
 	LoadManifest(ctx context.Context, packageName string, id string) (map[string]string, error)
 }
 
+// RevisionReader is implemented by Repositories that can report the revision content is loaded from,
+// eg a git commit SHA or OCI artifact digest; it is reported in status as deployedRevision.
+type RevisionReader interface {
+	Revision(ctx context.Context) (string, error)
+}
+
 // FSRepository is a Repository backed by a filesystem
 type FSRepository struct {
 	basedir string
@@ -100,7 +106,7 @@ func (r *FSRepository) LoadChannel(ctx context.Context, name string) (*Channel,
 	b, err := os.ReadFile(p)
 	if err != nil {
 		log.WithValues("path", p).Error(err, "error reading channel")
-		return nil, fmt.Errorf("error reading channel %s: %v", p, err)
+		return nil, fmt.Errorf("error reading channel %s: %w", p, err)
 	}
 
 	channel := &Channel{}
@@ -126,7 +132,7 @@ func (r *FSRepository) LoadManifest(ctx context.Context, packageName string, id
 	dirPath := filepath.Join(r.basedir, "packages", packageName, id)
 	filesPath, err := os.ReadDir(dirPath)
 	if err != nil {
-		return nil, fmt.Errorf("error reading directory %s: %v", dirPath, err)
+		return nil, fmt.Errorf("error reading directory %s: %w", dirPath, err)
 	}
 	result := make(map[string]string)
 	for _, p := range filesPath {
@@ -138,7 +144,7 @@ func (r *FSRepository) LoadManifest(ctx context.Context, packageName string, id
 		filePath := filepath.Join(dirPath, p.Name())
 		b, err := os.ReadFile(filePath)
 		if err != nil {
-			return nil, fmt.Errorf("error reading file %s: %v", filePath, err)
+			return nil, fmt.Errorf("error reading file %s: %w", filePath, err)
 		}
 		result[filePath] = string(b)
 	}
@@ -146,13 +152,103 @@ func (r *FSRepository) LoadManifest(ctx context.Context, packageName string, id
 	return result, nil
 }
 
+// ReadRawFile reads a file relative to the repository root, for signature verification
+func (r *FSRepository) ReadRawFile(ctx context.Context, p string) ([]byte, error) {
+	if !allowedRawPath(p) {
+		return nil, fmt.Errorf("invalid path: %q", p)
+	}
+	return os.ReadFile(filepath.Join(r.basedir, filepath.FromSlash(p)))
+}
+
+// allowedRawPath checks that a relative path stays within the repository root
+func allowedRawPath(p string) bool {
+	if p == "" || strings.HasPrefix(p, "/") {
+		return false
+	}
+	for _, component := range strings.Split(p, "/") {
+		if component == "" || strings.HasPrefix(component, ".") {
+			return false
+		}
+	}
+	return true
+}
+
+// Channel lists the versions of packages that are available.
+//
+// Channels written in the original format only list names and versions;
+// all the other fields are optional, so such channels remain valid.
 type Channel struct {
 	Manifests []Version `json:"manifests,omitempty"`
+
+	// SequentialMinorUpgrades, if set, only allows upgrading to a later patch of the deployed minor version,
+	// or to the next minor version; other upgrades must be allowed explicitly with UpgradeFrom.
+	SequentialMinorUpgrades bool `json:"sequentialMinorUpgrades,omitempty"`
 }
 
 type Version struct {
 	Package string `json:"name"`
 	Version string `json:"version"`
+
+	// SHA256 is the optional hex sha256 of the package manifest, verified by HTTPRepository when the package is downloaded
+	SHA256 string `json:"sha256,omitempty"`
+
+	// MinOperatorVersion is the minimum version of the operator that can install this version
+	MinOperatorVersion string `json:"minOperatorVersion,omitempty"`
+
+	// KubernetesVersions is a semver range of the Kubernetes versions this version supports, eg ">=1.27.0 <1.31.0"
+	KubernetesVersions string `json:"kubernetesVersions,omitempty"`
+
+	// Deprecated versions are only chosen when no other version is permissible
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// DeprecationMessage explains the deprecation, eg which version to move to
+	DeprecationMessage string `json:"deprecationMessage,omitempty"`
+
+	// ReleaseNotes is a URL describing the changes in this version
+	ReleaseNotes string `json:"releaseNotes,omitempty"`
+
+	// UpgradeFrom is a semver range of the deployed versions that may upgrade directly to this version, eg ">=1.1.0 <1.2.0".
+	// If not set, any earlier version may upgrade directly, subject to SequentialMinorUpgrades.
+	UpgradeFrom string `json:"upgradeFrom,omitempty"`
+
+	// Variants lists the flavors of this version that can be selected with spec.variant, eg aws or nginx
+	Variants []Variant `json:"variants,omitempty"`
+
+	// Packages, if set, makes this version a composite of the listed packages,
+	// which are loaded instead of the package named after the addon
+	Packages []PackageRef `json:"packages,omitempty"`
+}
+
+// PackageRef is a package of a composite version
+type PackageRef struct {
+	Name string `json:"name"`
+
+	// Version is the exact version of the package, or a constraint resolved against the channel entries for the package;
+	// if not set, the latest version of the package in the channel is used
+	Version string `json:"version,omitempty"`
+
+	// Optional packages are only included when they are enabled in spec.packages
+	Optional bool `json:"optional,omitempty"`
+}
+
+// Variant is a flavor of a package version, stored in the package as <version>-<name>, eg packages/nginx/1.1.2-aws
+type Variant struct {
+	Name string `json:"name"`
+
+	// Overlay, if set, means the variant only holds the files that differ from the base version;
+	// they replace the base files with the same name, and any other files are added.
+	// Otherwise the variant holds the complete package.
+	Overlay bool `json:"overlay,omitempty"`
+}
+
+// FindVariant returns the variant with the given name, or nil if this version does not declare it
+func (v *Version) FindVariant(name string) *Variant {
+	for i := range v.Variants {
+		if v.Variants[i].Name == name {
+			return &v.Variants[i]
+		}
+	}
+	return nil
 }
 
 func (c *Channel) Latest(ctx context.Context, packageName string) (*Version, error) {
@@ -96,7 +96,15 @@ func (a *aggregator) BuildStatus(ctx context.Context, info *declarative.StatusIn
 	status := currentStatus
 	status.Healthy = statusHealthy
 	status.Errors = statusErrors
+	status.Warnings = info.Warnings
 	status.ObservedGeneration = info.Subject.GetGeneration()
+	setVersionStatus(info, &status)
+
+	if info.Resolution != nil {
+		if err := setUpgradeAvailableCondition(info); err != nil {
+			return err
+		}
+	}
 
 	if !reflect.DeepEqual(status, currentStatus) {
 		err := utils.SetCommonStatus(info.Subject, status)
@@ -8,9 +8,10 @@ import (
 )
 
 const (
-	AbnormalReason = "ManifestsNotReady"
-	NormalReason   = "Normal"
-	ReadyType      = "Ready"
+	AbnormalReason     = "ManifestsNotReady"
+	NormalReason       = "Normal"
+	PruneBlockedReason = "PruneBlocked"
+	ReadyType          = "Ready"
 )
 
 // buildReadyCondition returns a Condition object with human-readable message and reason.
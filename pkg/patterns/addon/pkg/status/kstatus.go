@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -87,6 +88,7 @@ func (k *kstatusAggregator) BuildStatus(ctx context.Context, info *declarative.S
 	}
 
 	shouldComputeHealthFromObjects := info.Manifest != nil && info.LiveObjects != nil
+	pruneBlocked := false
 	if info.Err != nil {
 		switch info.KnownError {
 		case declarative.KnownErrorApplyFailed:
@@ -95,6 +97,16 @@ func (k *kstatusAggregator) BuildStatus(ctx context.Context, info *declarative.S
 		case declarative.KnownErrorVersionCheckFailed:
 			currentStatus.Phase = "VersionMismatch"
 			shouldComputeHealthFromObjects = false
+		case declarative.KnownErrorPolicyViolation:
+			currentStatus.Phase = "PolicyViolation"
+			shouldComputeHealthFromObjects = false
+		case declarative.KnownErrorPruneBlocked:
+			currentStatus.Phase = "PruneBlocked"
+			shouldComputeHealthFromObjects = false
+			pruneBlocked = true
+		case declarative.KnownErrorRemovedAPI:
+			currentStatus.Phase = "RemovedAPI"
+			shouldComputeHealthFromObjects = false
 		default:
 			currentStatus.Phase = "InternalError"
 			shouldComputeHealthFromObjects = false
@@ -156,12 +168,27 @@ func (k *kstatusAggregator) BuildStatus(ctx context.Context, info *declarative.S
 		meta.SetStatusCondition(&conditions, readyCondition)
 
 		currentStatus.Phase = string(aggregatedPhase)
+	}
+	if pruneBlocked {
+		meta.SetStatusCondition(&conditions, metav1.Condition{
+			Type:    ReadyType,
+			Status:  metav1.ConditionFalse,
+			Reason:  PruneBlockedReason,
+			Message: info.Err.Error(),
+		})
+	}
+	if info.Resolution != nil {
+		meta.SetStatusCondition(&conditions, buildUpgradeAvailableCondition(info.Resolution))
+	}
+	if shouldComputeHealthFromObjects || pruneBlocked || info.Resolution != nil {
 		if err := SetConditions(info.Subject, conditions); err != nil {
 			return err
 		}
 	}
+	setVersionStatus(info, &currentStatus)
 	currentStatus.Healthy = currentStatus.Phase == string(status.CurrentStatus)
 	currentStatus.ObservedGeneration = info.Subject.GetGeneration()
+	currentStatus.Warnings = info.Warnings
 	if err = utils.SetCommonStatus(info.Subject, currentStatus); err != nil {
 		return err
 	}
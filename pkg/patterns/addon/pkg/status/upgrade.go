@@ -0,0 +1,93 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	addonsv1alpha1 "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/apis/v1alpha1"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+)
+
+const (
+	UpgradeAvailableType = "UpgradeAvailable"
+
+	UpgradeAvailableReason = "UpgradeAvailable"
+	AwaitingApprovalReason = "AwaitingApproval"
+	PinnedReason           = "Pinned"
+	UpToDateReason         = "UpToDate"
+)
+
+// setVersionStatus records the deployed version and revision, the available version, and the versions of composite packages, from the manifest resolution.
+// The deployed versions are only updated once the manifest has been applied successfully.
+func setVersionStatus(info *declarative.StatusInfo, status *addonsv1alpha1.CommonStatus) {
+	resolution := info.Resolution
+	if resolution == nil {
+		return
+	}
+	if info.Err == nil {
+		status.DeployedVersion = resolution.Version
+		status.DeployedRevision = resolution.Revision
+		status.Packages = nil
+		for _, p := range resolution.Packages {
+			status.Packages = append(status.Packages, addonsv1alpha1.PackageStatus{Name: p.Name, Version: p.Version})
+		}
+	}
+	status.AvailableVersion = resolution.AvailableVersion
+}
+
+// setUpgradeAvailableCondition sets the UpgradeAvailable condition in status.conditions from the manifest resolution
+func setUpgradeAvailableCondition(info *declarative.StatusInfo) error {
+	conditions, err := GetConditions(info.Subject)
+	if err != nil {
+		return err
+	}
+	meta.SetStatusCondition(&conditions, buildUpgradeAvailableCondition(info.Resolution))
+	return SetConditions(info.Subject, conditions)
+}
+
+// buildUpgradeAvailableCondition returns the UpgradeAvailable condition for the manifest resolution
+func buildUpgradeAvailableCondition(resolution *declarative.ManifestResolution) metav1.Condition {
+	condition := metav1.Condition{
+		Type:    UpgradeAvailableType,
+		Status:  metav1.ConditionFalse,
+		Reason:  UpToDateReason,
+		Message: fmt.Sprintf("version %s is the latest available version.", resolution.Version),
+	}
+	if resolution.AvailableVersion == "" {
+		return condition
+	}
+
+	condition.Status = metav1.ConditionTrue
+	switch {
+	case resolution.AwaitingApproval:
+		condition.Reason = AwaitingApprovalReason
+		condition.Message = fmt.Sprintf("version %s is available; set spec.approvedVersion to %s to upgrade.", resolution.AvailableVersion, resolution.AvailableVersion)
+	case resolution.Pinned:
+		condition.Reason = PinnedReason
+		condition.Message = fmt.Sprintf("version %s is available, but version %s is pinned.", resolution.AvailableVersion, resolution.Version)
+	default:
+		condition.Reason = UpgradeAvailableReason
+		condition.Message = fmt.Sprintf("version %s is available, and will be applied automatically.", resolution.AvailableVersion)
+	}
+	if resolution.LatestVersion != "" && resolution.LatestVersion != resolution.AvailableVersion {
+		condition.Message += fmt.Sprintf(" The latest version in channel %s is %s.", resolution.Channel, resolution.LatestVersion)
+	}
+	return condition
+}
@@ -19,6 +19,7 @@ package declarative
 import (
 	"context"
 
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/applyset"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/applier"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
 )
@@ -37,6 +38,10 @@ type ApplyOperation struct {
 
 	// ApplierOptions is the set of options passed to the applier
 	ApplierOptions *applier.ApplierOptions
+
+	// Results holds the result of applying each object, if the applier reports them.
+	// It is only set for AfterApply hooks.
+	Results *applyset.ApplyResults
 }
 
 // AfterApply is implemented by hooks that want to be called after every apply operation
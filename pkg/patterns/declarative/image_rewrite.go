@@ -0,0 +1,355 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package declarative
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+const (
+	// ImageLockAPIVersion is the apiVersion of an image lockfile shipped in a manifest package.
+	ImageLockAPIVersion = "addons.k8s.io/v1alpha1"
+	// ImageLockKind is the kind of an image lockfile shipped in a manifest package.
+	ImageLockKind = "ImageLock"
+)
+
+// ImageMirror rewrites images from one registry (or repository prefix) to another.
+//
+// Source and Destination ending in "/*" match every repository under the prefix,
+// for example "registry.k8s.io/*" -> "mirror.corp/k8s/*" rewrites
+// "registry.k8s.io/pause:3.9" to "mirror.corp/k8s/pause:3.9".
+// A wildcard Source must have a wildcard Destination, and vice versa.
+// Otherwise Source must match the image repository exactly.
+// Docker Hub images are matched in their fully-qualified form, eg "docker.io/library/busybox".
+type ImageMirror struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+// ImageLock pins image tags to digests.
+//
+// It can be provided directly in ImageTransformOptions, or shipped in the manifest package
+// as an object of kind ImageLock (apiVersion addons.k8s.io/v1alpha1), which is consumed
+// by ImageTransform, if configured; the reconciler never applies it to the cluster.
+type ImageLock struct {
+	Images []ImageLockEntry `json:"images,omitempty"`
+}
+
+// ImageLockEntry pins a single image reference to a digest.
+type ImageLockEntry struct {
+	// Image is the reference as it appears in the manifest, eg registry.k8s.io/pause:3.9
+	Image string `json:"image"`
+	// Digest is the pinned digest, eg sha256:7031c1b283388d2c2e09b57badb803c05ebed362dc88d84b480cc47f72a21097
+	Digest string `json:"digest"`
+}
+
+// ParseImageLock parses an image lockfile in YAML or JSON form.
+func ParseImageLock(b []byte) (*ImageLock, error) {
+	lock := &ImageLock{}
+	if err := yaml.Unmarshal(b, lock); err != nil {
+		return nil, fmt.Errorf("error parsing image lock: %w", err)
+	}
+	for _, entry := range lock.Images {
+		if entry.Image == "" || entry.Digest == "" {
+			return nil, fmt.Errorf("image lock entry %+v must specify image and digest", entry)
+		}
+	}
+	return lock, nil
+}
+
+// validate checks that the wildcards of Source and Destination match,
+// so that a prefix rule cannot produce an image like "mirror.corp/k8spause".
+func (m ImageMirror) validate() error {
+	if m.Source == "" || m.Destination == "" {
+		return fmt.Errorf("image mirror %+v must specify source and destination", m)
+	}
+	if strings.HasSuffix(m.Source, "/*") != strings.HasSuffix(m.Destination, "/*") {
+		return fmt.Errorf("image mirror %q -> %q must use a /* wildcard in both source and destination, or in neither", m.Source, m.Destination)
+	}
+	return nil
+}
+
+// digestFor returns the pinned digest for image, if any
+func (l *ImageLock) digestFor(image string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	normalized := normalizeImageName(image)
+	for _, entry := range l.Images {
+		if entry.Image == image || normalizeImageName(entry.Image) == normalized {
+			return entry.Digest, true
+		}
+	}
+	return "", false
+}
+
+// ImageRewrite records a single image that was changed by ImageTransform.
+type ImageRewrite struct {
+	// Object identifies the workload, as kind/namespace/name (or kind/name for objects without a namespace)
+	Object string
+	// ContainerType is one of containers, initContainers or ephemeralContainers
+	ContainerType string
+	// Container is the name of the container
+	Container string
+
+	From string
+	To   string
+}
+
+// ImageTransformOptions configures ImageTransform.
+type ImageTransformOptions struct {
+	// Mirrors are evaluated in order; the first matching rule is used.
+	Mirrors []ImageMirror
+
+	// Lock pins image references to digests.
+	// Entries from ImageLock objects in the manifest are used in addition to these, with these taking precedence.
+	Lock *ImageLock
+
+	// ImagePullSecret, if set, is added to every pod spec that is rewritten.
+	ImagePullSecret string
+
+	// RequireDigests fails the transform if an image is not pinned by a digest after rewriting.
+	RequireDigests bool
+
+	// Report, if set, is called with every rewritten image after the transform has run.
+	Report func(ctx context.Context, instance DeclarativeObject, rewrites []ImageRewrite)
+}
+
+// workloadKinds are the kinds that contain a pod spec we know how to find.
+var workloadKinds = map[string]bool{
+	"Pod":                   true,
+	"Deployment":            true,
+	"DaemonSet":             true,
+	"StatefulSet":           true,
+	"ReplicaSet":            true,
+	"ReplicationController": true,
+	"Job":                   true,
+	"CronJob":               true,
+}
+
+// ImageTransform rewrites images in containers, initContainers and ephemeralContainers of all workloads,
+// applying mirror rules and pinning tags to digests from an image lock.
+// Invalid mirror rules are reported as an error every time the transform runs.
+func ImageTransform(options ImageTransformOptions) ObjectTransform {
+	var mirrorErr error
+	for _, mirror := range options.Mirrors {
+		if err := mirror.validate(); err != nil {
+			mirrorErr = err
+			break
+		}
+	}
+
+	return func(ctx context.Context, o DeclarativeObject, m *manifest.Objects) error {
+		log := log.FromContext(ctx)
+
+		if mirrorErr != nil {
+			return mirrorErr
+		}
+
+		lock, err := extractImageLocks(m)
+		if err != nil {
+			return err
+		}
+		if options.Lock != nil {
+			lock.Images = append(append([]ImageLockEntry{}, options.Lock.Images...), lock.Images...)
+		}
+
+		var rewrites []ImageRewrite
+		for _, item := range m.Items {
+			if !workloadKinds[item.Kind] {
+				continue
+			}
+
+			id := item.Kind + "/" + item.GetName()
+			if ns := item.GetNamespace(); ns != "" {
+				id = item.Kind + "/" + ns + "/" + item.GetName()
+			}
+
+			changed := false
+			if err := item.MutatePodSpec(func(podSpec map[string]interface{}) error {
+				for _, containerType := range []string{"containers", "initContainers", "ephemeralContainers"} {
+					containers, _, err := unstructured.NestedFieldNoCopy(podSpec, containerType)
+					if err != nil {
+						return fmt.Errorf("error reading %s: %w", containerType, err)
+					}
+					if containers == nil {
+						continue
+					}
+					containerList, ok := containers.([]interface{})
+					if !ok {
+						return fmt.Errorf("%s was not a list", containerType)
+					}
+					for _, co := range containerList {
+						container, ok := co.(map[string]interface{})
+						if !ok {
+							return fmt.Errorf("container was not an object")
+						}
+						image, _, err := unstructured.NestedString(container, "image")
+						if err != nil {
+							return fmt.Errorf("error reading container image: %w", err)
+						}
+						if image == "" {
+							continue
+						}
+
+						newImage := rewriteImage(image, options.Mirrors, lock)
+						if options.RequireDigests && !strings.Contains(newImage, "@") {
+							return fmt.Errorf("image %q in %s is not pinned to a digest", image, id)
+						}
+						if newImage == image {
+							continue
+						}
+
+						name, _, _ := unstructured.NestedString(container, "name")
+						container["image"] = newImage
+						changed = true
+						rewrites = append(rewrites, ImageRewrite{
+							Object:        id,
+							ContainerType: containerType,
+							Container:     name,
+							From:          image,
+							To:            newImage,
+						})
+						log.WithValues("object", id, "container", name, "from", image, "to", newImage).V(1).Info("rewrote image")
+					}
+				}
+
+				if changed && options.ImagePullSecret != "" {
+					return applyImagePullSecret(options.ImagePullSecret)(podSpec)
+				}
+				return nil
+			}); err != nil {
+				return fmt.Errorf("error rewriting images in %s: %w", id, err)
+			}
+		}
+
+		if options.Report != nil {
+			options.Report(ctx, o, rewrites)
+		}
+		return nil
+	}
+}
+
+// extractImageLocks removes any ImageLock objects from the manifest, returning their merged contents.
+func extractImageLocks(m *manifest.Objects) (*ImageLock, error) {
+	lock := &ImageLock{}
+
+	var items []*manifest.Object
+	for _, item := range m.Items {
+		if !isImageLock(item) {
+			items = append(items, item)
+			continue
+		}
+
+		objLock := &ImageLock{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.UnstructuredObject().Object, objLock); err != nil {
+			return nil, fmt.Errorf("error parsing %s %q: %w", ImageLockKind, item.GetName(), err)
+		}
+		lock.Images = append(lock.Images, objLock.Images...)
+	}
+	m.Items = items
+
+	return lock, nil
+}
+
+// removeImageLocks removes any ImageLock objects left in the manifest, which are never applied to the cluster,
+// so that packages shipping an ImageLock can be deployed without ImageTransform.
+func removeImageLocks(m *manifest.Objects) {
+	var items []*manifest.Object
+	for _, item := range m.Items {
+		if !isImageLock(item) {
+			items = append(items, item)
+		}
+	}
+	m.Items = items
+}
+
+// isImageLock is true if the object is an image lockfile shipped in a manifest package.
+func isImageLock(item *manifest.Object) bool {
+	return item.Kind == ImageLockKind && item.GroupVersionKind().GroupVersion().String() == ImageLockAPIVersion
+}
+
+// rewriteImage applies the first matching mirror rule and pins the image to a digest from the lock.
+// Lock entries are matched against the original image, so the lock does not need to know about mirrors.
+func rewriteImage(image string, mirrors []ImageMirror, lock *ImageLock) string {
+	name, tag, digest := splitImage(image)
+
+	if digest == "" {
+		if d, ok := lock.digestFor(image); ok {
+			digest = d
+		}
+	}
+
+	normalized := normalizeImageName(name)
+	for _, mirror := range mirrors {
+		if strings.HasSuffix(mirror.Source, "/*") {
+			prefix := strings.TrimSuffix(mirror.Source, "*")
+			if strings.HasPrefix(normalized, prefix) {
+				name = strings.TrimSuffix(mirror.Destination, "*") + strings.TrimPrefix(normalized, prefix)
+				break
+			}
+		} else if normalized == normalizeImageName(mirror.Source) {
+			name = mirror.Destination
+			break
+		}
+	}
+
+	out := name
+	if tag != "" {
+		out += ":" + tag
+	}
+	if digest != "" {
+		out += "@" + digest
+	}
+	return out
+}
+
+// splitImage splits an image reference into repository name, tag and digest.
+func splitImage(image string) (name, tag, digest string) {
+	name = image
+	if i := strings.Index(name, "@"); i != -1 {
+		digest = name[i+1:]
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, ":"); i != -1 && !strings.Contains(name[i:], "/") {
+		tag = name[i+1:]
+		name = name[:i]
+	}
+	return name, tag, digest
+}
+
+// normalizeImageName expands Docker Hub short names, eg busybox -> docker.io/library/busybox.
+// Tags and digests are preserved.
+func normalizeImageName(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 1 {
+		return "docker.io/library/" + image
+	}
+	if !strings.ContainsAny(parts[0], ".:") && parts[0] != "localhost" {
+		return "docker.io/" + image
+	}
+	return image
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package declarative
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+func Test_ImageTransform(t *testing.T) {
+	inputManifest := `---
+apiVersion: addons.k8s.io/v1alpha1
+kind: ImageLock
+metadata:
+  name: images
+images:
+- image: registry.k8s.io/pause:3.9
+  digest: sha256:aaaa
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: registry.k8s.io/pause:3.9
+      containers:
+      - name: busybox
+        image: busybox:1.28
+      - name: already-pinned
+        image: registry.k8s.io/coredns/coredns@sha256:bbbb
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+spec:
+  containers:
+  - name: app
+    image: quay.io/example/app:v1
+  ephemeralContainers:
+  - name: debugger
+    image: registry.k8s.io/e2e-test-images/agnhost:2.39`
+
+	var testCases = []struct {
+		name             string
+		options          ImageTransformOptions
+		expectedManifest string
+		expectedRewrites []ImageRewrite
+		expectError      bool
+	}{
+		{
+			name: "mirrors and lock",
+			options: ImageTransformOptions{
+				Mirrors: []ImageMirror{
+					{Source: "registry.k8s.io/*", Destination: "mirror.corp/k8s/*"},
+					{Source: "docker.io/library/*", Destination: "mirror.corp/hub/*"},
+				},
+				Lock: &ImageLock{
+					Images: []ImageLockEntry{
+						{Image: "busybox:1.28", Digest: "sha256:cccc"},
+					},
+				},
+			},
+			expectedManifest: `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: mirror.corp/k8s/pause:3.9@sha256:aaaa
+      containers:
+      - name: busybox
+        image: mirror.corp/hub/busybox:1.28@sha256:cccc
+      - name: already-pinned
+        image: mirror.corp/k8s/coredns/coredns@sha256:bbbb
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+spec:
+  containers:
+  - name: app
+    image: quay.io/example/app:v1
+  ephemeralContainers:
+  - name: debugger
+    image: mirror.corp/k8s/e2e-test-images/agnhost:2.39`,
+			expectedRewrites: []ImageRewrite{
+				{Object: "Deployment/frontend", ContainerType: "containers", Container: "busybox", From: "busybox:1.28", To: "mirror.corp/hub/busybox:1.28@sha256:cccc"},
+				{Object: "Deployment/frontend", ContainerType: "containers", Container: "already-pinned", From: "registry.k8s.io/coredns/coredns@sha256:bbbb", To: "mirror.corp/k8s/coredns/coredns@sha256:bbbb"},
+				{Object: "Deployment/frontend", ContainerType: "initContainers", Container: "init", From: "registry.k8s.io/pause:3.9", To: "mirror.corp/k8s/pause:3.9@sha256:aaaa"},
+				{Object: "Pod/debug", ContainerType: "ephemeralContainers", Container: "debugger", From: "registry.k8s.io/e2e-test-images/agnhost:2.39", To: "mirror.corp/k8s/e2e-test-images/agnhost:2.39"},
+			},
+		},
+		{
+			name: "exact mirror and pull secret",
+			options: ImageTransformOptions{
+				Mirrors: []ImageMirror{
+					{Source: "quay.io/example/app", Destination: "mirror.corp/app"},
+				},
+				ImagePullSecret: "mirror-secret",
+			},
+			expectedManifest: `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: registry.k8s.io/pause:3.9@sha256:aaaa
+      containers:
+      - name: busybox
+        image: busybox:1.28
+      - name: already-pinned
+        image: registry.k8s.io/coredns/coredns@sha256:bbbb
+      imagePullSecrets:
+      - name: mirror-secret
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+spec:
+  containers:
+  - name: app
+    image: mirror.corp/app:v1
+  ephemeralContainers:
+  - name: debugger
+    image: registry.k8s.io/e2e-test-images/agnhost:2.39
+  imagePullSecrets:
+  - name: mirror-secret`,
+			expectedRewrites: []ImageRewrite{
+				{Object: "Deployment/frontend", ContainerType: "initContainers", Container: "init", From: "registry.k8s.io/pause:3.9", To: "registry.k8s.io/pause:3.9@sha256:aaaa"},
+				{Object: "Pod/debug", ContainerType: "containers", Container: "app", From: "quay.io/example/app:v1", To: "mirror.corp/app:v1"},
+			},
+		},
+		{
+			name:        "require digests",
+			options:     ImageTransformOptions{RequireDigests: true},
+			expectError: true,
+		},
+		{
+			name: "wildcard source with exact destination",
+			options: ImageTransformOptions{
+				Mirrors: []ImageMirror{
+					{Source: "registry.k8s.io/*", Destination: "mirror.corp/k8s"},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "exact source with wildcard destination",
+			options: ImageTransformOptions{
+				Mirrors: []ImageMirror{
+					{Source: "quay.io/example/app", Destination: "mirror.corp/*"},
+				},
+			},
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dummyDeclarative := &TestResource{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "TestResource",
+					APIVersion: "addons.example.org/v1alpha1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-instance",
+				},
+			}
+
+			ctx := context.Background()
+
+			objects, err := manifest.ParseObjects(ctx, inputManifest)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var rewrites []ImageRewrite
+			tc.options.Report = func(ctx context.Context, instance DeclarativeObject, r []ImageRewrite) {
+				rewrites = r
+			}
+
+			fn := ImageTransform(tc.options)
+			err = fn(ctx, dummyDeclarative, objects)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expectedObjects, err := manifest.ParseObjects(ctx, tc.expectedManifest)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(expectedObjects.Items) != len(objects.Items) {
+				t.Fatalf("expected %d objects, got %d", len(expectedObjects.Items), len(objects.Items))
+			}
+
+			for idx := range expectedObjects.Items {
+				diff := cmp.Diff(
+					expectedObjects.Items[idx].UnstructuredObject().Object,
+					objects.Items[idx].UnstructuredObject().Object)
+				if diff != "" {
+					t.Errorf("result mismatch (-want +got):\n%s", diff)
+				}
+			}
+
+			if diff := cmp.Diff(tc.expectedRewrites, rewrites); diff != "" {
+				t.Errorf("rewrites mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_TransformManifestRemovesImageLocks(t *testing.T) {
+	ctx := context.Background()
+
+	objects, err := manifest.ParseObjects(ctx, `---
+apiVersion: addons.k8s.io/v1alpha1
+kind: ImageLock
+metadata:
+  name: images
+images:
+- image: registry.k8s.io/pause:3.9
+  digest: sha256:aaaa
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+spec:
+  containers:
+  - name: app
+    image: registry.k8s.io/pause:3.9
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Without ImageTransform, the ImageLock is still not applied
+	r := &Reconciler{}
+	if err := r.transformManifest(ctx, nil, objects); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var kinds []string
+	for _, item := range objects.Items {
+		kinds = append(kinds, item.Kind)
+	}
+	if diff := cmp.Diff([]string{"Pod"}, kinds); diff != "" {
+		t.Errorf("unexpected objects (-want +got):\n%s", diff)
+	}
+}
+
+func Test_ParseImageLock(t *testing.T) {
+	lock, err := ParseImageLock([]byte(`
+images:
+- image: registry.k8s.io/pause:3.9
+  digest: sha256:aaaa
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := lock.digestFor("registry.k8s.io/pause:3.9"); got != "sha256:aaaa" {
+		t.Errorf("unexpected digest %q", got)
+	}
+
+	if _, err := ParseImageLock([]byte(`images: [{image: foo}]`)); err == nil {
+		t.Errorf("expected error for entry without digest")
+	}
+}
@@ -50,6 +50,7 @@ const (
 const (
 	ReconcileCount   = "reconcile_count"
 	ReconcileFailure = "reconcile_failure_count"
+	ApplyRetryCount  = "apply_retry_count"
 
 	ManagedObjectsRecord = "managed_objects_record"
 )
@@ -69,6 +70,12 @@ var (
 		Help:      "How many times reconciliation failure of K8s objects managed by declarative reconciler occurs",
 	}, []string{"group_version_kind", "namespace", "name"})
 
+	applyRetryCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: Declarative,
+		Name:      ApplyRetryCount,
+		Help:      "How many times the apply of an object was retried after a transient error, during reconciliation of K8s objects managed by declarative reconciler",
+	}, []string{"group_version_kind", "namespace", "name"})
+
 	managedObjectsRecord = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Subsystem: Declarative,
 		Name:      ManagedObjectsRecord,
@@ -76,7 +83,7 @@ var (
 	}, []string{"group_version_kind", "namespace", "name"})
 )
 
-var metricsList = []prometheus.Collector{reconcileCount, reconcileFailure, managedObjectsRecord}
+var metricsList = []prometheus.Collector{reconcileCount, reconcileFailure, applyRetryCount, managedObjectsRecord}
 
 func gvkString(gvk schema.GroupVersionKind) string {
 	if len(gvk.Group) == 0 && gvk.Version == "v1" {
@@ -103,12 +110,14 @@ type reconcileMetrics struct {
 	groupVersionKind           string
 	reconcileCounterVec        *prometheus.CounterVec
 	reconcileFailureCounterVec *prometheus.CounterVec
+	applyRetryCounterVec       *prometheus.CounterVec
 }
 
 func reconcileMetricsFor(gvk schema.GroupVersionKind) reconcileMetrics {
 	return reconcileMetrics{
 		groupVersionKind:    gvkString(gvk),
 		reconcileCounterVec: reconcileCount, reconcileFailureCounterVec: reconcileFailure,
+		applyRetryCounterVec: applyRetryCount,
 	}
 }
 
@@ -122,6 +131,12 @@ func (rm *reconcileMetrics) reconcileFailedWith(req reconcile.Request, _ reconci
 	}
 }
 
+func (rm *reconcileMetrics) applyRetriedWith(req reconcile.Request, retries int) {
+	if retries > 0 {
+		rm.applyRetryCounterVec.WithLabelValues(rm.groupVersionKind, req.Namespace, req.Name).Add(float64(retries))
+	}
+}
+
 type objectRecorder struct {
 	groupVersionKind string
 	gaugeVec         *prometheus.GaugeVec
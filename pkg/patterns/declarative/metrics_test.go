@@ -248,6 +248,51 @@ func TestReconcileFailedWith(t *testing.T) {
 	}
 }
 
+// This test checks reconcileMetrics.applyRetriedWith method
+func TestApplyRetriedWith(t *testing.T) {
+	testCases := []struct {
+		subtest string
+		retries []int
+		want    string
+	}{
+		{
+			subtest: "retried",
+			retries: []int{2, 0, 1},
+			want: `
+			# HELP declarative_reconciler_apply_retry_count How many times the apply of an object was retried after a transient error, during reconciliation of K8s objects managed by declarative reconciler
+			# TYPE declarative_reconciler_apply_retry_count counter
+			declarative_reconciler_apply_retry_count {group_version_kind = "apps/v1/Deployment", name = "n1", namespace = "ns1"} 3
+			`,
+		},
+		{
+			subtest: "not retried",
+			retries: []int{0},
+			want: `
+			# HELP declarative_reconciler_apply_retry_count How many times the apply of an object was retried after a transient error, during reconciliation of K8s objects managed by declarative reconciler
+			# TYPE declarative_reconciler_apply_retry_count counter
+			declarative_reconciler_apply_retry_count {group_version_kind = "apps/v1/Deployment", name = "n1", namespace = "ns1"} 0
+			`,
+		},
+	}
+
+	gvk := apps.SchemeGroupVersion.WithKind("Deployment")
+	for _, st := range testCases {
+		t.Run(st.subtest, func(t *testing.T) {
+			rm := reconcileMetricsFor(gvk)
+			for _, retries := range st.retries {
+				rm.applyRetriedWith(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "ns1", Name: "n1"}}, retries)
+			}
+
+			if err := testutil.CollectAndCompare(rm.applyRetryCounterVec.WithLabelValues(gvkString(gvk), "ns1", "n1"),
+				strings.NewReader(st.want)); err != nil {
+				t.Error(err)
+			}
+		})
+
+		applyRetryCount.Reset()
+	}
+}
+
 // This test checks *ObjectTracker.addIfNotPresent method
 func TestAddIfNotPresent(t *testing.T) {
 	k8s, err := mockkubeapiserver.NewMockKubeAPIServer(":0")
@@ -23,8 +23,11 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/applyset"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/applier"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/deprecation"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/policy"
 )
 
 type ManifestLoaderFunc func() (ManifestController, error)
@@ -52,9 +55,14 @@ type reconcilerParams struct {
 
 	cascadingStrategy metav1.DeletionPropagation
 	prune             bool
+	prunePolicy       applyset.PrunePolicy
+	conflictPolicy    applyset.ConflictPolicy
+	recreatePolicy    applyset.RecreatePolicy
+	retryPolicy       applyset.RetryPolicy
 	preserveNamespace bool
 	kustomize         bool
 	validate          bool
+	fieldValidation   string
 	metrics           bool
 
 	sink       Sink
@@ -64,6 +72,11 @@ type reconcilerParams struct {
 
 	// hooks allow for interception of events during the reconciliation lifecycle
 	hooks []Hook
+
+	policyRules []policy.Rule
+	policyMode  PolicyMode
+
+	deprecationPolicy *deprecation.Policy
 }
 
 type ManifestController interface {
@@ -88,6 +101,16 @@ type OwnerSelector = func(context.Context, DeclarativeObject, manifest.Object, m
 // LabelMaker returns a fixed set of labels for a given DeclarativeObject
 type LabelMaker = func(context.Context, DeclarativeObject) map[string]string
 
+// PolicyMode controls what happens when rendered objects violate a policy
+type PolicyMode string
+
+const (
+	// PolicyModeEnforce blocks the apply when any policy is violated
+	PolicyModeEnforce PolicyMode = "Enforce"
+	// PolicyModeWarn records violations as events, but still applies the objects
+	PolicyModeWarn PolicyMode = "Warn"
+)
+
 // WithRawManifestOperation adds the specific ManifestOperations to the chain of manifest changes
 func WithRawManifestOperation(operations ...ManifestOperation) ReconcilerOption {
 	return func(p reconcilerParams) reconcilerParams {
@@ -124,6 +147,52 @@ func WithApplyPrune() ReconcilerOption {
 	}
 }
 
+// WithPrunePolicy configures the guards against pruning objects by mistake, when WithApplyPrune is used.
+//
+// By default objects annotated with addons.k8s.io/prune=disabled, CRDs and Namespaces are never pruned,
+// and pruning is blocked if it would delete more than half of the tracked objects in one reconcile,
+// unless the object being reconciled is annotated with addons.k8s.io/allow-mass-prune=true.
+func WithPrunePolicy(policy applyset.PrunePolicy) ReconcilerOption {
+	return func(p reconcilerParams) reconcilerParams {
+		p.prunePolicy = policy
+		return p
+	}
+}
+
+// WithConflictPolicy controls what happens when the apply conflicts with fields owned by other field managers.
+//
+// By default we force the apply and take ownership of the conflicting fields.
+// With applyset.ConflictPolicyFail the apply of the object fails, and with applyset.ConflictPolicySkipConflictingFields
+// (only supported by the ApplySetApplier) the conflicting fields are left to the other managers.
+// Either way, the conflicting fields and their managers are recorded as warnings on the DeclarativeObject.
+func WithConflictPolicy(policy applyset.ConflictPolicy) ReconcilerOption {
+	return func(p reconcilerParams) reconcilerParams {
+		p.conflictPolicy = policy
+		return p
+	}
+}
+
+// WithRecreatePolicy controls whether objects are deleted and recreated when the apply changes immutable fields,
+// eg the selector of a Deployment; this is only supported by the ApplySetApplier.
+//
+// Objects can opt in or out with the addons.k8s.io/recreate-on-immutable-change annotation.
+func WithRecreatePolicy(policy applyset.RecreatePolicy) ReconcilerOption {
+	return func(p reconcilerParams) reconcilerParams {
+		p.recreatePolicy = policy
+		return p
+	}
+}
+
+// WithRetryPolicy controls how the apply of an individual object is retried after transient errors,
+// eg throttling or an unavailable webhook, before the reconcile fails; validation errors are never retried.
+// By default applyset.DefaultRetryPolicy is used; set MaxRetries to a negative value to disable retries.
+func WithRetryPolicy(policy applyset.RetryPolicy) ReconcilerOption {
+	return func(p reconcilerParams) reconcilerParams {
+		p.retryPolicy = policy
+		return p
+	}
+}
+
 // WithOwner sets an owner ref on each deployed object by the OwnerSelector
 func WithOwner(ownerFn OwnerSelector) ReconcilerOption {
 	return func(p reconcilerParams) reconcilerParams {
@@ -177,10 +246,19 @@ func WithManagedApplication(labelMaker LabelMaker) ReconcilerOption {
 	}
 }
 
-// WithApplyValidation enables validation with kubectl apply
+// WithApplyValidation enables strict server-side field validation of the applied objects,
+// so that objects with unknown or duplicate fields are rejected by the apiserver.
 func WithApplyValidation() ReconcilerOption {
+	return WithFieldValidation(metav1.FieldValidationStrict)
+}
+
+// WithFieldValidation sets the server-side field validation directive for the apply,
+// metav1.FieldValidationStrict, metav1.FieldValidationWarn or metav1.FieldValidationIgnore.
+// With metav1.FieldValidationWarn, unknown fields are reported as warnings on the DeclarativeObject.
+func WithFieldValidation(directive string) ReconcilerOption {
 	return func(p reconcilerParams) reconcilerParams {
 		p.validate = true
+		p.fieldValidation = directive
 		return p
 	}
 }
@@ -252,3 +330,27 @@ func WithHook(hook Hook) ReconcilerOption {
 		return p
 	}
 }
+
+// WithPolicy evaluates the rendered objects against the policy rules before they are applied.
+// In PolicyModeEnforce, any violation blocks the apply and is reported with KnownErrorPolicyViolation;
+// in PolicyModeWarn, violations are recorded as events on the DeclarativeObject.
+func WithPolicy(mode PolicyMode, rules ...policy.Rule) ReconcilerOption {
+	return func(p reconcilerParams) reconcilerParams {
+		p.policyMode = mode
+		p.policyRules = append(p.policyRules, rules...)
+		return p
+	}
+}
+
+// WithDeprecatedAPICheck checks the rendered objects for deprecated or removed API versions before they are applied,
+// using the apiserver discovery and a built-in table of deprecations.
+//
+// Objects using deprecated API versions are recorded as warnings on the DeclarativeObject, with the suggested replacement.
+// Objects using API versions that are no longer served block the apply, and are reported with KnownErrorRemovedAPI,
+// unless policy.AutoConvert is set and the object can be converted, eg an Ingress or PodDisruptionBudget.
+func WithDeprecatedAPICheck(policy deprecation.Policy) ReconcilerOption {
+	return func(p reconcilerParams) reconcilerParams {
+		p.deprecationPolicy = &policy
+		return p
+	}
+}
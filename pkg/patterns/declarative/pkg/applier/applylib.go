@@ -11,31 +11,45 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/applyset"
 )
 
 type ApplysetOptions struct {
 	Tooling string
+
+	// MigrateFromLabelSelector adopts the objects that were pruned with a label selector (kubectl apply --prune --selector)
+	// into the applyset, so that operators using WithApplyPrune and WithLabels can switch to the ApplySetApplier.
+	// The selector and prune whitelist are taken from the --selector and --prune-whitelist args.
+	MigrateFromLabelSelector bool
 }
 
 type ApplySetApplier struct {
-	Tooling      string
-	patchOptions metav1.PatchOptions
+	Tooling string
+	// migrateFromLabelSelector adopts the objects matching the --selector arg into the applyset
+	migrateFromLabelSelector bool
+	patchOptions             metav1.PatchOptions
 	// Optional: This deletion Options is for pruning. It will only be taken into consideration if pruning is enabled
 	// e.g. `options.WithApplyPrune()`.
 	deleteOptions metav1.DeleteOptions
 }
 
-var _ Applier = &ApplySetApplier{}
+var _ ApplierWithResults = &ApplySetApplier{}
 
 func NewApplySetApplier(patchOptions metav1.PatchOptions, deleteOptions metav1.DeleteOptions, option ApplysetOptions) *ApplySetApplier {
-	return &ApplySetApplier{patchOptions: patchOptions, deleteOptions: deleteOptions, Tooling: option.Tooling}
+	return &ApplySetApplier{patchOptions: patchOptions, deleteOptions: deleteOptions, Tooling: option.Tooling, migrateFromLabelSelector: option.MigrateFromLabelSelector}
 }
 
 func (a *ApplySetApplier) Apply(ctx context.Context, opt ApplierOptions) error {
+	_, err := a.ApplyWithResults(ctx, opt)
+	return err
+}
 
+func (a *ApplySetApplier) ApplyWithResults(ctx context.Context, opt ApplierOptions) (*applyset.ApplyResults, error) {
 	patchOptions := a.patchOptions
 
+	var adoptSelector string
+	var adoptGroupKinds []schema.GroupKind
 	for i := 0; i < len(opt.ExtraArgs); i++ {
 		switch opt.ExtraArgs[i] {
 		case "--force":
@@ -44,24 +58,52 @@ func (a *ApplySetApplier) Apply(ctx context.Context, opt ApplierOptions) error {
 			opt.Prune = true
 		case "--selector":
 			if i == len(opt.ExtraArgs)-1 || strings.HasPrefix(opt.ExtraArgs[i+1], "-") {
-				return fmt.Errorf("invalid `--selector` in args %q", opt.ExtraArgs)
+				return nil, fmt.Errorf("invalid `--selector` in args %q", opt.ExtraArgs)
+			}
+			if a.migrateFromLabelSelector {
+				adoptSelector = opt.ExtraArgs[i+1]
+			} else {
+				klog.Warningf("skip `--selector` from args, selector value %v ", opt.ExtraArgs[i+1])
+			}
+			i++
+		case "--prune-whitelist":
+			if !a.migrateFromLabelSelector {
+				return nil, fmt.Errorf("extraArg %q is only supported by the ApplySetApplier when migrating from a label selector", opt.ExtraArgs[i])
+			}
+			if i == len(opt.ExtraArgs)-1 {
+				return nil, fmt.Errorf("invalid `--prune-whitelist` in args %q", opt.ExtraArgs)
+			}
+			gk, err := parsePruneWhitelistKind(opt.ExtraArgs[i+1])
+			if err != nil {
+				return nil, err
 			}
-			klog.Warningf("skip `--selector` from args, selector value %v ", opt.ExtraArgs[i+1])
+			adoptGroupKinds = append(adoptGroupKinds, gk)
 			i++
 		default:
-			return fmt.Errorf("extraArg %q is not supported by the ApplySetApplier", opt.ExtraArgs[i])
+			return nil, fmt.Errorf("extraArg %q is not supported by the ApplySetApplier", opt.ExtraArgs[i])
 		}
 	}
 
 	patchOptions.Force = &opt.Force
+	if fieldValidation := fieldValidationFor(opt); fieldValidation != "" {
+		patchOptions.FieldValidation = fieldValidation
+	}
 
+	// The warnings returned by the apiserver are recorded for each object; a cached DynamicClient records them
+	// through the context of the apply, if its transport is wrapped with applyset.WrapTransportForWarnings.
 	dynamicClient := opt.DynamicClient
+	warnings := applyset.NewWarningRecorder()
 	if dynamicClient == nil {
-		d, err := dynamic.NewForConfig(opt.RESTConfig)
+		restConfig, recorder := withWarningRecorder(opt)
+		if restConfig == nil {
+			return nil, fmt.Errorf("the ApplySetApplier requires a DynamicClient or RESTConfig")
+		}
+		d, err := dynamic.NewForConfig(restConfig)
 		if err != nil {
-			return fmt.Errorf("error building dynamic client: %w", err)
+			return nil, fmt.Errorf("error building dynamic client: %w", err)
 		}
 		dynamicClient = d
+		warnings = recorder
 	}
 
 	restMapper := opt.RESTMapper
@@ -79,10 +121,19 @@ func (a *ApplySetApplier) Apply(ctx context.Context, opt ApplierOptions) error {
 		Prune:         opt.Prune,
 		Tooling:       tooling,
 		ParentClient:  opt.Client,
+		PrunePolicy:   opt.PrunePolicy,
+
+		ConflictPolicy: opt.ConflictPolicy,
+		RecreatePolicy: opt.RecreatePolicy,
+		RetryPolicy:    opt.RetryPolicy,
+		Warnings:       warnings,
+
+		AdoptSelector:   adoptSelector,
+		AdoptGroupKinds: adoptGroupKinds,
 	}
 	s, err := applyset.New(options)
 	if err != nil {
-		return fmt.Errorf("error creating applyset: %w", err)
+		return nil, fmt.Errorf("error creating applyset: %w", err)
 	}
 
 	// Populate the namespace on any namespace-scoped objects
@@ -91,7 +142,7 @@ func (a *ApplySetApplier) Apply(ctx context.Context, opt ApplierOptions) error {
 			gvk := obj.GroupVersionKind()
 			restMapping, err := restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
 			if err != nil {
-				return fmt.Errorf("error getting rest mapping for %v: %w", gvk, err)
+				return nil, fmt.Errorf("error getting rest mapping for %v: %w", gvk, err)
 			}
 
 			switch restMapping.Scope {
@@ -101,7 +152,7 @@ func (a *ApplySetApplier) Apply(ctx context.Context, opt ApplierOptions) error {
 			case meta.RESTScopeRoot:
 				// Don't set namespace
 			default:
-				return fmt.Errorf("unknown rest mapping scope %v", restMapping.Scope)
+				return nil, fmt.Errorf("unknown rest mapping scope %v", restMapping.Scope)
 			}
 		}
 	}
@@ -112,21 +163,37 @@ func (a *ApplySetApplier) Apply(ctx context.Context, opt ApplierOptions) error {
 		applyableObjects = append(applyableObjects, applyableObject)
 	}
 	if err := s.SetDesiredObjects(applyableObjects); err != nil {
-		return fmt.Errorf("error setting desired objects for apply: %w", err)
+		return nil, fmt.Errorf("error setting desired objects for apply: %w", err)
 	}
 
 	results, err := s.ApplyOnce(ctx)
 	if err != nil {
 		// TODO: Aggregate errors?
-		return fmt.Errorf("error applying objects: %w", err)
+		return results, fmt.Errorf("error applying objects: %w", err)
+	}
+	if adopted := results.AdoptedCount(); adopted != 0 {
+		log.FromContext(ctx).Info("adopted objects from label selector into applyset", "count", adopted, "selector", adoptSelector)
 	}
 	if !results.AllApplied() {
-		return fmt.Errorf("not all objects applied")
+		return results, fmt.Errorf("not all objects applied")
 	}
 
 	// TODO: Check healthy
 
-	return nil
+	return results, nil
+}
+
+// parsePruneWhitelistKind parses a kind in the kubectl --prune-whitelist format, eg core/v1/ConfigMap or apps/v1/Deployment
+func parsePruneWhitelistKind(s string) (schema.GroupKind, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 3 || parts[2] == "" {
+		return schema.GroupKind{}, fmt.Errorf("invalid `--prune-whitelist` value %q, expected group/version/kind", s)
+	}
+	group := parts[0]
+	if group == "core" {
+		group = ""
+	}
+	return schema.GroupKind{Group: group, Kind: parts[2]}, nil
 }
 
 // NewParentRef maps a declarative object's information to the ParentRef defined in the applyset library.
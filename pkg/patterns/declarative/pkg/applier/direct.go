@@ -4,13 +4,17 @@
 package applier
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
-	"os"
+	"io"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -23,6 +27,8 @@ import (
 	cmdDelete "k8s.io/kubectl/pkg/cmd/delete"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/prune"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/applyset"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
 )
 
@@ -38,7 +44,7 @@ func (d *DirectApplier) UseServerSideApply() {
 	d.serverSideApplyPreferred = true
 }
 
-var _ Applier = &DirectApplier{}
+var _ ApplierWithResults = &DirectApplier{}
 
 type directApplier struct{}
 
@@ -79,19 +85,33 @@ func NewDirectApplier() Applier {
 }
 
 func (d *DirectApplier) Apply(ctx context.Context, opt ApplierOptions) error {
+	_, err := d.ApplyWithResults(ctx, opt)
+	return err
+}
+
+// ApplyWithResults applies the objects with kubectl apply, capturing the output of kubectl
+// into the per-object results instead of writing it to stdout.
+func (d *DirectApplier) ApplyWithResults(ctx context.Context, opt ApplierOptions) (*applyset.ApplyResults, error) {
 	objects := manifest.Objects{Items: opt.Objects}
 	manifestStr, err := objects.JSONManifest()
 	if err != nil {
-		return fmt.Errorf("error creating JSON manifest: %w", err)
+		return nil, fmt.Errorf("error creating JSON manifest: %w", err)
 	}
 
+	var stdout, stderr bytes.Buffer
 	ioStreams := genericclioptions.IOStreams{
-		In:     os.Stdin,
-		Out:    os.Stdout,
-		ErrOut: os.Stderr,
+		In:     &bytes.Buffer{},
+		Out:    &stdout,
+		ErrOut: &stderr,
 	}
 	ioReader := strings.NewReader(manifestStr)
 
+	// Record the warnings returned by the apiserver, eg for unknown fields
+	restConfig, warnings := withWarningRecorder(opt)
+	if restConfig != nil {
+		opt.RESTConfig = restConfig
+	}
+
 	b := d.inner.NewBuilder(opt)
 	f := d.inner.NewFactory(opt)
 
@@ -99,16 +119,11 @@ func (d *DirectApplier) Apply(ctx context.Context, opt ApplierOptions) error {
 	if dynamicClient == nil {
 		dc, err := f.DynamicClient()
 		if err != nil {
-			return err
+			return nil, err
 		}
 		dynamicClient = dc
 	}
 
-	if opt.Validate {
-		// client-side validation is no longer recommended, in favor of server-side apply/validation
-		return fmt.Errorf("client-side validation is no longer supported")
-	}
-
 	var errs []error
 	res := b.Unstructured().ContinueOnError().Stream(ioReader, "manifestString").Do()
 	infos, err := res.Infos()
@@ -116,7 +131,7 @@ func (d *DirectApplier) Apply(ctx context.Context, opt ApplierOptions) error {
 		errs = append(errs, err)
 
 		if len(infos) == 0 {
-			return err
+			return nil, err
 		}
 	}
 
@@ -125,7 +140,7 @@ func (d *DirectApplier) Apply(ctx context.Context, opt ApplierOptions) error {
 		visitor := resource.SetNamespace(opt.Namespace)
 		for _, info := range infos {
 			if err := info.Visit(visitor); err != nil {
-				return utilerrors.NewAggregate(append(errs, fmt.Errorf("error from SetNamespace: %w", err)))
+				return nil, utilerrors.NewAggregate(append(errs, fmt.Errorf("error from SetNamespace: %w", err)))
 			}
 		}
 	}
@@ -145,8 +160,6 @@ func (d *DirectApplier) Apply(ctx context.Context, opt ApplierOptions) error {
 		// Automatically resolve conflicts between the modified and live configuration by using values from the modified configuration
 		Overwrite: true,
 	}
-	// TODO this will add the print part at all times.
-	applyOpts.PostProcessorFn = applyOpts.PrintAndPrunePostProcessor()
 
 	whiteListResources := []string{}
 	for i, arg := range opt.ExtraArgs {
@@ -168,33 +181,238 @@ func (d *DirectApplier) Apply(ctx context.Context, opt ApplierOptions) error {
 	if len(whiteListResources) > 0 {
 		rm, err := f.ToRESTMapper()
 		if err != nil {
-			return err
+			return nil, err
 		}
 		r, err := prune.ParseResources(rm, whiteListResources)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		applyOpts.PruneResources = append(applyOpts.PruneResources, r...)
 	}
 
+	results := applyset.NewApplyResults(len(infos))
+	recorder := &resultRecorder{ctx: ctx, results: results, warnings: warnings, applied: make(map[string]bool)}
+
+	if fieldValidation := fieldValidationFor(opt); fieldValidation != "" {
+		applyOpts.ValidationDirective = fieldValidation
+	}
 	applyOpts.ServerSideApply = d.serverSideApplyPreferred
-	applyOpts.ForceConflicts = opt.Force
+	force, err := forceConflicts(opt)
+	if err != nil {
+		return nil, err
+	}
+	applyOpts.ForceConflicts = force
 	applyOpts.Namespace = opt.Namespace
 	applyOpts.SetObjects(infos)
 	applyOpts.ToPrinter = func(operation string) (printers.ResourcePrinter, error) {
-		applyOpts.PrintFlags.NamePrintFlags.Operation = operation
-		cmdutil.PrintFlagsWithDryRunStrategy(applyOpts.PrintFlags, applyOpts.DryRunStrategy)
-		return applyOpts.PrintFlags.ToPrinter()
+		return recorder.printerFor(operation), nil
 	}
 	applyOpts.DeleteOptions = &cmdDelete.DeleteOptions{
 		IOStreams:         ioStreams,
 		CascadingStrategy: opt.CascadingStrategy,
 	}
 
-	if err := d.inner.Run(applyOpts); err != nil {
-		return utilerrors.NewAggregate(append(errs, fmt.Errorf("error from apply yamls: %w", err)))
+	// We prune ourselves rather than with kubectl's PrintAndPrunePostProcessor, so that we can apply the PrunePolicy
+	applyOpts.PostProcessorFn = func() error {
+		if !applyOpts.Prune {
+			return nil
+		}
+		pruner := &kubectlPruner{
+			dynamicClient:      dynamicClient,
+			restMapper:         applyOpts.Mapper,
+			pruneResources:     applyOpts.PruneResources,
+			namespaceSpecified: applyOpts.Namespace != "",
+			namespaces:         applyOpts.VisitedNamespaces,
+			selector:           applyOpts.Selector,
+			isApplied: func(obj *unstructured.Unstructured) bool {
+				return applyOpts.VisitedUids.Has(obj.GetUID())
+			},
+			appliedCount:      applyOpts.VisitedUids.Len(),
+			cascadingStrategy: opt.CascadingStrategy,
+			policy:            prunePolicyFor(opt),
+		}
+		return pruner.prune(ctx, results)
+	}
+
+	// We retry the objects that failed with transient errors, but only if all the failures were transient;
+	// otherwise kubectl would prune once the retried objects had been applied.
+	retryPolicy := opt.RetryPolicy
+	if retryPolicy == (applyset.RetryPolicy{}) {
+		retryPolicy = applyset.DefaultRetryPolicy
+	}
+	retries := make(map[string]int)
+	var pending []*resource.Info
+	var runErr error
+	// objectErrs are the errors from the last run, keyed by infoKey
+	var objectErrs map[string]error
+	_, _ = retryPolicy.Retry(ctx, func() error {
+		running := infos
+		if pending != nil {
+			running = pending
+		}
+		for _, info := range pending {
+			retries[infoKey(info)]++
+		}
+		runErr = d.inner.Run(applyOpts)
+		if runErr == nil {
+			return nil
+		}
+		objectErrs = recorder.objectErrors(running, runErr)
+		pending = nil
+		for _, info := range running {
+			err, failed := objectErrs[infoKey(info)]
+			if !failed {
+				continue
+			}
+			if !applyset.IsRetryable(err) {
+				return err
+			}
+			pending = append(pending, info)
+		}
+		if len(pending) == 0 {
+			// The error is not from applying an object (eg it is from pruning), so we don't retry
+			return nil
+		}
+		log.FromContext(ctx).Info("retrying apply after transient errors", "objects", len(pending))
+		applyOpts.SetObjects(pending)
+		return runErr
+	})
+	logOutput(ctx, "stdout", &stdout)
+	logOutput(ctx, "stderr", &stderr)
+
+	// Objects that kubectl did not print were not applied
+	for _, info := range infos {
+		gvk := info.Object.GetObjectKind().GroupVersionKind()
+		nn := types.NamespacedName{Namespace: info.Namespace, Name: info.Name}
+		if recorder.applied[resultKey(gvk.GroupKind().String(), nn)] {
+			continue
+		}
+		err, found := objectErrs[infoKey(info)]
+		if !found {
+			err = runErr
+		}
+		if err == nil {
+			err = fmt.Errorf("object was not applied")
+		}
+		log.FromContext(ctx).WithValues("object", nn, "gvk", gvk).Error(err, "error applying object")
+		results.RecordApplyError(gvk, nn, err)
+	}
+
+	for _, info := range infos {
+		gvk := info.Object.GetObjectKind().GroupVersionKind()
+		nn := types.NamespacedName{Namespace: info.Namespace, Name: info.Name}
+		if n := retries[infoKey(info)]; n != 0 {
+			results.RecordRetries(gvk, nn, n)
+		}
+	}
+
+	if runErr != nil {
+		errs = append(errs, fmt.Errorf("error from apply yamls: %w", runErr))
+	}
+	if len(errs) == 1 {
+		// Return the error directly, so callers can inspect it with errors.As (eg for an applyset.PruneBlockedError)
+		return results, errs[0]
+	}
+	return results, utilerrors.NewAggregate(errs)
+}
+
+// resultRecorder captures the objects that kubectl prints, in place of the kubectl printer.
+type resultRecorder struct {
+	ctx     context.Context
+	results *applyset.ApplyResults
+	// warnings, if set, records the warnings returned by the apiserver since the last object was printed
+	warnings *applyset.WarningRecorder
+
+	// applied is the set of objects that were applied, keyed by resultKey
+	applied map[string]bool
+}
+
+// printerFor returns the printer kubectl uses to report that objects were created, configured, pruned etc.
+func (r *resultRecorder) printerFor(operation string) printers.ResourcePrinter {
+	return printers.ResourcePrinterFunc(func(obj runtime.Object, w io.Writer) error {
+		return r.record(operation, obj)
+	})
+}
+
+func (r *resultRecorder) record(operation string, obj runtime.Object) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return fmt.Errorf("unable to record result for %T: %w", obj, err)
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	nn := types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}
+
+	log := log.FromContext(r.ctx).WithValues("object", nn, "gvk", gvk, "operation", operation)
+	if operation == applyset.OperationUnchanged {
+		log.V(2).Info("applied object")
+	} else {
+		log.Info("applied object")
+	}
+
+	// kubectl applies the objects one at a time, so the warnings are from applying (or pruning) this object
+	warnings := r.warnings.Take()
+	for _, warning := range warnings {
+		log.Info("warning from apiserver", "warning", warning)
+	}
+
+	if operation == applyset.OperationPruned {
+		r.results.RecordPruned(gvk, nn)
+		return nil
+	}
+
+	r.applied[resultKey(gvk.GroupKind().String(), nn)] = true
+	u, _ := obj.(*unstructured.Unstructured)
+	r.results.RecordApplied(gvk, nn, operation, u)
+	if len(warnings) != 0 {
+		r.results.RecordWarnings(gvk, nn, warnings)
+	}
+	return nil
+}
+
+func resultKey(groupKind string, nn types.NamespacedName) string {
+	return groupKind + " " + nn.String()
+}
+
+func infoKey(info *resource.Info) string {
+	gvk := info.Object.GetObjectKind().GroupVersionKind()
+	return resultKey(gvk.GroupKind().String(), types.NamespacedName{Namespace: info.Namespace, Name: info.Name})
+}
+
+// objectErrors returns the errors from a kubectl apply of infos for the objects that were not applied, keyed by infoKey.
+// kubectl applies the objects in order, returning one error for each object that fails, so the errors are matched
+// to the objects that were not applied by position; if the numbers differ, each object is given the whole error.
+func (r *resultRecorder) objectErrors(infos []*resource.Info, err error) map[string]error {
+	errs := []error{err}
+	if agg, ok := err.(utilerrors.Aggregate); ok {
+		errs = agg.Errors()
+	}
+
+	var failed []*resource.Info
+	for _, info := range infos {
+		if !r.applied[infoKey(info)] {
+			failed = append(failed, info)
+		}
+	}
+
+	objectErrs := make(map[string]error)
+	for i, info := range failed {
+		if len(errs) == len(failed) {
+			objectErrs[infoKey(info)] = errs[i]
+		} else {
+			objectErrs[infoKey(info)] = err
+		}
+	}
+	return objectErrs
+}
+
+// logOutput logs any output kubectl wrote directly to its streams, eg warnings
+func logOutput(ctx context.Context, stream string, b *bytes.Buffer) {
+	scanner := bufio.NewScanner(b)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			log.FromContext(ctx).WithValues("stream", stream).Info(line)
+		}
 	}
-	return utilerrors.NewAggregate(errs)
 }
 
 // staticRESTClientGetter returns a fixed RESTClient
@@ -21,12 +21,20 @@ package applier
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/rest/fake"
 	"k8s.io/client-go/restmapper"
@@ -34,16 +42,23 @@ import (
 	kubectltesting "k8s.io/kubectl/pkg/cmd/testing"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/applyset"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
 )
 
 type directApplierTestSite struct {
 	Error     error
 	applyOpts *apply.ApplyOptions
+
+	// run, if set, simulates kubectl apply
+	run func(a *apply.ApplyOptions) error
 }
 
 func (d *directApplierTestSite) Run(a *apply.ApplyOptions) error {
 	d.applyOpts = a
+	if d.run != nil {
+		return d.run(a)
+	}
 	return nil
 }
 
@@ -75,6 +90,7 @@ func TestDirectApply(t *testing.T) {
 		namespace          string
 		manifest           string
 		validate           bool
+		fieldValidation    string
 		args               []string
 		err                error
 		expectApplyOptions *apply.ApplyOptions
@@ -114,28 +130,41 @@ metadata:
 				}
 			},
 		},
-		// This test use fake test factory on "k8s.io/kubectl/pkg/cmd/testing", that's why this test for validate is commented out.
-		// opt.Validator is always set to validation.NullSchema instance by fake test factory, so we can't check if validator is set successfully.
-		//		{
-		//			name:      "manifest with validate",
-		//			namespace: "",
-		//			manifest: `---
-		//apiVersion: v1
-		//kind: ServiceAccount
-		//metadata:
-		//  name: foo-operator
-		//  namespace: kube-system`,
-		//			validate: true,
-		//			args:     []string{},
-		//			expectCheckFunc: func(opt *apply.ApplyOptions) error {
-		//				if opt.Validator != nil {
-		//                  // success pattern, validator is set.
-		//					return nil
-		//				} else {
-		//					return fmt.Errorf("validator is not nil")
-		//				}
-		//			},
-		//		},
+		{
+			name:      "manifest with validate",
+			namespace: "",
+			manifest: `---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: foo-operator
+  namespace: kube-system`,
+			validate: true,
+			expectCheckFunc: func(opt *apply.ApplyOptions) error {
+				if opt.ValidationDirective != metav1.FieldValidationStrict {
+					return fmt.Errorf("unexpected validation directive %q", opt.ValidationDirective)
+				}
+				return nil
+			},
+		},
+		{
+			name:      "manifest with field validation",
+			namespace: "",
+			manifest: `---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: foo-operator
+  namespace: kube-system`,
+			validate:        true,
+			fieldValidation: metav1.FieldValidationWarn,
+			expectCheckFunc: func(opt *apply.ApplyOptions) error {
+				if opt.ValidationDirective != metav1.FieldValidationWarn {
+					return fmt.Errorf("unexpected validation directive %q", opt.ValidationDirective)
+				}
+				return nil
+			},
+		},
 		{
 			name:      "manifest with prune",
 			namespace: "",
@@ -198,10 +227,11 @@ metadata:
 			}
 
 			opts := ApplierOptions{
-				Namespace: test.namespace,
-				Objects:   objects.GetItems(),
-				Validate:  test.validate,
-				ExtraArgs: test.args,
+				Namespace:       test.namespace,
+				Objects:         objects.GetItems(),
+				Validate:        test.validate,
+				FieldValidation: test.fieldValidation,
+				ExtraArgs:       test.args,
 			}
 
 			if err := testApplier.Apply(ctx, opts); err != nil {
@@ -224,3 +254,255 @@ func TestDirectApplier(t *testing.T) {
 	applier := NewDirectApplier()
 	runApplierGoldenTests(t, "testdata/direct", false, applier)
 }
+
+func TestDirectApplyResults(t *testing.T) {
+	ctx := context.TODO()
+
+	objects, err := manifest.ParseObjects(ctx, `---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: foo-operator
+  namespace: kube-system
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo-config
+  namespace: kube-system
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo-service
+  namespace: kube-system`)
+	if err != nil {
+		t.Fatalf("error parsing manifest: %v", err)
+	}
+
+	pruned := &unstructured.Unstructured{}
+	pruned.SetAPIVersion("v1")
+	pruned.SetKind("ConfigMap")
+	pruned.SetNamespace("kube-system")
+	pruned.SetName("old-config")
+
+	d := &directApplierTestSite{
+		run: func(a *apply.ApplyOptions) error {
+			// Simulate kubectl, which prints each object it applies or prunes
+			infos, err := a.GetObjects()
+			if err != nil {
+				return err
+			}
+			operations := map[string]string{
+				"foo-operator": "created",
+				"foo-config":   "unchanged",
+			}
+			for _, info := range infos {
+				operation, found := operations[info.Name]
+				if !found {
+					continue
+				}
+				printer, err := a.ToPrinter(operation)
+				if err != nil {
+					return err
+				}
+				if err := printer.PrintObj(info.Object, a.Out); err != nil {
+					return err
+				}
+			}
+			printer, err := a.ToPrinter("pruned")
+			if err != nil {
+				return err
+			}
+			if err := printer.PrintObj(pruned, a.Out); err != nil {
+				return err
+			}
+			return utilerrors.NewAggregate([]error{fmt.Errorf(`error when patching "foo-service": forbidden`)})
+		},
+	}
+	testApplier := &DirectApplier{inner: d}
+
+	results, err := testApplier.ApplyWithResults(ctx, ApplierOptions{Objects: objects.GetItems()})
+	if err == nil {
+		t.Fatalf("expected error from ApplyWithResults")
+	}
+	if results == nil {
+		t.Fatalf("expected results along with the error")
+	}
+	if results.AllApplied() {
+		t.Errorf("expected AllApplied to be false")
+	}
+
+	got := make(map[string]string)
+	for _, obj := range results.Objects {
+		result := obj.Apply.Operation
+		if obj.Apply.Error != nil {
+			result = "error: " + obj.Apply.Error.Error()
+		}
+		got[obj.GVK.Kind+" "+obj.NameNamespace.String()] = result
+	}
+	want := map[string]string{
+		"ServiceAccount kube-system/foo-operator": "created",
+		"ConfigMap kube-system/foo-config":        "unchanged",
+		"ConfigMap kube-system/old-config":        "pruned",
+		"Service kube-system/foo-service":         `error: error when patching "foo-service": forbidden`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results (-want +got):\n%s", diff)
+	}
+}
+
+func TestDirectApplyRetries(t *testing.T) {
+	ctx := context.TODO()
+
+	objects, err := manifest.ParseObjects(ctx, `---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: foo-operator
+  namespace: kube-system
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo-config
+  namespace: kube-system`)
+	if err != nil {
+		t.Fatalf("error parsing manifest: %v", err)
+	}
+
+	var applied [][]string
+	d := &directApplierTestSite{
+		run: func(a *apply.ApplyOptions) error {
+			// Simulate kubectl, where the apply of foo-config is throttled the first time
+			infos, err := a.GetObjects()
+			if err != nil {
+				return err
+			}
+			var names []string
+			for _, info := range infos {
+				names = append(names, info.Name)
+				if info.Name == "foo-config" && len(applied) == 0 {
+					continue
+				}
+				printer, err := a.ToPrinter("created")
+				if err != nil {
+					return err
+				}
+				if err := printer.PrintObj(info.Object, a.Out); err != nil {
+					return err
+				}
+			}
+			applied = append(applied, names)
+			if len(applied) == 1 {
+				return apierrors.NewTooManyRequests("slow down", 0)
+			}
+			return nil
+		},
+	}
+	testApplier := &DirectApplier{inner: d}
+
+	results, err := testApplier.ApplyWithResults(ctx, ApplierOptions{
+		Objects:     objects.GetItems(),
+		RetryPolicy: applyset.RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error from ApplyWithResults: %v", err)
+	}
+	if !results.AllApplied() {
+		t.Errorf("expected AllApplied to be true")
+	}
+	if got, want := results.RetryCount(), 1; got != want {
+		t.Errorf("unexpected retry count, got %d, want %d", got, want)
+	}
+	want := [][]string{{"foo-operator", "foo-config"}, {"foo-config"}}
+	if diff := cmp.Diff(want, applied); diff != "" {
+		t.Errorf("unexpected objects applied by each run (-want +got):\n%s", diff)
+	}
+}
+
+func TestDirectApplyObjectErrors(t *testing.T) {
+	ctx := context.TODO()
+
+	objects, err := manifest.ParseObjects(ctx, `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+  namespace: kube-system
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: bar
+  namespace: kube-system
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+  namespace: kube-system`)
+	if err != nil {
+		t.Fatalf("error parsing manifest: %v", err)
+	}
+
+	runs := 0
+	d := &directApplierTestSite{
+		run: func(a *apply.ApplyOptions) error {
+			// Simulate kubectl, where the objects named foo fail with errors that both mention "foo"
+			runs++
+			infos, err := a.GetObjects()
+			if err != nil {
+				return err
+			}
+			var errs []error
+			for _, info := range infos {
+				switch info.Object.GetObjectKind().GroupVersionKind().Kind {
+				case "ConfigMap":
+					errs = append(errs, fmt.Errorf("error when patching %q: %w", info.Name, apierrors.NewTooManyRequests("slow down", 0)))
+				case "Service":
+					errs = append(errs, fmt.Errorf("error when patching %q: %w", info.Name, apierrors.NewForbidden(schema.GroupResource{Resource: "services"}, info.Name, errors.New("denied"))))
+				default:
+					printer, err := a.ToPrinter("created")
+					if err != nil {
+						return err
+					}
+					if err := printer.PrintObj(info.Object, a.Out); err != nil {
+						return err
+					}
+				}
+			}
+			return utilerrors.NewAggregate(errs)
+		},
+	}
+	testApplier := &DirectApplier{inner: d}
+
+	results, err := testApplier.ApplyWithResults(ctx, ApplierOptions{
+		Objects:     objects.GetItems(),
+		RetryPolicy: applyset.RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+	})
+	if err == nil {
+		t.Fatalf("expected error from ApplyWithResults")
+	}
+	// The forbidden error is not retryable, so the throttled ConfigMap is not retried either
+	if runs != 1 {
+		t.Errorf("expected kubectl to run once, got %d", runs)
+	}
+
+	got := make(map[string]string)
+	for _, obj := range results.Objects {
+		result := obj.Apply.Operation
+		if obj.Apply.Error != nil {
+			result = "error: " + obj.Apply.Error.Error()
+		}
+		got[obj.GVK.Kind+" "+obj.NameNamespace.String()] = result
+	}
+	want := map[string]string{
+		"ConfigMap kube-system/foo":      `error: error when patching "foo": slow down`,
+		"ServiceAccount kube-system/bar": "created",
+		"Service kube-system/foo":        `error: error when patching "foo": services "foo" is forbidden: denied`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results (-want +got):\n%s", diff)
+	}
+}
@@ -22,17 +22,27 @@ package applier
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
-
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/klog/v2"
+	"k8s.io/kubectl/pkg/util/prune"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/applyset"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
 )
 
@@ -44,9 +54,38 @@ func NewExec() *ExecKubectl {
 // ExecKubectl provides an interface to kubectl
 type ExecKubectl struct {
 	cmdSite commandSite
+
+	// applySetPreferred makes us use server-side apply and kubectl's native applysets, if kubectl supports them
+	applySetPreferred bool
+	// applySetPruneWithoutGuards lets kubectl prune the applyset itself, without the PrunePolicy
+	applySetPruneWithoutGuards bool
+
+	// mutex guards version
+	mutex sync.Mutex
+	// version is the version of the kubectl binary, once detected
+	version *version.Version
 }
 
-var _ Applier = &ExecKubectl{}
+var _ ApplierWithResults = &ExecKubectl{}
+
+// minApplySetVersion is the first version of kubectl that supports --applyset, enabled with KUBECTL_APPLYSET=true
+var minApplySetVersion = version.MustParseGeneric("v1.27.0")
+
+// UseApplySet makes ExecKubectl apply the objects with --server-side, if the kubectl binary supports applysets (v1.27 or later).
+// When pruning, we fall back to client-side apply and prune the objects ourselves, so that the PrunePolicy is enforced,
+// unless UseApplySetPruneWithoutGuards is also called.
+func (c *ExecKubectl) UseApplySet() {
+	c.applySetPreferred = true
+}
+
+// UseApplySetPruneWithoutGuards makes ExecKubectl prune with kubectl's native applysets (--applyset=<parent>)
+// rather than a label selector, when UseApplySet is used. The ParentRef is the applyset parent, so the applyset
+// can be shared with the ApplySetApplier if its Tooling is kubectl.
+// kubectl prunes the applyset itself, so the PrunePolicy is not enforced: objects annotated with
+// addons.k8s.io/prune=disabled, CustomResourceDefinitions and Namespaces can be pruned, and mass deletion is not blocked.
+func (c *ExecKubectl) UseApplySetPruneWithoutGuards() {
+	c.applySetPruneWithoutGuards = true
+}
 
 // commandSite allows for tests to mock cmd.Run() events
 type commandSite interface {
@@ -94,12 +133,18 @@ func buildKubeconfig(restConfig *rest.Config) ([]byte, error) {
 
 // Apply runs the kubectl apply with the provided manifest argument
 func (c *ExecKubectl) Apply(ctx context.Context, opt ApplierOptions) error {
+	_, err := c.ApplyWithResults(ctx, opt)
+	return err
+}
+
+// ApplyWithResults runs kubectl apply like Apply, parsing the output of kubectl into the per-object results.
+func (c *ExecKubectl) ApplyWithResults(ctx context.Context, opt ApplierOptions) (*applyset.ApplyResults, error) {
 	log := log.FromContext(ctx)
 
 	objects := manifest.Objects{Items: opt.Objects}
 	manifestStr, err := objects.JSONManifest()
 	if err != nil {
-		return fmt.Errorf("error creating JSON manifest: %w", err)
+		return nil, fmt.Errorf("error creating JSON manifest: %w", err)
 	}
 
 	log.Info("applying manifest")
@@ -111,17 +156,21 @@ func (c *ExecKubectl) Apply(ctx context.Context, opt ApplierOptions) error {
 
 	// Not doing --validate avoids downloading the OpenAPI
 	// which can save a lot work & memory
-	args = append(args, "--validate="+strconv.FormatBool(opt.Validate))
+	if opt.FieldValidation != "" {
+		args = append(args, "--validate="+strings.ToLower(opt.FieldValidation))
+	} else {
+		args = append(args, "--validate="+strconv.FormatBool(opt.Validate))
+	}
 
 	if opt.RESTConfig != nil {
 		kubeconfig, err := buildKubeconfig(opt.RESTConfig)
 		if err != nil {
-			return fmt.Errorf("error building kubeconfig: %w", err)
+			return nil, fmt.Errorf("error building kubeconfig: %w", err)
 		}
 
 		f, err := os.CreateTemp("", "kubeconfig")
 		if err != nil {
-			return fmt.Errorf("error creating temp file: %w", err)
+			return nil, fmt.Errorf("error creating temp file: %w", err)
 		}
 
 		defer func() {
@@ -131,27 +180,60 @@ func (c *ExecKubectl) Apply(ctx context.Context, opt ApplierOptions) error {
 		}()
 
 		if _, err := f.Write(kubeconfig); err != nil {
-			return fmt.Errorf("error writing kubeconfig: %w", err)
+			return nil, fmt.Errorf("error writing kubeconfig: %w", err)
 		}
 
 		if err := f.Close(); err != nil {
-			return fmt.Errorf("error writing kubeconfig: %w", err)
+			return nil, fmt.Errorf("error writing kubeconfig: %w", err)
 		}
 
 		args = append(args, "--kubeconfig", f.Name())
 	}
 
-	if opt.Force {
-		args = append(args, "--force")
+	force, err := forceConflicts(opt)
+	if err != nil {
+		return nil, err
 	}
 
-	args = append(args, opt.ExtraArgs...)
-	args = append(args, "-f", "-")
+	pruneArgs, extraArgs, err := parsePruneArgs(opt.ExtraArgs)
+	if err != nil {
+		return nil, err
+	}
 
-	log.Info("applying manifest with kubectl %s", strings.Join(args, " "))
+	useApplySet := c.applySetPreferred && c.supportsApplySet(ctx, opt, pruneArgs)
+
+	var env []string
+	var pruner *kubectlPruner
+	if useApplySet {
+		args = append(args, "--server-side")
+		if force {
+			args = append(args, "--force-conflicts")
+		}
+		if pruneArgs.prune {
+			// kubectl prunes the members of the applyset; the selector and allowlist are incompatible with --applyset
+			args = append(args, "--prune", "--applyset="+applySetParentFlag(opt.ParentRef))
+			extraArgs = removeSelectorArgs(extraArgs)
+			env = append(os.Environ(), "KUBECTL_APPLYSET=true")
+		}
+	} else {
+		if force {
+			args = append(args, "--force")
+		}
+		// We prune ourselves after kubectl has applied the objects, so that we can apply the PrunePolicy
+		if pruneArgs.prune {
+			pruner, err = newExecPruner(opt, pruneArgs)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	args = append(args, extraArgs...)
+	args = append(args, "-f", "-")
 
 	cmd := exec.Command("kubectl", args...)
 	cmd.Stdin = strings.NewReader(manifestStr)
+	cmd.Env = env
 
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
@@ -160,13 +242,245 @@ func (c *ExecKubectl) Apply(ctx context.Context, opt ApplierOptions) error {
 
 	log.WithValues("command", "kubectl").WithValues("args", args).Info("executing kubectl")
 
-	if err := c.cmdSite.Run(cmd); err != nil {
-		log.WithValues("stdout", stdout.String()).WithValues("stderr", stderr.String()).Error(err, "error from running kubectl apply")
+	runErr := c.cmdSite.Run(cmd)
+	if runErr != nil {
+		log.WithValues("stdout", stdout.String()).WithValues("stderr", stderr.String()).Error(runErr, "error from running kubectl apply")
 		log.Info(fmt.Sprintf("manifest:\n%v", manifestStr))
-		return fmt.Errorf("error from running kubectl apply: %v", err)
+	} else {
+		log.WithValues("stdout", stdout.String()).WithValues("stderr", stderr.String()).V(2).Info("ran kubectl apply")
+	}
+
+	results := recordKubectlResults(ctx, opt, parseApplyOutput(stdout.String()), stderr.String(), runErr)
+	if runErr != nil {
+		return results, fmt.Errorf("error from running kubectl apply: %v", runErr)
+	}
+
+	if pruner != nil {
+		if err := pruner.prune(ctx, results); err != nil {
+			return results, fmt.Errorf("error pruning objects: %w", err)
+		}
+	}
+
+	return results, nil
+}
+
+// supportsApplySet returns true if we can apply with server-side apply and kubectl's native applysets.
+// If we are pruning, kubectl must be allowed to prune without the PrunePolicy, and we need an applyset parent
+// in the namespace of the apply, because kubectl looks for the parent there.
+func (c *ExecKubectl) supportsApplySet(ctx context.Context, opt ApplierOptions, pruneArgs pruneArgs) bool {
+	log := log.FromContext(ctx)
+
+	v, err := c.kubectlVersion()
+	if err != nil {
+		log.Error(err, "unable to detect kubectl version, using client-side apply")
+		return false
+	}
+	if !v.AtLeast(minApplySetVersion) {
+		log.Info("kubectl does not support --applyset, using client-side apply", "version", v.String(), "minVersion", minApplySetVersion.String())
+		return false
+	}
+
+	if pruneArgs.prune {
+		if !c.applySetPruneWithoutGuards {
+			log.Info("kubectl does not enforce the PrunePolicy when pruning an applyset, using client-side apply")
+			return false
+		}
+		if opt.ParentRef == nil {
+			log.Info("no applyset parent, using client-side apply")
+			return false
+		}
+		if opt.ParentRef.RESTMapping().Scope.Name() == meta.RESTScopeNameNamespace && opt.ParentRef.Namespace() != opt.Namespace {
+			log.Info("applyset parent is not in the namespace of the apply, using client-side apply", "parentNamespace", opt.ParentRef.Namespace(), "namespace", opt.Namespace)
+			return false
+		}
 	}
+	return true
+}
+
+// kubectlVersion returns the client version of the kubectl binary, running kubectl version the first time.
+func (c *ExecKubectl) kubectlVersion() (*version.Version, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
-	log.WithValues("stdout", stdout.String()).WithValues("stderr", stderr.String()).V(2).Info("ran kubectl apply")
+	if c.version != nil {
+		return c.version, nil
+	}
+
+	cmd := exec.Command("kubectl", "version", "--client", "--output=json")
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := c.cmdSite.Run(cmd); err != nil {
+		return nil, fmt.Errorf("error from running kubectl version: %w (stderr %q)", err, stderr.String())
+	}
+
+	v, err := parseKubectlVersion(stdout.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	c.version = v
+	return v, nil
+}
+
+// parseKubectlVersion parses the output of kubectl version --client --output=json
+func parseKubectlVersion(b []byte) (*version.Version, error) {
+	var info struct {
+		ClientVersion struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"clientVersion"`
+	}
+	if err := json.Unmarshal(b, &info); err != nil {
+		return nil, fmt.Errorf("error parsing kubectl version: %w", err)
+	}
+	v, err := version.ParseGeneric(info.ClientVersion.GitVersion)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing kubectl version %q: %w", info.ClientVersion.GitVersion, err)
+	}
+	return v, nil
+}
+
+// applySetParentFlag formats the parent for the --applyset flag, as <resource>.<group>/<name>
+func applySetParentFlag(parent applyset.Parent) string {
+	return parent.RESTMapping().Resource.GroupResource().String() + "/" + parent.Name()
+}
+
+// removeSelectorArgs removes the --selector argument, which is incompatible with --applyset
+func removeSelectorArgs(args []string) []string {
+	var remaining []string
+	for i := 0; i < len(args); i++ {
+		name, _, hasValue := strings.Cut(args[i], "=")
+		if name == "--selector" || name == "-l" {
+			if !hasValue {
+				i++
+			}
+			continue
+		}
+		remaining = append(remaining, args[i])
+	}
+	return remaining
+}
+
+// pruneArgs are the kubectl arguments that control pruning
+type pruneArgs struct {
+	prune     bool
+	selector  string
+	allowlist []string
+}
+
+// parsePruneArgs extracts the prune arguments from the kubectl arguments, returning the remaining arguments.
+// The selector is also kept in the remaining arguments, because it also selects the objects to apply.
+func parsePruneArgs(args []string) (pruneArgs, []string, error) {
+	var parsed pruneArgs
+	var remaining []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+
+		// nextValue returns the value of a flag, which is either --flag=value or --flag value
+		nextValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i == len(args)-1 {
+				return "", fmt.Errorf("missing value for %s in args %q", name, args)
+			}
+			i++
+			return args[i], nil
+		}
+
+		switch name {
+		case "--prune":
+			if !hasValue {
+				parsed.prune = true
+				continue
+			}
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return parsed, nil, fmt.Errorf("invalid value for --prune in args %q", args)
+			}
+			parsed.prune = b
+
+		case "--prune-whitelist", "--prune-allowlist":
+			v, err := nextValue()
+			if err != nil {
+				return parsed, nil, err
+			}
+			parsed.allowlist = append(parsed.allowlist, v)
+
+		case "--selector", "-l":
+			v, err := nextValue()
+			if err != nil {
+				return parsed, nil, err
+			}
+			parsed.selector = v
+			if hasValue {
+				remaining = append(remaining, arg)
+			} else {
+				remaining = append(remaining, arg, v)
+			}
+
+		default:
+			remaining = append(remaining, arg)
+		}
+	}
+	return parsed, remaining, nil
+}
+
+// newExecPruner builds the pruner for the objects applied by kubectl
+func newExecPruner(opt ApplierOptions, args pruneArgs) (*kubectlPruner, error) {
+	if opt.RESTMapper == nil {
+		return nil, fmt.Errorf("pruning requires a RESTMapper")
+	}
+	dynamicClient := opt.DynamicClient
+	if dynamicClient == nil {
+		if opt.RESTConfig == nil {
+			return nil, fmt.Errorf("pruning requires a DynamicClient or RESTConfig")
+		}
+		dc, err := dynamic.NewForConfig(opt.RESTConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error building dynamic client: %w", err)
+		}
+		dynamicClient = dc
+	}
+
+	pruneResources, err := prune.ParseResources(opt.RESTMapper, args.allowlist)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := sets.New[string]()
+	applied := sets.New[string]()
+	for _, obj := range opt.Objects {
+		gvk := obj.GroupVersionKind()
+		mapping, err := opt.RESTMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, fmt.Errorf("error getting rest mapping for %v: %w", gvk, err)
+		}
+		namespace := ""
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			namespace = obj.GetNamespace()
+			if namespace == "" {
+				namespace = opt.Namespace
+			}
+			namespaces.Insert(namespace)
+		}
+		applied.Insert(resultKey(gvk.GroupKind().String(), types.NamespacedName{Namespace: namespace, Name: obj.GetName()}))
+	}
 
-	return nil
+	return &kubectlPruner{
+		dynamicClient:      dynamicClient,
+		restMapper:         opt.RESTMapper,
+		pruneResources:     pruneResources,
+		namespaceSpecified: opt.Namespace != "",
+		namespaces:         namespaces,
+		selector:           args.selector,
+		isApplied: func(obj *unstructured.Unstructured) bool {
+			nn := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+			return applied.Has(resultKey(obj.GroupVersionKind().GroupKind().String(), nn))
+		},
+		appliedCount:      len(opt.Objects),
+		cascadingStrategy: opt.CascadingStrategy,
+		policy:            prunePolicyFor(opt),
+	}, nil
 }
@@ -0,0 +1,160 @@
+//go:build !without_exec_applier
+// +build !without_exec_applier
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applier
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/applyset"
+)
+
+// kubectlResult is an object reported in the output of kubectl apply, eg "deployment.apps/foo created"
+type kubectlResult struct {
+	// kind is the lowercase kind and group of the object, as printed by kubectl, eg "deployment.apps"
+	kind      string
+	name      string
+	operation string
+}
+
+// applyOutputRE matches the lines that kubectl apply prints for each object, ignoring suffixes like "(server dry run)"
+var applyOutputRE = regexp.MustCompile(`^(\S+)/(\S+) (created|configured|unchanged|serverside-applied|pruned)\b`)
+
+// parseApplyOutput parses the objects reported in the output of kubectl apply, ignoring any other lines.
+func parseApplyOutput(stdout string) []kubectlResult {
+	var parsed []kubectlResult
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		match := applyOutputRE.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+		parsed = append(parsed, kubectlResult{kind: match[1], name: match[2], operation: match[3]})
+	}
+	return parsed
+}
+
+// recordKubectlResults builds the per-object results from the output of kubectl apply.
+// Objects that kubectl did not report were applied if kubectl succeeded; otherwise we record the error
+// that kubectl printed for the object, or else runErr.
+func recordKubectlResults(ctx context.Context, opt ApplierOptions, output []kubectlResult, stderr string, runErr error) *applyset.ApplyResults {
+	log := log.FromContext(ctx)
+
+	results := applyset.NewApplyResults(len(opt.Objects))
+
+	// kubectl applies the objects in order, so we match each object to the first unmatched line with its kind and name
+	matched := make([]bool, len(output))
+	for _, obj := range opt.Objects {
+		gvk := obj.GroupVersionKind()
+		nn := objectNamespacedName(opt, obj)
+
+		operation, found := "", false
+		for i, result := range output {
+			if matched[i] || result.operation == applyset.OperationPruned {
+				continue
+			}
+			if result.kind == printedKind(gvk.GroupKind()) && result.name == obj.GetName() {
+				matched[i] = true
+				operation, found = result.operation, true
+				break
+			}
+		}
+
+		switch {
+		case found:
+			results.RecordApplied(gvk, nn, operation, nil)
+		case runErr == nil:
+			// kubectl succeeded, but we could not find the object in its output
+			results.RecordApplied(gvk, nn, "", nil)
+		default:
+			err := kubectlObjectError(stderr, gvk.Kind, obj.GetName(), runErr)
+			log.WithValues("object", nn, "gvk", gvk).Error(err, "error applying object")
+			results.RecordApplyError(gvk, nn, err)
+		}
+	}
+
+	for _, result := range output {
+		if result.operation != applyset.OperationPruned {
+			continue
+		}
+		gvk, nn := prunedObject(opt, result)
+		log.WithValues("object", nn, "gvk", gvk).Info("pruned object")
+		results.RecordPruned(gvk, nn)
+	}
+
+	return results
+}
+
+// printedKind formats a GroupKind the way kubectl prints it, eg "deployment.apps"
+func printedKind(gk schema.GroupKind) string {
+	return strings.ToLower(gk.String())
+}
+
+// prunedObject maps an object that kubectl reports as pruned to its kind, using the RESTMapper.
+// kubectl does not print the namespace, so we assume namespaced objects were in the namespace of the apply.
+func prunedObject(opt ApplierOptions, result kubectlResult) (schema.GroupVersionKind, types.NamespacedName) {
+	// kubectl prints the lowercase kind, which is also the singular resource name
+	resource, group, _ := strings.Cut(result.kind, ".")
+	gvk := schema.GroupVersionKind{Group: group, Kind: resource}
+	nn := types.NamespacedName{Name: result.name}
+	if opt.RESTMapper == nil {
+		return gvk, nn
+	}
+	resolved, err := opt.RESTMapper.KindFor(schema.GroupVersionResource{Group: group, Resource: resource})
+	if err != nil {
+		return gvk, nn
+	}
+	if mapping, err := opt.RESTMapper.RESTMapping(resolved.GroupKind(), resolved.Version); err == nil && mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		nn.Namespace = opt.Namespace
+	}
+	return resolved, nn
+}
+
+// kubectlObjectError returns the error that kubectl printed for the object, eg
+// `Error from server (Invalid): error when creating "STDIN": ConfigMap "foo" is invalid: ...`, or else err.
+func kubectlObjectError(stderr string, kind, name string, err error) error {
+	quoted := strconv.Quote(name)
+	var candidate string
+	scanner := bufio.NewScanner(strings.NewReader(stderr))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.Contains(line, quoted) {
+			continue
+		}
+		if strings.Contains(line, kind+" "+quoted) {
+			return errors.New(line)
+		}
+		if candidate == "" {
+			candidate = line
+		}
+	}
+	if candidate != "" {
+		return errors.New(candidate)
+	}
+	return err
+}
@@ -24,13 +24,26 @@ import (
 	"errors"
 	"io"
 	"os/exec"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/klog/v2/klogr"
+	"k8s.io/kubectl/pkg/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/applyset"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
 )
 
@@ -59,6 +72,7 @@ metadata:
 		namespace   string
 		manifest    string
 		validate    bool
+		validation  string
 		args        []string
 		err         error
 		expectStdin string
@@ -86,18 +100,28 @@ metadata:
 			validate:    true,
 			expectArgs:  []string{"kubectl", "apply", "--validate=true", "-f", "-"},
 		},
+		{
+			name:        "manifest with field validation",
+			namespace:   "",
+			manifest:    configMapYAML,
+			expectStdin: configMapJSON,
+			validate:    true,
+			validation:  metav1.FieldValidationWarn,
+			expectArgs:  []string{"kubectl", "apply", "--validate=warn", "-f", "-"},
+		},
 		{
 			name:       "error propagation",
 			expectArgs: []string{"kubectl", "apply", "--validate=false", "-f", "-"},
 			err:        errors.New("error"),
 		},
 		{
+			// We prune after kubectl apply, to apply the PrunePolicy, so the prune arguments are not passed to kubectl
 			name:        "manifest with prune",
 			namespace:   "kube-system",
 			manifest:    configMapYAML,
 			expectStdin: configMapJSON,
-			args:        []string{"--prune=true", "--prune-whitelist=hello-world"},
-			expectArgs:  []string{"kubectl", "apply", "-n", "kube-system", "--validate=false", "--prune=true", "--prune-whitelist=hello-world", "-f", "-"},
+			args:        []string{"--prune=true", "--prune-whitelist=core/v1/ConfigMap", "--selector", "app=foo"},
+			expectArgs:  []string{"kubectl", "apply", "-n", "kube-system", "--validate=false", "--selector", "app=foo", "-f", "-"},
 		},
 	}
 
@@ -114,10 +138,13 @@ metadata:
 			}
 
 			opts := ApplierOptions{
-				Namespace: test.namespace,
-				Objects:   objects.GetItems(),
-				Validate:  test.validate,
-				ExtraArgs: test.args,
+				Namespace:       test.namespace,
+				Objects:         objects.GetItems(),
+				Validate:        test.validate,
+				FieldValidation: test.validation,
+				ExtraArgs:       test.args,
+				RESTMapper:      testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme),
+				DynamicClient:   dynamicfake.NewSimpleDynamicClient(scheme.Scheme),
 			}
 			err = kubectl.Apply(ctx, opts)
 
@@ -148,6 +175,140 @@ metadata:
 	}
 }
 
+func TestKubectlApplyPrunePolicy(t *testing.T) {
+	desiredYAML := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+  namespace: kube-system
+`
+
+	liveConfigMap := func(name string, annotations map[string]string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("ConfigMap")
+		u.SetNamespace("kube-system")
+		u.SetName(name)
+		u.SetUID(types.UID("uid-" + name))
+		all := map[string]string{corev1.LastAppliedConfigAnnotation: "{}"}
+		for k, v := range annotations {
+			all[k] = v
+		}
+		u.SetAnnotations(all)
+		return u
+	}
+
+	tests := []struct {
+		name        string
+		live        []*unstructured.Unstructured
+		policy      applyset.PrunePolicy
+		expectError string
+		expectLive  []string
+	}{
+		{
+			name: "prunes objects that are no longer applied",
+			live: []*unstructured.Unstructured{
+				liveConfigMap("foo", nil),
+				liveConfigMap("old", nil),
+			},
+			expectLive: []string{"foo"},
+		},
+		{
+			name: "keeps objects annotated with prune disabled",
+			live: []*unstructured.Unstructured{
+				liveConfigMap("foo", nil),
+				liveConfigMap("old", nil),
+				liveConfigMap("keep", map[string]string{applyset.PruneAnnotation: applyset.PruneDisabled}),
+			},
+			expectLive: []string{"foo", "keep"},
+		},
+		{
+			name: "blocks mass deletion",
+			live: []*unstructured.Unstructured{
+				liveConfigMap("foo", nil),
+				liveConfigMap("old1", nil),
+				liveConfigMap("old2", nil),
+			},
+			policy:      applyset.PrunePolicy{MaxPruneFraction: 0.5},
+			expectError: "refusing to prune 2 of 3 tracked objects",
+			expectLive:  []string{"foo", "old1", "old2"},
+		},
+		{
+			name: "allows mass deletion without a limit",
+			live: []*unstructured.Unstructured{
+				liveConfigMap("foo", nil),
+				liveConfigMap("old1", nil),
+				liveConfigMap("old2", nil),
+			},
+			expectLive: []string{"foo"},
+		},
+		{
+			name: "allows mass deletion with override",
+			live: []*unstructured.Unstructured{
+				liveConfigMap("foo", nil),
+				liveConfigMap("old1", nil),
+				liveConfigMap("old2", nil),
+			},
+			policy:     applyset.PrunePolicy{MaxPruneFraction: 0.5, AllowMassPrune: true},
+			expectLive: []string{"foo"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.TODO()
+
+			var live []runtime.Object
+			for _, obj := range test.live {
+				live = append(live, obj)
+			}
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(scheme.Scheme, live...)
+
+			objects, err := manifest.ParseObjects(ctx, desiredYAML)
+			if err != nil {
+				t.Fatalf("error parsing manifest: %v", err)
+			}
+
+			cs := collector{}
+			kubectl := &ExecKubectl{cmdSite: &cs}
+			opts := ApplierOptions{
+				Objects:       objects.GetItems(),
+				ExtraArgs:     []string{"--prune", "--prune-allowlist", "core/v1/ConfigMap"},
+				RESTMapper:    testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme),
+				DynamicClient: dynamicClient,
+				PrunePolicy:   test.policy,
+			}
+			err = kubectl.Apply(ctx, opts)
+			if test.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectError) {
+					t.Errorf("expected error containing %q, got %v", test.expectError, err)
+				}
+				var blocked *applyset.PruneBlockedError
+				if !errors.As(err, &blocked) {
+					t.Errorf("expected PruneBlockedError, got %T", err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+			list, err := dynamicClient.Resource(configMaps).Namespace("kube-system").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("error listing configmaps: %v", err)
+			}
+			var got []string
+			for _, obj := range list.Items {
+				got = append(got, obj.GetName())
+			}
+			sort.Strings(got)
+			if diff := cmp.Diff(test.expectLive, got); diff != "" {
+				t.Errorf("unexpected live objects (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestKubectlApplier(t *testing.T) {
 	log.SetLogger(klogr.New())
 
@@ -166,3 +327,286 @@ func TestKubectlApplier(t *testing.T) {
 	applier := NewExec()
 	runApplierGoldenTests(t, "testdata/kubectl", true, applier)
 }
+
+// fakeKubectl is a commandSite that runs testdata/fake-kubectl.sh in place of kubectl
+type fakeKubectl struct {
+	Cmds []*exec.Cmd
+}
+
+func (f *fakeKubectl) Run(c *exec.Cmd) error {
+	f.Cmds = append(f.Cmds, c)
+	script, err := filepath.Abs("testdata/fake-kubectl.sh")
+	if err != nil {
+		return err
+	}
+	c.Path = script
+	// kubectl may not be on the path, so clear the error from looking it up
+	c.Err = nil
+	return console{}.Run(c)
+}
+
+func TestKubectlApplySet(t *testing.T) {
+	desiredYAML := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: bar
+`
+
+	tests := []struct {
+		name            string
+		version         string
+		parentNamespace string
+		args            []string
+		withoutGuards   bool
+		stdout          string
+		stderr          string
+		exit            string
+		expectError     bool
+		expectArgs      []string
+		expectApplySet  bool
+		expectResults   []string
+	}{
+		{
+			name:            "applyset",
+			version:         "v1.29.1",
+			parentNamespace: "kube-system",
+			args:            []string{"--prune", "--selector", "app=foo", "--prune-allowlist", "core/v1/ConfigMap"},
+			withoutGuards:   true,
+			stdout:          "configmap/foo serverside-applied\ndeployment.apps/bar serverside-applied\nconfigmap/old pruned\n",
+			expectArgs:      []string{"kubectl", "apply", "-n", "kube-system", "--validate=false", "--server-side", "--force-conflicts", "--prune", "--applyset=configmaps/test", "-f", "-"},
+			expectApplySet:  true,
+			expectResults: []string{
+				"ConfigMap kube-system/foo serverside-applied",
+				"Deployment kube-system/bar serverside-applied",
+				"ConfigMap kube-system/old pruned",
+			},
+		},
+		{
+			name:       "server-side apply without pruning",
+			version:    "v1.27.0-gke.100",
+			stdout:     "configmap/foo serverside-applied\ndeployment.apps/bar serverside-applied\n",
+			expectArgs: []string{"kubectl", "apply", "-n", "kube-system", "--validate=false", "--server-side", "--force-conflicts", "-f", "-"},
+			expectResults: []string{
+				"ConfigMap kube-system/foo serverside-applied",
+				"Deployment kube-system/bar serverside-applied",
+			},
+		},
+		{
+			name:       "kubectl without applyset support",
+			version:    "v1.26.3",
+			stdout:     "configmap/foo configured\ndeployment.apps/bar created\n",
+			expectArgs: []string{"kubectl", "apply", "-n", "kube-system", "--validate=false", "--force", "-f", "-"},
+			expectResults: []string{
+				"ConfigMap kube-system/foo configured",
+				"Deployment kube-system/bar created",
+			},
+		},
+		{
+			name:            "parent in another namespace",
+			version:         "v1.29.1",
+			parentNamespace: "default",
+			args:            []string{"--prune", "--selector", "app=foo", "--prune-allowlist", "core/v1/ConfigMap"},
+			withoutGuards:   true,
+			stdout:          "configmap/foo configured\ndeployment.apps/bar configured\n",
+			expectArgs:      []string{"kubectl", "apply", "-n", "kube-system", "--validate=false", "--force", "--selector", "app=foo", "-f", "-"},
+			expectResults: []string{
+				"ConfigMap kube-system/foo configured",
+				"Deployment kube-system/bar configured",
+			},
+		},
+		{
+			name:            "prune with guards",
+			version:         "v1.29.1",
+			parentNamespace: "kube-system",
+			args:            []string{"--prune", "--selector", "app=foo", "--prune-allowlist", "core/v1/ConfigMap"},
+			stdout:          "configmap/foo configured\ndeployment.apps/bar configured\n",
+			expectArgs:      []string{"kubectl", "apply", "-n", "kube-system", "--validate=false", "--force", "--selector", "app=foo", "-f", "-"},
+			expectResults: []string{
+				"ConfigMap kube-system/foo configured",
+				"Deployment kube-system/bar configured",
+			},
+		},
+		{
+			name:        "apply error",
+			version:     "v1.29.1",
+			stdout:      "configmap/foo serverside-applied\n",
+			stderr:      "Error from server (Invalid): error when creating \"STDIN\": Deployment.apps \"bar\" is invalid: spec.replicas: Invalid value: -1\n",
+			exit:        "1",
+			expectError: true,
+			expectArgs:  []string{"kubectl", "apply", "-n", "kube-system", "--validate=false", "--server-side", "--force-conflicts", "-f", "-"},
+			expectResults: []string{
+				"ConfigMap kube-system/foo serverside-applied",
+				"Deployment kube-system/bar error: Error from server (Invalid): error when creating \"STDIN\": Deployment.apps \"bar\" is invalid: spec.replicas: Invalid value: -1",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.TODO()
+
+			t.Setenv("FAKE_KUBECTL_VERSION", test.version)
+			t.Setenv("FAKE_KUBECTL_STDOUT", test.stdout)
+			t.Setenv("FAKE_KUBECTL_STDERR", test.stderr)
+			t.Setenv("FAKE_KUBECTL_EXIT", test.exit)
+
+			restMapper := testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme)
+			parentMapping, err := restMapper.RESTMapping(schema.GroupKind{Kind: "ConfigMap"}, "v1")
+			if err != nil {
+				t.Fatalf("error getting parent mapping: %v", err)
+			}
+			parentNamespace := test.parentNamespace
+			if parentNamespace == "" {
+				parentNamespace = "kube-system"
+			}
+			parent := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: parentNamespace}}
+
+			objects, err := manifest.ParseObjects(ctx, desiredYAML)
+			if err != nil {
+				t.Fatalf("error parsing manifest: %v", err)
+			}
+
+			runner := &fakeKubectl{}
+			kubectl := &ExecKubectl{cmdSite: runner}
+			kubectl.UseApplySet()
+			if test.withoutGuards {
+				kubectl.UseApplySetPruneWithoutGuards()
+			}
+			opts := ApplierOptions{
+				Namespace:     "kube-system",
+				Objects:       objects.GetItems(),
+				ExtraArgs:     test.args,
+				Force:         true,
+				ParentRef:     applyset.NewParentRef(parent, parent.Name, parent.Namespace, parentMapping),
+				RESTMapper:    restMapper,
+				DynamicClient: dynamicfake.NewSimpleDynamicClient(scheme.Scheme),
+			}
+			results, err := kubectl.ApplyWithResults(ctx, opts)
+			if test.expectError && err == nil {
+				t.Errorf("expected error to occur")
+			} else if !test.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			// The version is only detected once
+			if _, err := kubectl.kubectlVersion(); err != nil {
+				t.Errorf("unexpected error detecting version: %v", err)
+			}
+			if len(runner.Cmds) != 2 {
+				t.Fatalf("expected kubectl version and apply to be invoked, got %d commands", len(runner.Cmds))
+			}
+
+			cmd := runner.Cmds[1]
+			if diff := cmp.Diff(test.expectArgs, cmd.Args); diff != "" {
+				t.Errorf("unexpected args (-want +got):\n%s", diff)
+			}
+			hasApplySetEnv := false
+			for _, env := range cmd.Env {
+				if env == "KUBECTL_APPLYSET=true" {
+					hasApplySetEnv = true
+				}
+			}
+			if hasApplySetEnv != test.expectApplySet {
+				t.Errorf("unexpected KUBECTL_APPLYSET, got %v, want %v", hasApplySetEnv, test.expectApplySet)
+			}
+
+			var got []string
+			for _, obj := range results.Objects {
+				s := obj.GVK.Kind + " " + obj.NameNamespace.String() + " " + obj.Apply.Operation
+				if obj.Apply.Error != nil {
+					s += "error: " + obj.Apply.Error.Error()
+				}
+				got = append(got, s)
+			}
+			if diff := cmp.Diff(test.expectResults, got); diff != "" {
+				t.Errorf("unexpected results (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestKubectlApplySetPruneGuards(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Setenv("FAKE_KUBECTL_VERSION", "v1.29.1")
+	t.Setenv("FAKE_KUBECTL_STDOUT", "configmap/foo configured\n")
+	t.Setenv("FAKE_KUBECTL_STDERR", "")
+	t.Setenv("FAKE_KUBECTL_EXIT", "")
+
+	crdGVK := schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}
+	crdMapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{crdGVK.GroupVersion()})
+	crdMapper.Add(crdGVK, meta.RESTScopeRoot)
+	restMapper := meta.MultiRESTMapper{testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme), crdMapper}
+
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(crdGVK)
+	crd.SetName("widgets.example.com")
+	crd.SetLabels(map[string]string{"app": "foo"})
+	crd.SetAnnotations(map[string]string{corev1.LastAppliedConfigAnnotation: "{}"})
+	crds := schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		crds: "CustomResourceDefinitionList",
+	}, crd)
+
+	parentMapping, err := restMapper.RESTMapping(schema.GroupKind{Kind: "ConfigMap"}, "v1")
+	if err != nil {
+		t.Fatalf("error getting parent mapping: %v", err)
+	}
+	parent := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "kube-system"}}
+
+	objects, err := manifest.ParseObjects(ctx, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+`)
+	if err != nil {
+		t.Fatalf("error parsing manifest: %v", err)
+	}
+
+	runner := &fakeKubectl{}
+	kubectl := &ExecKubectl{cmdSite: runner}
+	kubectl.UseApplySet()
+	opts := ApplierOptions{
+		Namespace:     "kube-system",
+		Objects:       objects.GetItems(),
+		ExtraArgs:     []string{"--prune", "--selector", "app=foo", "--prune-allowlist", "apiextensions.k8s.io/v1/CustomResourceDefinition"},
+		Force:         true,
+		ParentRef:     applyset.NewParentRef(parent, parent.Name, parent.Namespace, parentMapping),
+		RESTMapper:    restMapper,
+		DynamicClient: dynamicClient,
+	}
+	if _, err := kubectl.ApplyWithResults(ctx, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// kubectl must not prune the applyset itself, as it would not protect the CRD
+	for _, arg := range runner.Cmds[len(runner.Cmds)-1].Args {
+		if strings.HasPrefix(arg, "--applyset") {
+			t.Errorf("unexpected %s in kubectl args", arg)
+		}
+	}
+	if _, err := dynamicClient.Resource(crds).Get(ctx, crd.GetName(), metav1.GetOptions{}); err != nil {
+		t.Errorf("expected CRD to survive the prune: %v", err)
+	}
+}
+
+func TestParseKubectlVersion(t *testing.T) {
+	v, err := parseKubectlVersion([]byte(`{"clientVersion":{"major":"1","minor":"29","gitVersion":"v1.29.1","platform":"linux/amd64"},"kustomizeVersion":"v5.0.4-0.20230601165947-6ce0bf390ce3"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := v.String(), "1.29.1"; got != want {
+		t.Errorf("unexpected version, got %q, want %q", got, want)
+	}
+
+	if _, err := parseKubectlVersion([]byte(`{"clientVersion":{}}`)); err == nil {
+		t.Errorf("expected error parsing version without gitVersion")
+	}
+}
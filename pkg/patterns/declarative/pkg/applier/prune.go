@@ -0,0 +1,159 @@
+//go:build !without_exec_applier || !without_direct_applier
+// +build !without_exec_applier !without_direct_applier
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applier
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/kubectl/pkg/util/prune"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/applyset"
+)
+
+// kubectlPruner prunes objects the same way as kubectl apply --prune,
+// but honours the PrunePolicy before deleting anything.
+type kubectlPruner struct {
+	dynamicClient dynamic.Interface
+	restMapper    meta.RESTMapper
+
+	// pruneResources are the kinds to prune; if empty, the kubectl defaults are used
+	pruneResources []prune.Resource
+	// namespaceSpecified is true if the apply was scoped to a namespace
+	namespaceSpecified bool
+	// namespaces are the namespaces that objects were applied to
+	namespaces sets.Set[string]
+	// selector is the label selector for the objects to prune
+	selector string
+
+	// isApplied is true for live objects that were applied, which must not be pruned
+	isApplied func(obj *unstructured.Unstructured) bool
+	// appliedCount is the number of objects that were applied, used for the mass-deletion threshold
+	appliedCount int
+
+	cascadingStrategy metav1.DeletionPropagation
+	policy            applyset.PrunePolicy
+}
+
+// prunePolicyFor returns the prune policy for the apply, honouring the mass-prune override on the parent.
+func prunePolicyFor(opt ApplierOptions) applyset.PrunePolicy {
+	policy := opt.PrunePolicy
+	if opt.ParentRef != nil {
+		if accessor, err := meta.Accessor(opt.ParentRef.GetSubject()); err == nil {
+			if accessor.GetAnnotations()[applyset.AllowMassPruneAnnotation] == "true" {
+				policy.AllowMassPrune = true
+			}
+		}
+	}
+	return policy
+}
+
+// prune deletes the objects that are no longer applied, recording the outcome in results.
+// Like kubectl, only objects created by kubectl apply (with the last-applied annotation) are pruned.
+func (p *kubectlPruner) prune(ctx context.Context, results *applyset.ApplyResults) error {
+	log := log.FromContext(ctx)
+
+	namespaced, nonNamespaced, err := prune.GetRESTMappings(p.restMapper, p.pruneResources, p.namespaceSpecified)
+	if err != nil {
+		return fmt.Errorf("error retrieving RESTMappings to prune: %w", err)
+	}
+
+	type candidate struct {
+		mapping *meta.RESTMapping
+		object  *unstructured.Unstructured
+	}
+	var candidates []candidate
+	findCandidates := func(namespace string, mapping *meta.RESTMapping) error {
+		list, err := p.dynamicClient.Resource(mapping.Resource).Namespace(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: p.selector,
+		})
+		if err != nil {
+			return fmt.Errorf("error listing %v objects for pruning: %w", mapping.GroupVersionKind, err)
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if _, found := obj.GetAnnotations()[corev1.LastAppliedConfigAnnotation]; !found {
+				// don't prune resources not created with apply
+				continue
+			}
+			if p.isApplied(obj) {
+				continue
+			}
+			candidates = append(candidates, candidate{mapping: mapping, object: obj})
+		}
+		return nil
+	}
+	for _, namespace := range sets.List(p.namespaces) {
+		for _, mapping := range namespaced {
+			if err := findCandidates(namespace, mapping); err != nil {
+				return err
+			}
+		}
+	}
+	for _, mapping := range nonNamespaced {
+		if err := findCandidates(metav1.NamespaceNone, mapping); err != nil {
+			return err
+		}
+	}
+
+	var prunable []candidate
+	for _, c := range candidates {
+		gvk := c.object.GroupVersionKind()
+		nn := types.NamespacedName{Namespace: c.object.GetNamespace(), Name: c.object.GetName()}
+		if reason := p.policy.ProtectedReason(gvk.GroupKind(), c.object.GetAnnotations()); reason != "" {
+			log.WithValues("object", nn, "gvk", gvk).Info("not pruning object", "reason", reason)
+			results.RecordPruneSkipped(gvk, nn, reason)
+			continue
+		}
+		prunable = append(prunable, c)
+	}
+
+	if err := p.policy.CheckMassPrune(len(prunable), p.appliedCount+len(candidates)); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, c := range prunable {
+		gvk := c.object.GroupVersionKind()
+		nn := types.NamespacedName{Namespace: c.object.GetNamespace(), Name: c.object.GetName()}
+
+		deleteOptions := metav1.DeleteOptions{}
+		if p.cascadingStrategy != "" {
+			deleteOptions.PropagationPolicy = &p.cascadingStrategy
+		}
+		if err := p.dynamicClient.Resource(c.mapping.Resource).Namespace(nn.Namespace).Delete(ctx, nn.Name, deleteOptions); err != nil {
+			err = fmt.Errorf("error from delete: %w", err)
+			results.RecordPruneError(gvk, nn, err)
+			errs = append(errs, err)
+			continue
+		}
+		log.WithValues("object", nn, "gvk", gvk).Info("pruned object")
+		results.RecordPruned(gvk, nn)
+	}
+	return utilerrors.NewAggregate(errs)
+}
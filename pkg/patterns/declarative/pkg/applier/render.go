@@ -0,0 +1,249 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/applyset"
+)
+
+// kustomizationFile is the name of the index of the rendered objects
+const kustomizationFile = "kustomization.yaml"
+
+// RenderOptions configures the RenderApplier
+type RenderOptions struct {
+	// FileSystem is where the objects are written; if not set, they are written to disk.
+	FileSystem filesys.FileSystem
+
+	// Dir is the directory the objects are written to. If there is a ParentRef, the objects are written to
+	// <Dir>/<namespace>/<name> of the object being reconciled, so that each object has its own kustomization.
+	Dir string
+}
+
+// RenderApplier writes the objects to a directory rather than applying them, so that another process
+// (eg a GitOps tool like Argo CD or Flux) can commit and apply them.
+//
+// Each object is written to its own file, and the files are listed in a kustomization.yaml.
+// The files of objects that are no longer desired are removed; other files in the directory are left alone.
+// ExtraArgs are ignored.
+type RenderApplier struct {
+	fs  filesys.FileSystem
+	dir string
+}
+
+var _ ApplierWithResults = &RenderApplier{}
+
+// NewRenderApplier returns a RenderApplier that writes the objects to options.Dir.
+func NewRenderApplier(options RenderOptions) *RenderApplier {
+	fs := options.FileSystem
+	if fs == nil {
+		fs = filesys.MakeFsOnDisk()
+	}
+	return &RenderApplier{fs: fs, dir: options.Dir}
+}
+
+func (a *RenderApplier) Apply(ctx context.Context, opt ApplierOptions) error {
+	_, err := a.ApplyWithResults(ctx, opt)
+	return err
+}
+
+func (a *RenderApplier) ApplyWithResults(ctx context.Context, opt ApplierOptions) (*applyset.ApplyResults, error) {
+	log := log.FromContext(ctx)
+
+	dir := a.dir
+	if opt.ParentRef != nil {
+		dir = filepath.Join(dir, opt.ParentRef.Namespace(), opt.ParentRef.Name())
+	}
+	if err := a.fs.MkdirAll(dir); err != nil {
+		return nil, fmt.Errorf("error creating directory %q: %w", dir, err)
+	}
+
+	previous, err := a.readIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	results := applyset.NewApplyResults(len(opt.Objects))
+
+	rendered := make(map[string]bool)
+	for _, obj := range opt.Objects {
+		gvk := obj.GroupVersionKind()
+		nn := objectNamespacedName(opt, obj)
+
+		u := obj.UnstructuredObject()
+		if nn.Namespace != u.GetNamespace() {
+			u = u.DeepCopy()
+			u.SetNamespace(nn.Namespace)
+		}
+
+		fileName := renderFileName(gvk.GroupKind(), nn)
+		if rendered[fileName] {
+			return results, fmt.Errorf("multiple objects would be rendered to %q", fileName)
+		}
+		rendered[fileName] = true
+
+		b, err := yaml.Marshal(u.Object)
+		if err != nil {
+			return results, fmt.Errorf("error converting %v %v to yaml: %w", gvk.Kind, nn, err)
+		}
+
+		operation, err := a.writeFile(filepath.Join(dir, fileName), b)
+		if err != nil {
+			results.RecordApplyError(gvk, nn, err)
+			return results, err
+		}
+		log.WithValues("object", nn, "gvk", gvk, "operation", operation).V(2).Info("rendered object")
+		results.RecordApplied(gvk, nn, operation, u)
+	}
+
+	// We only remove the files we rendered previously, so that other files in the directory are left alone.
+	// The index is written last, so that files we fail to remove are retried on the next apply.
+	for _, fileName := range previous {
+		if rendered[fileName] || fileName != filepath.Base(fileName) || filepath.Ext(fileName) != ".yaml" {
+			continue
+		}
+		p := filepath.Join(dir, fileName)
+		if !a.fs.Exists(p) {
+			continue
+		}
+		gvk, nn := a.renderedObject(p)
+		if err := a.fs.RemoveAll(p); err != nil {
+			err = fmt.Errorf("error removing %q: %w", p, err)
+			results.RecordPruneError(gvk, nn, err)
+			return results, err
+		}
+		log.WithValues("object", nn, "gvk", gvk, "file", p).Info("removed rendered object")
+		results.RecordPruned(gvk, nn)
+	}
+
+	resources := []string{}
+	for fileName := range rendered {
+		resources = append(resources, fileName)
+	}
+	sort.Strings(resources)
+	if err := a.writeIndex(dir, resources); err != nil {
+		return results, err
+	}
+
+	return results, nil
+}
+
+// writeFile writes the file if its content has changed, returning the operation like kubectl apply.
+func (a *RenderApplier) writeFile(p string, b []byte) (string, error) {
+	if a.fs.Exists(p) {
+		existing, err := a.fs.ReadFile(p)
+		if err != nil {
+			return "", fmt.Errorf("error reading %q: %w", p, err)
+		}
+		if bytes.Equal(existing, b) {
+			return applyset.OperationUnchanged, nil
+		}
+		if err := a.fs.WriteFile(p, b); err != nil {
+			return "", fmt.Errorf("error writing %q: %w", p, err)
+		}
+		return applyset.OperationConfigured, nil
+	}
+	if err := a.fs.WriteFile(p, b); err != nil {
+		return "", fmt.Errorf("error writing %q: %w", p, err)
+	}
+	return applyset.OperationCreated, nil
+}
+
+// kustomization is the subset of the kustomization.yaml that we write
+type kustomization struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Resources  []string `json:"resources"`
+}
+
+// readIndex returns the files listed in the kustomization.yaml in dir, if there is one.
+func (a *RenderApplier) readIndex(dir string) ([]string, error) {
+	p := filepath.Join(dir, kustomizationFile)
+	if !a.fs.Exists(p) {
+		return nil, nil
+	}
+	b, err := a.fs.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %q: %w", p, err)
+	}
+	var k kustomization
+	if err := yaml.Unmarshal(b, &k); err != nil {
+		return nil, fmt.Errorf("error parsing %q: %w", p, err)
+	}
+	return k.Resources, nil
+}
+
+// writeIndex writes the kustomization.yaml listing the rendered files.
+func (a *RenderApplier) writeIndex(dir string, resources []string) error {
+	b, err := yaml.Marshal(kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  resources,
+	})
+	if err != nil {
+		return fmt.Errorf("error building %s: %w", kustomizationFile, err)
+	}
+	if _, err := a.writeFile(filepath.Join(dir, kustomizationFile), b); err != nil {
+		return err
+	}
+	return nil
+}
+
+// renderedObject returns the kind and name of the object in a rendered file, for reporting its removal.
+func (a *RenderApplier) renderedObject(p string) (schema.GroupVersionKind, types.NamespacedName) {
+	u := &unstructured.Unstructured{}
+	if b, err := a.fs.ReadFile(p); err == nil {
+		_ = yaml.Unmarshal(b, &u.Object)
+	}
+	return u.GroupVersionKind(), types.NamespacedName{Namespace: u.GetNamespace(), Name: u.GetName()}
+}
+
+// renderFileName returns the file for an object, eg deployment.apps_kube-system_foo.yaml,
+// or clusterrole.rbac.authorization.k8s.io_foo.yaml for a cluster-scoped object.
+func renderFileName(gk schema.GroupKind, nn types.NamespacedName) string {
+	parts := []string{strings.ToLower(gk.String())}
+	if nn.Namespace != "" {
+		parts = append(parts, nn.Namespace)
+	}
+	parts = append(parts, sanitizeFileName(nn.Name))
+	return strings.Join(parts, "_") + ".yaml"
+}
+
+// sanitizeFileName replaces the characters that some names allow (eg "system:controller" for RBAC), but are not safe in paths
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package applier
+
+import (
+	"context"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/applyset"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+func TestRenderApplier(t *testing.T) {
+	ctx := context.TODO()
+
+	restMapper := testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme)
+	parentMapping, err := restMapper.RESTMapping(schema.GroupKind{Kind: "ConfigMap"}, "v1")
+	if err != nil {
+		t.Fatalf("error getting parent mapping: %v", err)
+	}
+	parent := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "kube-system"}}
+
+	fs := filesys.MakeFsInMemory()
+	if err := fs.MkdirAll("/out/kube-system/test"); err != nil {
+		t.Fatalf("error creating directory: %v", err)
+	}
+	if err := fs.WriteFile("/out/kube-system/test/README.md", []byte("not rendered")); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+
+	renderApplier := NewRenderApplier(RenderOptions{FileSystem: fs, Dir: "/out"})
+
+	apply := func(manifestYAML string) []string {
+		objects, err := manifest.ParseObjects(ctx, manifestYAML)
+		if err != nil {
+			t.Fatalf("error parsing manifest: %v", err)
+		}
+		results, err := renderApplier.ApplyWithResults(ctx, ApplierOptions{
+			Namespace:  "kube-system",
+			Objects:    objects.GetItems(),
+			ParentRef:  applyset.NewParentRef(parent, parent.Name, parent.Namespace, parentMapping),
+			RESTMapper: restMapper,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got []string
+		for _, obj := range results.Objects {
+			got = append(got, obj.GVK.Kind+" "+obj.NameNamespace.String()+" "+obj.Apply.Operation)
+		}
+		return got
+	}
+
+	readFile := func(p string) string {
+		b, err := fs.ReadFile(p)
+		if err != nil {
+			t.Fatalf("error reading %q: %v", p, err)
+		}
+		return string(b)
+	}
+
+	listFiles := func() []string {
+		var files []string
+		if err := fs.Walk("/out", func(p string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				files = append(files, p)
+			}
+			return err
+		}); err != nil {
+			t.Fatalf("error listing files: %v", err)
+		}
+		sort.Strings(files)
+		return files
+	}
+
+	got := apply(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+data:
+  key: value1
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: system:foo
+`)
+	want := []string{
+		"Deployment kube-system/foo created",
+		"ConfigMap kube-system/foo created",
+		"ClusterRole /system:foo created",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results (-want +got):\n%s", diff)
+	}
+
+	wantFiles := []string{
+		"/out/kube-system/test/README.md",
+		"/out/kube-system/test/clusterrole.rbac.authorization.k8s.io_system_foo.yaml",
+		"/out/kube-system/test/configmap_kube-system_foo.yaml",
+		"/out/kube-system/test/deployment.apps_kube-system_foo.yaml",
+		"/out/kube-system/test/kustomization.yaml",
+	}
+	if diff := cmp.Diff(wantFiles, listFiles()); diff != "" {
+		t.Errorf("unexpected files (-want +got):\n%s", diff)
+	}
+
+	wantKustomization := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- clusterrole.rbac.authorization.k8s.io_system_foo.yaml
+- configmap_kube-system_foo.yaml
+- deployment.apps_kube-system_foo.yaml
+`
+	if diff := cmp.Diff(wantKustomization, readFile("/out/kube-system/test/kustomization.yaml")); diff != "" {
+		t.Errorf("unexpected kustomization.yaml (-want +got):\n%s", diff)
+	}
+
+	wantConfigMap := `apiVersion: v1
+data:
+  key: value1
+kind: ConfigMap
+metadata:
+  name: foo
+  namespace: kube-system
+`
+	if diff := cmp.Diff(wantConfigMap, readFile("/out/kube-system/test/configmap_kube-system_foo.yaml")); diff != "" {
+		t.Errorf("unexpected configmap (-want +got):\n%s", diff)
+	}
+
+	// The Deployment is no longer desired, and the ConfigMap has changed
+	got = apply(`
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: system:foo
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+data:
+  key: value2
+`)
+	want = []string{
+		"ClusterRole /system:foo unchanged",
+		"ConfigMap kube-system/foo configured",
+		"Deployment kube-system/foo pruned",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results (-want +got):\n%s", diff)
+	}
+
+	wantFiles = []string{
+		"/out/kube-system/test/README.md",
+		"/out/kube-system/test/clusterrole.rbac.authorization.k8s.io_system_foo.yaml",
+		"/out/kube-system/test/configmap_kube-system_foo.yaml",
+		"/out/kube-system/test/kustomization.yaml",
+	}
+	if diff := cmp.Diff(wantFiles, listFiles()); diff != "" {
+		t.Errorf("unexpected files (-want +got):\n%s", diff)
+	}
+}
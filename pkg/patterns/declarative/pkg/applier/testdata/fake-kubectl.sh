@@ -0,0 +1,31 @@
+#!/bin/sh
+
+# fake-kubectl.sh stands in for kubectl in the ExecKubectl tests.
+# FAKE_KUBECTL_VERSION is the version reported by kubectl version;
+# kubectl apply prints FAKE_KUBECTL_STDOUT and FAKE_KUBECTL_STDERR, and exits with FAKE_KUBECTL_EXIT.
+
+case "$1" in
+version)
+  echo "{\"clientVersion\":{\"gitVersion\":\"${FAKE_KUBECTL_VERSION}\"}}"
+  ;;
+apply)
+  for arg in "$@"; do
+    case "${arg}" in
+    --applyset=*)
+      if [ "${KUBECTL_APPLYSET}" != "true" ]; then
+        echo "error: --applyset requires KUBECTL_APPLYSET=true" >&2
+        exit 1
+      fi
+      ;;
+    esac
+  done
+  cat > /dev/null
+  printf '%s' "${FAKE_KUBECTL_STDOUT}"
+  printf '%s' "${FAKE_KUBECTL_STDERR}" >&2
+  exit "${FAKE_KUBECTL_EXIT:-0}"
+  ;;
+*)
+  echo "unexpected command $1" >&2
+  exit 1
+  ;;
+esac
@@ -2,9 +2,11 @@ package applier
 
 import (
 	"context"
+	"fmt"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -16,24 +18,52 @@ type Applier interface {
 	Apply(ctx context.Context, options ApplierOptions) error
 }
 
+// ApplierWithResults is implemented by appliers that can report the result of applying each object.
+type ApplierWithResults interface {
+	Applier
+
+	// ApplyWithResults applies the objects like Apply, also returning the per-object results.
+	// The results may be returned along with an error, if some objects were not applied.
+	ApplyWithResults(ctx context.Context, options ApplierOptions) (*applyset.ApplyResults, error)
+}
+
 type ApplierOptions struct {
 	Objects []*manifest.Object
 
 	RESTConfig *rest.Config
 	RESTMapper meta.RESTMapper
 	Namespace  string
-	Validate   bool
+	// Validate enables server-side field validation, with FieldValidation or else metav1.FieldValidationStrict.
+	Validate bool
+	// FieldValidation is the server-side field validation directive, eg metav1.FieldValidationStrict or metav1.FieldValidationWarn.
+	FieldValidation string
 
 	CascadingStrategy metav1.DeletionPropagation
 
 	PruneWhitelist []string
 	Prune          bool
 
+	// PrunePolicy guards against pruning objects by mistake: protected objects are never pruned,
+	// and pruning is blocked with an applyset.PruneBlockedError if it would delete too many objects.
+	PrunePolicy applyset.PrunePolicy
+
 	// Force is set if we should "force" the apply.
 	// For server-side-apply, this corresponds to setting the force option, which ensures we take ownership
 	// even when another field manager owns a field.
 	Force bool
 
+	// ConflictPolicy controls what happens when the apply conflicts with fields owned by another field manager.
+	// If set, it takes precedence over Force; applyset.ConflictPolicySkipConflictingFields is only supported by the ApplySetApplier.
+	ConflictPolicy applyset.ConflictPolicy
+
+	// RecreatePolicy controls whether objects are deleted and recreated when the apply changes immutable fields.
+	// It is only supported by the ApplySetApplier.
+	RecreatePolicy applyset.RecreatePolicy
+
+	// RetryPolicy controls how the apply of an object is retried after transient errors.
+	// If not set, applyset.DefaultRetryPolicy is used.
+	RetryPolicy applyset.RetryPolicy
+
 	// ExtraArgs holds additional arguments that should be passed to kubectl.
 	// @deprecated: prefer using explicit arguments (Force etc)
 	ExtraArgs []string
@@ -44,5 +74,57 @@ type ApplierOptions struct {
 	// DynamicClient, if set, will be used for applying additional objects.
 	// If not set, a dynamic client will be built from RESTConfig.
 	// If the caller can provide a cached DynamicClient, that is more efficient.
+	//
+	// The ApplySetApplier records the warnings returned by the apiserver for each object through the context of the apply,
+	// so the transport of DynamicClient should be wrapped with applyset.WrapTransportForWarnings, as the Reconciler does.
 	DynamicClient dynamic.Interface
 }
+
+// withWarningRecorder returns a copy of the RESTConfig, for which the warnings returned by the apiserver are recorded,
+// or nil if RESTConfig is not set.
+func withWarningRecorder(opt ApplierOptions) (*rest.Config, *applyset.WarningRecorder) {
+	if opt.RESTConfig == nil {
+		return nil, nil
+	}
+	warnings := applyset.NewWarningRecorder()
+	restConfig := rest.CopyConfig(opt.RESTConfig)
+	restConfig.WarningHandler = warnings
+	return restConfig, warnings
+}
+
+// fieldValidationFor returns the server-side field validation directive for the apply, or "" to use the default.
+func fieldValidationFor(opt ApplierOptions) string {
+	if opt.FieldValidation != "" {
+		return opt.FieldValidation
+	}
+	if opt.Validate {
+		return metav1.FieldValidationStrict
+	}
+	return ""
+}
+
+// forceConflicts returns whether to force the apply, for appliers that cannot skip conflicting fields.
+func forceConflicts(opt ApplierOptions) (bool, error) {
+	switch opt.ConflictPolicy {
+	case "":
+		return opt.Force, nil
+	case applyset.ConflictPolicyForce:
+		return true, nil
+	case applyset.ConflictPolicyFail:
+		return false, nil
+	default:
+		return false, fmt.Errorf("conflict policy %q is not supported by this applier", opt.ConflictPolicy)
+	}
+}
+
+// objectNamespacedName returns the name and namespace of an object, defaulting the namespace of namespaced objects.
+func objectNamespacedName(opt ApplierOptions, obj *manifest.Object) types.NamespacedName {
+	nn := obj.NamespacedName()
+	if nn.Namespace == "" && opt.RESTMapper != nil {
+		gvk := obj.GroupVersionKind()
+		if mapping, err := opt.RESTMapper.RESTMapping(gvk.GroupKind(), gvk.Version); err == nil && mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			nn.Namespace = opt.Namespace
+		}
+	}
+	return nn
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deprecation
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+// converter rewrites the content of an object to the schema of the replacement API version.
+// It returns false if the object cannot be converted without changing its meaning.
+type converter func(obj map[string]interface{}) (bool, error)
+
+// converters are the conversions from deprecated API versions that we can perform mechanically.
+var converters = map[schema.GroupVersionKind]converter{
+	{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}:         convertIngress,
+	{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}:  convertIngress,
+	{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"}: convertPodDisruptionBudget,
+	{Group: "batch", Version: "v1beta1", Kind: "CronJob"}:              sameSchema,
+}
+
+// convert returns obj converted to the replacement for the deprecated API version,
+// or nil if there is no replacement or the object cannot be converted.
+func convert(obj *manifest.Object, deprecation *Deprecation) (*manifest.Object, error) {
+	fn, found := converters[deprecation.GroupVersionKind]
+	if !found || deprecation.Replacement.Empty() {
+		return nil, nil
+	}
+
+	u := obj.UnstructuredObject().DeepCopy()
+	ok, err := fn(u.Object)
+	if err != nil || !ok {
+		return nil, err
+	}
+	u.SetGroupVersionKind(deprecation.Replacement)
+	return manifest.NewObject(u)
+}
+
+// sameSchema is the converter for API versions that only changed version.
+func sameSchema(obj map[string]interface{}) (bool, error) {
+	return true, nil
+}
+
+// convertPodDisruptionBudget converts a policy/v1beta1 PodDisruptionBudget to policy/v1.
+// An empty selector matches no pods in v1beta1 but all pods in v1, so those are not converted.
+func convertPodDisruptionBudget(obj map[string]interface{}) (bool, error) {
+	selector, found, err := unstructured.NestedMap(obj, "spec", "selector")
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		return true, nil
+	}
+	matchLabels, _ := selector["matchLabels"].(map[string]interface{})
+	matchExpressions, _ := selector["matchExpressions"].([]interface{})
+	return len(matchLabels) != 0 || len(matchExpressions) != 0, nil
+}
+
+// convertIngress converts an extensions/v1beta1 or networking.k8s.io/v1beta1 Ingress to networking.k8s.io/v1.
+func convertIngress(obj map[string]interface{}) (bool, error) {
+	spec, found, err := unstructured.NestedMap(obj, "spec")
+	if err != nil || !found {
+		return true, err
+	}
+
+	if backend, found := spec["backend"]; found {
+		converted, err := convertIngressBackend(backend)
+		if err != nil {
+			return false, fmt.Errorf("spec.backend: %w", err)
+		}
+		spec["defaultBackend"] = converted
+		delete(spec, "backend")
+	}
+
+	rules, _ := spec["rules"].([]interface{})
+	for i, rule := range rules {
+		rule, _ := rule.(map[string]interface{})
+		http, _ := rule["http"].(map[string]interface{})
+		paths, _ := http["paths"].([]interface{})
+		for j, path := range paths {
+			path, ok := path.(map[string]interface{})
+			if !ok {
+				return false, fmt.Errorf("spec.rules[%d].http.paths[%d] is not an object", i, j)
+			}
+			if backend, found := path["backend"]; found {
+				converted, err := convertIngressBackend(backend)
+				if err != nil {
+					return false, fmt.Errorf("spec.rules[%d].http.paths[%d].backend: %w", i, j, err)
+				}
+				path["backend"] = converted
+			}
+			if _, found := path["pathType"]; !found {
+				// The v1beta1 default; pathType is required in v1
+				path["pathType"] = "ImplementationSpecific"
+			}
+		}
+	}
+
+	return true, unstructured.SetNestedMap(obj, spec, "spec")
+}
+
+// convertIngressBackend converts serviceName and servicePort to the v1 service backend.
+func convertIngressBackend(backend interface{}) (map[string]interface{}, error) {
+	m, ok := backend.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("backend is not an object")
+	}
+	serviceName, hasName := m["serviceName"]
+	servicePort, hasPort := m["servicePort"]
+	if !hasName && !hasPort {
+		// A resource backend is unchanged
+		return m, nil
+	}
+	delete(m, "serviceName")
+	delete(m, "servicePort")
+
+	port := make(map[string]interface{})
+	switch servicePort := servicePort.(type) {
+	case int64:
+		port["number"] = servicePort
+	case float64:
+		port["number"] = int64(servicePort)
+	case string:
+		port["name"] = servicePort
+	default:
+		return nil, fmt.Errorf("unexpected type %T for servicePort", servicePort)
+	}
+	m["service"] = map[string]interface{}{
+		"name": serviceName,
+		"port": port,
+	}
+	return m, nil
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package deprecation detects rendered manifest objects that use deprecated or removed API versions,
+// before they are applied.
+package deprecation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+// Policy controls what is done with objects that use deprecated or removed API versions.
+type Policy struct {
+	// AutoConvert rewrites objects to their replacement API version, where the conversion is mechanical,
+	// eg for Ingress, PodDisruptionBudget and CronJob.
+	AutoConvert bool
+}
+
+// Finding records a single object using a deprecated or removed API version.
+type Finding struct {
+	// Object identifies the object, as kind/namespace/name
+	Object string
+
+	// GroupVersionKind is the API version used by the object in the manifest
+	GroupVersionKind schema.GroupVersionKind
+
+	// Removed is true if the API version is not served by the apiserver, so the object cannot be applied
+	Removed bool
+
+	// Deprecation is the entry in KnownDeprecations for the API version, if any
+	Deprecation *Deprecation
+
+	// Replacement is the suggested API version to use instead, if known
+	Replacement schema.GroupVersionKind
+
+	// Converted is true if the object was rewritten to the Replacement API version
+	Converted bool
+}
+
+func (f Finding) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s uses %s", f.Object, formatGVK(f.GroupVersionKind))
+	switch {
+	case f.Removed:
+		b.WriteString(", which is not served by the apiserver")
+		if f.Deprecation != nil && f.Deprecation.RemovedIn != "" {
+			fmt.Fprintf(&b, " (removed in Kubernetes %s)", f.Deprecation.RemovedIn)
+		}
+	case f.Deprecation != nil:
+		fmt.Fprintf(&b, ", which is deprecated since Kubernetes %s", f.Deprecation.DeprecatedIn)
+		if f.Deprecation.RemovedIn != "" {
+			fmt.Fprintf(&b, " and removed in %s", f.Deprecation.RemovedIn)
+		}
+	}
+	if !f.Replacement.Empty() {
+		if f.Converted {
+			fmt.Fprintf(&b, "; converted to %s, the manifest should be updated", formatGVK(f.Replacement))
+		} else {
+			fmt.Fprintf(&b, "; use %s instead", formatGVK(f.Replacement))
+		}
+	}
+	return b.String()
+}
+
+// Findings is the list of deprecated or removed API versions found in a manifest.
+type Findings []Finding
+
+// Blocking returns the findings for objects that cannot be applied, because they use an API version
+// that is not served and were not converted.
+func (f Findings) Blocking() Findings {
+	var blocking Findings
+	for _, finding := range f {
+		if finding.Removed && !finding.Converted {
+			blocking = append(blocking, finding)
+		}
+	}
+	return blocking
+}
+
+// Error formats all findings, so Findings can be returned as an error.
+func (f Findings) Error() string {
+	var messages []string
+	for _, finding := range f {
+		messages = append(messages, finding.String())
+	}
+	return fmt.Sprintf("%d object(s) use removed API versions: %s", len(f), strings.Join(messages, "; "))
+}
+
+// Check compares the API version of every object against the versions served by the apiserver, as reported by
+// restMapper, and against KnownDeprecations. If policy.AutoConvert is set, objects that can be converted are
+// replaced in objects by their converted form.
+//
+// Objects of a kind that is not served at all are not reported, unless the API version is a known deprecation,
+// because the kind may be a CRD that is installed by the same manifest. Likewise, a version that is served by a
+// CustomResourceDefinition in objects is treated as served, as the CRD is applied along with the object.
+func Check(ctx context.Context, restMapper meta.RESTMapper, objects *manifest.Objects, policy Policy) (Findings, error) {
+	log := log.FromContext(ctx)
+
+	crdVersions := servedByCRDs(objects)
+
+	var findings Findings
+	for i, obj := range objects.GetItems() {
+		gvk := obj.GroupVersionKind()
+		deprecation := Lookup(gvk)
+
+		served, err := isServed(restMapper, gvk)
+		if err != nil {
+			return nil, fmt.Errorf("checking whether %s is served: %w", formatGVK(gvk), err)
+		}
+		if !served && crdVersions[gvk] {
+			served = true
+		}
+		if served && deprecation == nil {
+			continue
+		}
+
+		finding := Finding{
+			Object:           objectID(obj),
+			GroupVersionKind: gvk,
+			Removed:          !served,
+			Deprecation:      deprecation,
+		}
+		if deprecation != nil {
+			finding.Replacement = deprecation.Replacement
+		} else {
+			preferred, err := preferredVersion(restMapper, gvk.GroupKind())
+			if err != nil {
+				return nil, fmt.Errorf("finding served versions of %s: %w", gvk.GroupKind(), err)
+			}
+			if preferred.Empty() {
+				// Not served in any version; probably a CRD that is not yet installed
+				continue
+			}
+			finding.Replacement = preferred
+		}
+
+		if policy.AutoConvert && deprecation != nil {
+			converted, err := convert(obj, deprecation)
+			if err != nil {
+				return nil, fmt.Errorf("converting %s to %s: %w", finding.Object, formatGVK(deprecation.Replacement), err)
+			}
+			if converted != nil {
+				log.WithValues("object", finding.Object).WithValues("from", formatGVK(gvk)).WithValues("to", formatGVK(deprecation.Replacement)).Info("converted object from deprecated API version")
+				objects.Items[i] = converted
+				finding.Converted = true
+			}
+		}
+
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// isServed returns true if the apiserver serves the exact group, version and kind.
+func isServed(restMapper meta.RESTMapper, gvk schema.GroupVersionKind) (bool, error) {
+	if _, err := restMapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// servedByCRDs returns the versions served by the CustomResourceDefinitions in objects.
+func servedByCRDs(objects *manifest.Objects) map[schema.GroupVersionKind]bool {
+	served := make(map[schema.GroupVersionKind]bool)
+	for _, obj := range objects.GetItems() {
+		if obj.Group != "apiextensions.k8s.io" || obj.Kind != "CustomResourceDefinition" {
+			continue
+		}
+		u := obj.UnstructuredObject().Object
+		group, _, _ := unstructured.NestedString(u, "spec", "group")
+		kind, _, _ := unstructured.NestedString(u, "spec", "names", "kind")
+		if group == "" || kind == "" {
+			continue
+		}
+
+		// apiextensions.k8s.io/v1beta1 CRDs may declare a single version, which is served
+		if version, _, _ := unstructured.NestedString(u, "spec", "version"); version != "" {
+			served[schema.GroupVersionKind{Group: group, Version: version, Kind: kind}] = true
+		}
+		versions, _, _ := unstructured.NestedSlice(u, "spec", "versions")
+		for _, v := range versions {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(m, "name")
+			if isServed, _, _ := unstructured.NestedBool(m, "served"); name != "" && isServed {
+				served[schema.GroupVersionKind{Group: group, Version: name, Kind: kind}] = true
+			}
+		}
+	}
+	return served
+}
+
+// preferredVersion returns the preferred served version of the kind, or an empty GVK if it is not served.
+func preferredVersion(restMapper meta.RESTMapper, gk schema.GroupKind) (schema.GroupVersionKind, error) {
+	mapping, err := restMapper.RESTMapping(gk)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return schema.GroupVersionKind{}, nil
+		}
+		return schema.GroupVersionKind{}, err
+	}
+	return mapping.GroupVersionKind, nil
+}
+
+func formatGVK(gvk schema.GroupVersionKind) string {
+	return gvk.GroupVersion().String() + " " + gvk.Kind
+}
+
+func objectID(obj *manifest.Object) string {
+	if ns := obj.GetNamespace(); ns != "" {
+		return obj.Kind + "/" + ns + "/" + obj.GetName()
+	}
+	return obj.Kind + "/" + obj.GetName()
+}
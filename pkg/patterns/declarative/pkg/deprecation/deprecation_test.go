@@ -0,0 +1,276 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deprecation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+const testManifest = `---
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+  namespace: default
+spec:
+  backend:
+    serviceName: default
+    servicePort: http
+  rules:
+  - host: example.com
+    http:
+      paths:
+      - path: /
+        backend:
+          serviceName: web
+          servicePort: 80
+---
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+  namespace: default
+spec:
+  minAvailable: 1
+  selector:
+    matchLabels:
+      app: web
+---
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: everything
+  namespace: default
+spec:
+  minAvailable: 1
+  selector: {}
+---
+apiVersion: autoscaling/v2beta2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: web
+  namespace: default
+---
+apiVersion: example.com/v1alpha1
+kind: Widget
+metadata:
+  name: web
+  namespace: default
+---
+apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: web
+  namespace: default
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+  namespace: default
+`
+
+// testRESTMapper serves the APIs of an apiserver from which most deprecated versions have been removed;
+// autoscaling/v2beta2 is still served, and example.com Widget is only served as v1.
+func testRESTMapper() meta.RESTMapper {
+	served := []schema.GroupVersionKind{
+		{Group: "", Version: "v1", Kind: "ConfigMap"},
+		{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+		{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"},
+		{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"},
+		{Group: "autoscaling", Version: "v2beta2", Kind: "HorizontalPodAutoscaler"},
+		{Group: "example.com", Version: "v1", Kind: "Widget"},
+	}
+
+	var groupVersions []schema.GroupVersion
+	for _, gvk := range served {
+		groupVersions = append(groupVersions, gvk.GroupVersion())
+	}
+	restMapper := meta.NewDefaultRESTMapper(groupVersions)
+	for _, gvk := range served {
+		restMapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	return restMapper
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+
+	objects, err := manifest.ParseObjects(ctx, testManifest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	findings, err := Check(ctx, testRESTMapper(), objects, Policy{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, finding := range findings {
+		got = append(got, finding.String())
+	}
+	want := []string{
+		"Ingress/default/web uses extensions/v1beta1 Ingress, which is not served by the apiserver (removed in Kubernetes v1.22); use networking.k8s.io/v1 Ingress instead",
+		"PodDisruptionBudget/default/web uses policy/v1beta1 PodDisruptionBudget, which is not served by the apiserver (removed in Kubernetes v1.25); use policy/v1 PodDisruptionBudget instead",
+		"PodDisruptionBudget/default/everything uses policy/v1beta1 PodDisruptionBudget, which is not served by the apiserver (removed in Kubernetes v1.25); use policy/v1 PodDisruptionBudget instead",
+		"HorizontalPodAutoscaler/default/web uses autoscaling/v2beta2 HorizontalPodAutoscaler, which is deprecated since Kubernetes v1.23 and removed in v1.26; use autoscaling/v2 HorizontalPodAutoscaler instead",
+		"Widget/default/web uses example.com/v1alpha1 Widget, which is not served by the apiserver; use example.com/v1 Widget instead",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("findings mismatch (-want +got):\n%s", diff)
+	}
+
+	if got, want := len(findings.Blocking()), 4; got != want {
+		t.Errorf("unexpected number of blocking findings, got %d, want %d", got, want)
+	}
+	if !strings.HasPrefix(findings.Blocking().Error(), "4 object(s) use removed API versions: ") {
+		t.Errorf("unexpected error message %q", findings.Blocking().Error())
+	}
+}
+
+func TestCheckCRDInManifest(t *testing.T) {
+	ctx := context.Background()
+
+	objects, err := manifest.ParseObjects(ctx, `---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+  - name: v2
+    served: true
+    storage: true
+  - name: v1
+    served: true
+  - name: v1alpha1
+    served: false
+---
+apiVersion: example.com/v2
+kind: Widget
+metadata:
+  name: upgraded
+  namespace: default
+---
+apiVersion: example.com/v1alpha1
+kind: Widget
+metadata:
+  name: web
+  namespace: default
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	findings, err := Check(ctx, testRESTMapper(), objects, Policy{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, finding := range findings {
+		got = append(got, finding.String())
+	}
+	want := []string{
+		"Widget/default/web uses example.com/v1alpha1 Widget, which is not served by the apiserver; use example.com/v1 Widget instead",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("findings mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckAutoConvert(t *testing.T) {
+	ctx := context.Background()
+
+	objects, err := manifest.ParseObjects(ctx, testManifest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	findings, err := Check(ctx, testRESTMapper(), objects, Policy{AutoConvert: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	converted := make(map[string]bool)
+	for _, finding := range findings {
+		converted[finding.Object] = finding.Converted
+	}
+	wantConverted := map[string]bool{
+		"Ingress/default/web":                    true,
+		"PodDisruptionBudget/default/web":        true,
+		"PodDisruptionBudget/default/everything": false,
+		"HorizontalPodAutoscaler/default/web":    false,
+		"Widget/default/web":                     false,
+	}
+	if diff := cmp.Diff(wantConverted, converted); diff != "" {
+		t.Errorf("converted mismatch (-want +got):\n%s", diff)
+	}
+
+	ingress, err := yaml.Marshal(objects.Items[0].UnstructuredObject().Object)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantIngress := `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+  namespace: default
+spec:
+  defaultBackend:
+    service:
+      name: default
+      port:
+        name: http
+  rules:
+  - host: example.com
+    http:
+      paths:
+      - backend:
+          service:
+            name: web
+            port:
+              number: 80
+        path: /
+        pathType: ImplementationSpecific
+`
+	if diff := cmp.Diff(wantIngress, string(ingress)); diff != "" {
+		t.Errorf("converted ingress mismatch (-want +got):\n%s", diff)
+	}
+
+	if got, want := objects.Items[1].GroupVersionKind(), (schema.GroupVersionKind{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"}); got != want {
+		t.Errorf("unexpected GVK for converted PodDisruptionBudget, got %v, want %v", got, want)
+	}
+	if got, want := objects.Items[2].GroupVersionKind(), (schema.GroupVersionKind{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"}); got != want {
+		t.Errorf("PodDisruptionBudget with empty selector should not be converted, got %v", got)
+	}
+}
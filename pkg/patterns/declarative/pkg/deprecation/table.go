@@ -0,0 +1,107 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deprecation
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Deprecation records a deprecated API version of a built-in kind, and the version that replaces it.
+type Deprecation struct {
+	GroupVersionKind schema.GroupVersionKind
+
+	// DeprecatedIn is the Kubernetes version in which the API version was deprecated
+	DeprecatedIn string
+	// RemovedIn is the Kubernetes version in which the API version is no longer served
+	RemovedIn string
+
+	// Replacement is the API version to migrate to; it is empty if the kind was removed without a replacement
+	Replacement schema.GroupVersionKind
+}
+
+// KnownDeprecations is the table of deprecated API versions of built-in kinds,
+// from https://kubernetes.io/docs/reference/using-api/deprecation-guide/
+var KnownDeprecations = []Deprecation{
+	// Removed in v1.16
+	deprecated("extensions", "v1beta1", "Deployment", "v1.9", "v1.16", "apps", "v1"),
+	deprecated("extensions", "v1beta1", "DaemonSet", "v1.9", "v1.16", "apps", "v1"),
+	deprecated("extensions", "v1beta1", "ReplicaSet", "v1.9", "v1.16", "apps", "v1"),
+	deprecated("extensions", "v1beta1", "NetworkPolicy", "v1.9", "v1.16", "networking.k8s.io", "v1"),
+	deprecated("extensions", "v1beta1", "PodSecurityPolicy", "v1.10", "v1.16", "policy", "v1beta1"),
+	deprecated("apps", "v1beta1", "Deployment", "v1.9", "v1.16", "apps", "v1"),
+	deprecated("apps", "v1beta1", "StatefulSet", "v1.9", "v1.16", "apps", "v1"),
+	deprecated("apps", "v1beta2", "Deployment", "v1.9", "v1.16", "apps", "v1"),
+	deprecated("apps", "v1beta2", "StatefulSet", "v1.9", "v1.16", "apps", "v1"),
+	deprecated("apps", "v1beta2", "DaemonSet", "v1.9", "v1.16", "apps", "v1"),
+	deprecated("apps", "v1beta2", "ReplicaSet", "v1.9", "v1.16", "apps", "v1"),
+
+	// Removed in v1.22
+	deprecated("extensions", "v1beta1", "Ingress", "v1.14", "v1.22", "networking.k8s.io", "v1"),
+	deprecated("networking.k8s.io", "v1beta1", "Ingress", "v1.19", "v1.22", "networking.k8s.io", "v1"),
+	deprecated("networking.k8s.io", "v1beta1", "IngressClass", "v1.19", "v1.22", "networking.k8s.io", "v1"),
+	deprecated("apiextensions.k8s.io", "v1beta1", "CustomResourceDefinition", "v1.16", "v1.22", "apiextensions.k8s.io", "v1"),
+	deprecated("admissionregistration.k8s.io", "v1beta1", "MutatingWebhookConfiguration", "v1.16", "v1.22", "admissionregistration.k8s.io", "v1"),
+	deprecated("admissionregistration.k8s.io", "v1beta1", "ValidatingWebhookConfiguration", "v1.16", "v1.22", "admissionregistration.k8s.io", "v1"),
+	deprecated("apiregistration.k8s.io", "v1beta1", "APIService", "v1.19", "v1.22", "apiregistration.k8s.io", "v1"),
+	deprecated("rbac.authorization.k8s.io", "v1beta1", "ClusterRole", "v1.17", "v1.22", "rbac.authorization.k8s.io", "v1"),
+	deprecated("rbac.authorization.k8s.io", "v1beta1", "ClusterRoleBinding", "v1.17", "v1.22", "rbac.authorization.k8s.io", "v1"),
+	deprecated("rbac.authorization.k8s.io", "v1beta1", "Role", "v1.17", "v1.22", "rbac.authorization.k8s.io", "v1"),
+	deprecated("rbac.authorization.k8s.io", "v1beta1", "RoleBinding", "v1.17", "v1.22", "rbac.authorization.k8s.io", "v1"),
+	deprecated("scheduling.k8s.io", "v1beta1", "PriorityClass", "v1.14", "v1.22", "scheduling.k8s.io", "v1"),
+	deprecated("storage.k8s.io", "v1beta1", "CSIDriver", "v1.19", "v1.22", "storage.k8s.io", "v1"),
+	deprecated("storage.k8s.io", "v1beta1", "CSINode", "v1.17", "v1.22", "storage.k8s.io", "v1"),
+	deprecated("storage.k8s.io", "v1beta1", "StorageClass", "v1.6", "v1.22", "storage.k8s.io", "v1"),
+	deprecated("storage.k8s.io", "v1beta1", "VolumeAttachment", "v1.13", "v1.22", "storage.k8s.io", "v1"),
+	deprecated("coordination.k8s.io", "v1beta1", "Lease", "v1.14", "v1.22", "coordination.k8s.io", "v1"),
+
+	// Removed in v1.25 and later
+	deprecated("policy", "v1beta1", "PodDisruptionBudget", "v1.21", "v1.25", "policy", "v1"),
+	deprecated("policy", "v1beta1", "PodSecurityPolicy", "v1.21", "v1.25", "", ""),
+	deprecated("batch", "v1beta1", "CronJob", "v1.21", "v1.25", "batch", "v1"),
+	deprecated("discovery.k8s.io", "v1beta1", "EndpointSlice", "v1.21", "v1.25", "discovery.k8s.io", "v1"),
+	deprecated("events.k8s.io", "v1beta1", "Event", "v1.19", "v1.25", "events.k8s.io", "v1"),
+	deprecated("node.k8s.io", "v1beta1", "RuntimeClass", "v1.20", "v1.25", "node.k8s.io", "v1"),
+	deprecated("autoscaling", "v2beta1", "HorizontalPodAutoscaler", "v1.22", "v1.25", "autoscaling", "v2"),
+	deprecated("autoscaling", "v2beta2", "HorizontalPodAutoscaler", "v1.23", "v1.26", "autoscaling", "v2"),
+	deprecated("storage.k8s.io", "v1beta1", "CSIStorageCapacity", "v1.24", "v1.27", "storage.k8s.io", "v1"),
+	deprecated("flowcontrol.apiserver.k8s.io", "v1beta2", "FlowSchema", "v1.26", "v1.29", "flowcontrol.apiserver.k8s.io", "v1"),
+	deprecated("flowcontrol.apiserver.k8s.io", "v1beta2", "PriorityLevelConfiguration", "v1.26", "v1.29", "flowcontrol.apiserver.k8s.io", "v1"),
+	deprecated("flowcontrol.apiserver.k8s.io", "v1beta3", "FlowSchema", "v1.29", "v1.32", "flowcontrol.apiserver.k8s.io", "v1"),
+	deprecated("flowcontrol.apiserver.k8s.io", "v1beta3", "PriorityLevelConfiguration", "v1.29", "v1.32", "flowcontrol.apiserver.k8s.io", "v1"),
+}
+
+// Lookup returns the entry in KnownDeprecations for gvk, or nil if the API version is not known to be deprecated.
+func Lookup(gvk schema.GroupVersionKind) *Deprecation {
+	for i := range KnownDeprecations {
+		if KnownDeprecations[i].GroupVersionKind == gvk {
+			return &KnownDeprecations[i]
+		}
+	}
+	return nil
+}
+
+func deprecated(group, version, kind, deprecatedIn, removedIn, replacementGroup, replacementVersion string) Deprecation {
+	d := Deprecation{
+		GroupVersionKind: schema.GroupVersionKind{Group: group, Version: version, Kind: kind},
+		DeprecatedIn:     deprecatedIn,
+		RemovedIn:        removedIn,
+	}
+	if replacementVersion != "" {
+		d.Replacement = schema.GroupVersionKind{Group: replacementGroup, Version: replacementVersion, Kind: kind}
+	}
+	return d
+}
@@ -165,6 +165,8 @@ func (o *Object) podSpecPath() []string {
 	switch o.object.GetKind() {
 	case "CronJob":
 		return []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	case "Pod":
+		return []string{"spec"}
 	default: // Default to try the path used by common types such as Deployment, StatefulSet, etc.
 		return []string{"spec", "template", "spec"}
 	}
@@ -250,6 +252,14 @@ func (o *Object) MutatePodSpec(fn func(map[string]interface{}) error) error {
 	return err
 }
 
+// PodSpec returns a copy of the pod spec of a workload object, and whether it was found
+func (o *Object) PodSpec() (map[string]interface{}, bool, error) {
+	if o.object.Object == nil {
+		return nil, false, nil
+	}
+	return unstructured.NestedMap(o.object.Object, o.podSpecPath()...)
+}
+
 func (o *Object) NestedStringMap(fields ...string) (map[string]string, bool, error) {
 	if o.object.Object == nil {
 		o.object.Object = make(map[string]interface{})
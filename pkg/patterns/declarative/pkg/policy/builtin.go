@@ -0,0 +1,150 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+// NoPrivilegedContainers rejects containers with securityContext.privileged set.
+func NoPrivilegedContainers() Rule {
+	return RuleFunc("no-privileged-containers", func(ctx context.Context, obj *manifest.Object) ([]string, error) {
+		return forEachContainer(obj, func(containerType, name string, container map[string]interface{}) (string, error) {
+			privileged, _, err := unstructured.NestedBool(container, "securityContext", "privileged")
+			if err != nil {
+				return "", err
+			}
+			if privileged {
+				return fmt.Sprintf("%s %q is privileged", containerType, name), nil
+			}
+			return "", nil
+		})
+	})
+}
+
+// RequireResourceLimits rejects containers that do not set cpu and memory limits.
+// Ephemeral containers are not checked, as they cannot set resources.
+func RequireResourceLimits() Rule {
+	return RuleFunc("require-resource-limits", func(ctx context.Context, obj *manifest.Object) ([]string, error) {
+		return forEachContainer(obj, func(containerType, name string, container map[string]interface{}) (string, error) {
+			if containerType == "ephemeralContainers" {
+				return "", nil
+			}
+			limits, _, err := unstructured.NestedMap(container, "resources", "limits")
+			if err != nil {
+				return "", err
+			}
+			var missing []string
+			for _, resource := range []string{"cpu", "memory"} {
+				if _, found := limits[resource]; !found {
+					missing = append(missing, resource)
+				}
+			}
+			if len(missing) != 0 {
+				return fmt.Sprintf("%s %q does not set %s limits", containerType, name, strings.Join(missing, ", ")), nil
+			}
+			return "", nil
+		})
+	})
+}
+
+// NoHostPath rejects pods that mount hostPath volumes.
+func NoHostPath() Rule {
+	return RuleFunc("no-host-path", func(ctx context.Context, obj *manifest.Object) ([]string, error) {
+		podSpec, found, err := obj.PodSpec()
+		if err != nil || !found {
+			return nil, err
+		}
+		volumes, _, err := unstructured.NestedSlice(podSpec, "volumes")
+		if err != nil {
+			return nil, err
+		}
+		var messages []string
+		for _, v := range volumes {
+			volume, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("volume was not an object")
+			}
+			if _, found := volume["hostPath"]; found {
+				messages = append(messages, fmt.Sprintf("volume %q uses hostPath", volume["name"]))
+			}
+		}
+		return messages, nil
+	})
+}
+
+// AllowedRegistries rejects containers whose image does not start with one of the registries,
+// eg "registry.k8s.io" or "mirror.corp/k8s". Registries are matched up to a path boundary,
+// so "registry.k8s.io" does not allow "registry.k8s.io.example.com/pause".
+func AllowedRegistries(registries ...string) Rule {
+	prefixes := make([]string, 0, len(registries))
+	for _, registry := range registries {
+		prefixes = append(prefixes, strings.TrimSuffix(registry, "/")+"/")
+	}
+	return RuleFunc("allowed-registries", func(ctx context.Context, obj *manifest.Object) ([]string, error) {
+		return forEachContainer(obj, func(containerType, name string, container map[string]interface{}) (string, error) {
+			image, _, err := unstructured.NestedString(container, "image")
+			if err != nil {
+				return "", err
+			}
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(image, prefix) {
+					return "", nil
+				}
+			}
+			return fmt.Sprintf("%s %q uses image %q which is not from an allowed registry", containerType, name, image), nil
+		})
+	})
+}
+
+// forEachContainer calls fn for every container, initContainer and ephemeralContainer in obj,
+// collecting the non-empty messages
+func forEachContainer(obj *manifest.Object, fn func(containerType, name string, container map[string]interface{}) (string, error)) ([]string, error) {
+	podSpec, found, err := obj.PodSpec()
+	if err != nil || !found {
+		return nil, err
+	}
+
+	var messages []string
+	for _, containerType := range []string{"containers", "initContainers", "ephemeralContainers"} {
+		containers, _, err := unstructured.NestedSlice(podSpec, containerType)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("container was not an object")
+			}
+			name, _, _ := unstructured.NestedString(container, "name")
+			message, err := fn(containerType, name, container)
+			if err != nil {
+				return nil, err
+			}
+			if message != "" {
+				messages = append(messages, message)
+			}
+		}
+	}
+	return messages, nil
+}
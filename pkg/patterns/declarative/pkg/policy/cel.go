@@ -0,0 +1,84 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+// CELRule is a Rule defined by a CEL expression.
+//
+// Like a ValidatingAdmissionPolicy, the expression has access to the rendered object as `object`
+// and must evaluate to true for the object to be compliant, for example:
+//
+//	object.kind != 'Service' || object.spec.type != 'LoadBalancer'
+type CELRule struct {
+	name    string
+	message string
+	program cel.Program
+}
+
+var _ Rule = &CELRule{}
+
+// NewCELRule compiles expression into a Rule; message is reported for objects that do not satisfy it.
+func NewCELRule(name, expression, message string) (*CELRule, error) {
+	env, err := cel.NewEnv(cel.Variable("object", cel.DynType))
+	if err != nil {
+		return nil, fmt.Errorf("creating CEL environment: %w", err)
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("compiling CEL expression for policy %q: %w", name, issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("CEL expression for policy %q must evaluate to a bool, got %v", name, ast.OutputType())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("building CEL program for policy %q: %w", name, err)
+	}
+	if message == "" {
+		message = fmt.Sprintf("failed expression: %s", expression)
+	}
+	return &CELRule{name: name, message: message, program: program}, nil
+}
+
+func (r *CELRule) Name() string {
+	return r.name
+}
+
+func (r *CELRule) Evaluate(ctx context.Context, obj *manifest.Object) ([]string, error) {
+	out, _, err := r.program.ContextEval(ctx, map[string]interface{}{
+		"object": obj.UnstructuredObject().Object,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Type() != types.BoolType {
+		return nil, fmt.Errorf("CEL expression evaluated to %v, not bool", out.Type())
+	}
+	if out == types.True {
+		return nil, nil
+	}
+	return []string{r.message}, nil
+}
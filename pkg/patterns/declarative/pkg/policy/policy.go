@@ -0,0 +1,105 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy evaluates rendered manifest objects against a set of rules before they are applied.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+// Rule is a policy that is evaluated against each rendered object.
+type Rule interface {
+	// Name identifies the rule in violations
+	Name() string
+
+	// Evaluate returns a message for each way in which obj violates the rule.
+	// An error is returned only if the rule could not be evaluated.
+	Evaluate(ctx context.Context, obj *manifest.Object) ([]string, error)
+}
+
+// Violation records a single object failing a single rule.
+type Violation struct {
+	Rule    string
+	Object  string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s: %s", v.Rule, v.Object, v.Message)
+}
+
+// Violations is the list of violations found in a manifest.
+type Violations []Violation
+
+// Error formats all violations, so Violations can be returned as an error.
+func (v Violations) Error() string {
+	var messages []string
+	for _, violation := range v {
+		messages = append(messages, violation.String())
+	}
+	return fmt.Sprintf("%d policy violation(s): %s", len(v), strings.Join(messages, "; "))
+}
+
+// Evaluate runs every rule against every object, returning all violations found.
+func Evaluate(ctx context.Context, rules []Rule, objects *manifest.Objects) (Violations, error) {
+	var violations Violations
+	for _, obj := range objects.Items {
+		for _, rule := range rules {
+			messages, err := rule.Evaluate(ctx, obj)
+			if err != nil {
+				return nil, fmt.Errorf("evaluating policy %q on %s: %w", rule.Name(), objectID(obj), err)
+			}
+			for _, message := range messages {
+				violations = append(violations, Violation{
+					Rule:    rule.Name(),
+					Object:  objectID(obj),
+					Message: message,
+				})
+			}
+		}
+	}
+	return violations, nil
+}
+
+// RuleFunc adapts a function to the Rule interface.
+func RuleFunc(name string, fn func(ctx context.Context, obj *manifest.Object) ([]string, error)) Rule {
+	return &ruleFunc{name: name, fn: fn}
+}
+
+type ruleFunc struct {
+	name string
+	fn   func(ctx context.Context, obj *manifest.Object) ([]string, error)
+}
+
+func (r *ruleFunc) Name() string {
+	return r.name
+}
+
+func (r *ruleFunc) Evaluate(ctx context.Context, obj *manifest.Object) ([]string, error) {
+	return r.fn(ctx, obj)
+}
+
+func objectID(obj *manifest.Object) string {
+	if ns := obj.GetNamespace(); ns != "" {
+		return obj.Kind + "/" + ns + "/" + obj.GetName()
+	}
+	return obj.Kind + "/" + obj.GetName()
+}
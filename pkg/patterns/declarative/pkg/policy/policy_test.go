@@ -0,0 +1,155 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+const testManifest = `---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+  namespace: kube-system
+spec:
+  template:
+    spec:
+      volumes:
+      - name: host
+        hostPath:
+          path: /var/log
+      - name: config
+        configMap:
+          name: agent
+      initContainers:
+      - name: setup
+        image: docker.io/library/busybox
+        securityContext:
+          privileged: true
+      containers:
+      - name: agent
+        image: registry.k8s.io/agent:v1
+        resources:
+          limits:
+            cpu: 100m
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: agent
+  namespace: kube-system
+spec:
+  type: LoadBalancer
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: agent
+  namespace: kube-system
+`
+
+func TestEvaluate(t *testing.T) {
+	ctx := context.Background()
+
+	noLoadBalancers, err := NewCELRule("no-load-balancers",
+		"object.kind != 'Service' || !has(object.spec.type) || object.spec.type != 'LoadBalancer'",
+		"LoadBalancer services are not allowed")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	objects, err := manifest.ParseObjects(ctx, testManifest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	violations, err := Evaluate(ctx, []Rule{
+		NoPrivilegedContainers(),
+		RequireResourceLimits(),
+		NoHostPath(),
+		AllowedRegistries("registry.k8s.io/"),
+		noLoadBalancers,
+	}, objects)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := Violations{
+		{Rule: "no-privileged-containers", Object: "DaemonSet/kube-system/agent", Message: `initContainers "setup" is privileged`},
+		{Rule: "require-resource-limits", Object: "DaemonSet/kube-system/agent", Message: `containers "agent" does not set memory limits`},
+		{Rule: "require-resource-limits", Object: "DaemonSet/kube-system/agent", Message: `initContainers "setup" does not set cpu, memory limits`},
+		{Rule: "no-host-path", Object: "DaemonSet/kube-system/agent", Message: `volume "host" uses hostPath`},
+		{Rule: "allowed-registries", Object: "DaemonSet/kube-system/agent", Message: `initContainers "setup" uses image "docker.io/library/busybox" which is not from an allowed registry`},
+		{Rule: "no-load-balancers", Object: "Service/kube-system/agent", Message: "LoadBalancer services are not allowed"},
+	}
+	if diff := cmp.Diff(want, violations); diff != "" {
+		t.Errorf("violations mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewCELRule(t *testing.T) {
+	if _, err := NewCELRule("invalid", "object.", ""); err == nil {
+		t.Errorf("expected error compiling invalid expression")
+	}
+	if _, err := NewCELRule("not-bool", "'hello'", ""); err == nil {
+		t.Errorf("expected error for non-bool expression")
+	}
+}
+
+func TestAllowedRegistries(t *testing.T) {
+	ctx := context.Background()
+
+	objects, err := manifest.ParseObjects(ctx, `---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+  namespace: default
+spec:
+  containers:
+  - name: allowed
+    image: registry.k8s.io/pause:3.9
+  - name: lookalike
+    image: registry.k8s.io.evil.example/pause:3.9
+  - name: mirror
+    image: mirror.corp/k8s/pause:3.9
+  - name: mirror-lookalike
+    image: mirror.corp/k8s-evil/pause:3.9
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	violations, err := Evaluate(ctx, []Rule{AllowedRegistries("registry.k8s.io", "mirror.corp/k8s/")}, objects)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := Violations{
+		{Rule: "allowed-registries", Object: "Pod/default/app", Message: `containers "lookalike" uses image "registry.k8s.io.evil.example/pause:3.9" which is not from an allowed registry`},
+		{Rule: "allowed-registries", Object: "Pod/default/app", Message: `containers "mirror-lookalike" uses image "mirror.corp/k8s-evil/pause:3.9" which is not from an allowed registry`},
+	}
+	if diff := cmp.Diff(want, violations); diff != "" {
+		t.Errorf("violations mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -42,11 +42,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/kustomize/kyaml/filesys"
 
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/applyset"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/commonclient"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/utils"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/kustomize"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/applier"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/deprecation"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/policy"
 )
 
 var _ reconcile.Reconciler = &Reconciler{}
@@ -130,7 +133,14 @@ func (r *Reconciler) InitWithName(mgr manager.Manager, prototype DeclarativeObje
 	r.mgr = mgr
 	globalObjectTracker.mgr = mgr
 
-	d, err := dynamic.NewForConfigAndClient(r.restConfig, r.httpClient)
+	// The dynamic client records the warnings returned by the apiserver for each apply, through the context
+	dynamicHTTPClient := *r.httpClient
+	transport := dynamicHTTPClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	dynamicHTTPClient.Transport = applyset.WrapTransportForWarnings(transport)
+	d, err := dynamic.NewForConfigAndClient(r.restConfig, &dynamicHTTPClient)
 	if err != nil {
 		return err
 	}
@@ -164,7 +174,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 
 	log := log.FromContext(ctx)
 	defer func() {
-		r.collectMetrics(request, result, statusInfo.Err)
+		r.collectMetrics(request, result, statusInfo)
 	}()
 
 	// Fetch the object
@@ -295,6 +305,16 @@ func (r *Reconciler) reconcileExists(ctx context.Context, name types.NamespacedN
 		Subject: instance,
 	}
 
+	ctx, resolution := withResolutionRecorder(ctx)
+	ctx, warnings := withWarningCollector(ctx)
+	defer func() {
+		statusInfo.Resolution = resolution.resolution
+		statusInfo.Warnings = warnings.Warnings()
+		for _, warning := range statusInfo.Warnings {
+			r.recorder.Event(instance, "Warning", "ReconcileWarning", warning)
+		}
+	}()
+
 	var fs filesys.FileSystem
 	if r.IsKustomizeOptionUsed() {
 		fs = filesys.MakeFsInMemory()
@@ -329,6 +349,21 @@ func (r *Reconciler) reconcileExists(ctx context.Context, name types.NamespacedN
 		}
 	}
 
+	if r.options.deprecationPolicy != nil {
+		findings, err := deprecation.Check(ctx, r.restMapper, objects, *r.options.deprecationPolicy)
+		if err != nil {
+			return statusInfo, fmt.Errorf("error checking for deprecated APIs: %w", err)
+		}
+		for _, finding := range findings {
+			RecordWarning(ctx, "%s", finding.String())
+		}
+		if blocking := findings.Blocking(); len(blocking) != 0 {
+			log.Error(blocking, "objects use removed API versions, not applying")
+			statusInfo.KnownError = KnownErrorRemovedAPI
+			return statusInfo, blocking
+		}
+	}
+
 	err = r.setNamespaces(ctx, instance, objects)
 	if err != nil {
 		return statusInfo, err
@@ -358,6 +393,24 @@ func (r *Reconciler) reconcileExists(ctx context.Context, name types.NamespacedN
 	}
 	objects.Items = newItems
 
+	if len(r.options.policyRules) != 0 {
+		violations, err := policy.Evaluate(ctx, r.options.policyRules, objects)
+		if err != nil {
+			return statusInfo, fmt.Errorf("error evaluating policies: %w", err)
+		}
+		if len(violations) != 0 {
+			if r.options.policyMode == PolicyModeWarn {
+				for _, violation := range violations {
+					r.recorder.Event(instance, "Warning", "PolicyViolation", violation.String())
+				}
+			} else {
+				log.Error(violations, "policy violations, not applying")
+				statusInfo.KnownError = KnownErrorPolicyViolation
+				return statusInfo, violations
+			}
+		}
+	}
+
 	extraArgs := []string{}
 
 	// allow user disable prune in CR
@@ -411,9 +464,14 @@ func (r *Reconciler) reconcileExists(ctx context.Context, name types.NamespacedN
 		ParentRef:         parentRef,
 		Objects:           objects.GetItems(),
 		Validate:          r.options.validate,
+		FieldValidation:   r.options.fieldValidation,
 		ExtraArgs:         extraArgs,
-		Force:             true,
+		Force:             r.options.conflictPolicy == "" || r.options.conflictPolicy == applyset.ConflictPolicyForce,
+		ConflictPolicy:    r.options.conflictPolicy,
+		RecreatePolicy:    r.options.recreatePolicy,
+		RetryPolicy:       r.options.retryPolicy,
 		CascadingStrategy: r.options.cascadingStrategy,
+		PrunePolicy:       r.options.prunePolicy,
 		Client:            r.client,
 		DynamicClient:     r.dynamicClient,
 	}
@@ -424,7 +482,7 @@ func (r *Reconciler) reconcileExists(ctx context.Context, name types.NamespacedN
 		ApplierOptions: &applierOpt,
 	}
 
-	applier := r.options.applier
+	resourceApplier := r.options.applier
 	for _, hook := range r.options.hooks {
 		if beforeApply, ok := hook.(BeforeApply); ok {
 			if err := beforeApply.BeforeApply(ctx, applyOperation); err != nil {
@@ -434,10 +492,33 @@ func (r *Reconciler) reconcileExists(ctx context.Context, name types.NamespacedN
 		}
 	}
 
-	if err := applier.Apply(ctx, applierOpt); err != nil {
-		log.Error(err, "applying manifest")
-		statusInfo.KnownError = KnownErrorApplyFailed
-		return statusInfo, fmt.Errorf("error applying manifest: %v", err)
+	var applyErr error
+	if withResults, ok := resourceApplier.(applier.ApplierWithResults); ok {
+		applyOperation.Results, applyErr = withResults.ApplyWithResults(ctx, applierOpt)
+		statusInfo.ApplyResults = applyOperation.Results
+	} else {
+		applyErr = resourceApplier.Apply(ctx, applierOpt)
+	}
+	recordApplyWarnings(ctx, statusInfo.ApplyResults)
+	if statusInfo.ApplyResults != nil {
+		for _, obj := range statusInfo.ApplyResults.Objects {
+			if obj.Apply.Operation == applyset.OperationRecreated {
+				r.recorder.Eventf(instance, "Normal", "Recreated", "%s %s %s", obj.GVK.Kind, obj.NameNamespace, obj.Apply.Message)
+			}
+		}
+	}
+	if applyErr != nil {
+		log.Error(applyErr, "applying manifest")
+		var pruneBlocked *applyset.PruneBlockedError
+		if errors.As(applyErr, &pruneBlocked) {
+			statusInfo.KnownError = KnownErrorPruneBlocked
+		} else {
+			statusInfo.KnownError = KnownErrorApplyFailed
+		}
+		return statusInfo, fmt.Errorf("error applying manifest: %v", applyErr)
+	}
+	if statusInfo.ApplyResults != nil && statusInfo.ApplyResults.AdoptedCount() != 0 {
+		r.recorder.Eventf(instance, "Normal", "Adopted", "adopted %d objects into the applyset", statusInfo.ApplyResults.AdoptedCount())
 	}
 
 	statusInfo.LiveObjects = func(ctx context.Context, gvk schema.GroupVersionKind, nn types.NamespacedName) (*unstructured.Unstructured, error) {
@@ -606,11 +687,22 @@ func (r *Reconciler) transformManifest(ctx context.Context, instance Declarative
 			return err
 		}
 	}
+	// ImageLock objects are consumed by ImageTransform, if configured, and must not be applied
+	removeImageLocks(objects)
 	return nil
 }
 
 // loadRawManifest loads the raw manifest YAML from the repository
 func (r *Reconciler) loadRawManifest(ctx context.Context, o DeclarativeObject) (map[string]string, error) {
+	if resolver, ok := r.options.manifestController.(ManifestResolver); ok {
+		s, resolution, err := resolver.ResolveManifestWithMetadata(ctx, o)
+		if err != nil {
+			return nil, err
+		}
+		recordResolution(ctx, resolution)
+		return s, nil
+	}
+
 	s, err := r.options.manifestController.ResolveManifest(ctx, o)
 	if err != nil {
 		return nil, err
@@ -762,10 +854,13 @@ func (r *Reconciler) injectOwnerRef(ctx context.Context, instance DeclarativeObj
 	return nil
 }
 
-func (r *Reconciler) collectMetrics(request reconcile.Request, result reconcile.Result, err error) {
+func (r *Reconciler) collectMetrics(request reconcile.Request, result reconcile.Result, statusInfo *StatusInfo) {
 	if r.options.metrics {
 		r.metrics.reconcileWith(request)
-		r.metrics.reconcileFailedWith(request, result, err)
+		r.metrics.reconcileFailedWith(request, result, statusInfo.Err)
+		if statusInfo.ApplyResults != nil {
+			r.metrics.applyRetriedWith(request, statusInfo.ApplyResults.RetryCount())
+		}
 	}
 }
 
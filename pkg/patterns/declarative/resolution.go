@@ -0,0 +1,86 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package declarative
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ManifestResolution describes how the version of a manifest was chosen
+type ManifestResolution struct {
+	// Version is the version that was resolved, and that is being applied
+	Version string
+
+	// Channel is the channel the version was resolved from, if any
+	Channel string
+
+	// Revision is the revision of the repository the manifest was loaded from, eg a git commit SHA
+	// or OCI artifact digest, if the repository reports one
+	Revision string
+
+	// Variant is the flavor of the version that is being applied, if any
+	Variant string
+
+	// LatestVersion is the latest version in the channel that is compatible with the operator and cluster
+	LatestVersion string
+
+	// AvailableVersion is the next version the addon can be upgraded to, if any;
+	// it is held back by the upgrade policy, or will be applied on a later reconcile.
+	AvailableVersion string
+
+	// Pinned is true if the version was specified explicitly, or the upgrade policy pins the deployed version
+	Pinned bool
+
+	// AwaitingApproval is true if AvailableVersion is held back until it is approved
+	AwaitingApproval bool
+
+	// Packages are the packages of a composite addon and the versions they were resolved to
+	Packages []PackageResolution
+}
+
+// PackageResolution is the version a package of a composite addon was resolved to
+type PackageResolution struct {
+	Name    string
+	Version string
+}
+
+// ManifestResolver is implemented by ManifestControllers that also report how the manifest version was chosen.
+// The resolution is passed to Status in StatusInfo.Resolution.
+type ManifestResolver interface {
+	ResolveManifestWithMetadata(ctx context.Context, object runtime.Object) (map[string]string, *ManifestResolution, error)
+}
+
+type resolutionKey struct{}
+
+// resolutionRecorder holds the resolution of the manifest during a single reconcile
+type resolutionRecorder struct {
+	resolution *ManifestResolution
+}
+
+// withResolutionRecorder returns a context in which loadRawManifest records the resolution
+func withResolutionRecorder(ctx context.Context) (context.Context, *resolutionRecorder) {
+	r := &resolutionRecorder{}
+	return context.WithValue(ctx, resolutionKey{}, r), r
+}
+
+func recordResolution(ctx context.Context, resolution *ManifestResolution) {
+	if r, ok := ctx.Value(resolutionKey{}).(*resolutionRecorder); ok {
+		r.resolution = resolution
+	}
+}
@@ -14,7 +14,10 @@
 
 package declarative
 
-import "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+import (
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/applyset"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
 
 type StatusInfo struct {
 	Subject DeclarativeObject
@@ -25,6 +28,15 @@ type StatusInfo struct {
 	LiveObjects LiveObjectReader
 	KnownError  KnownErrorCode
 	Err         error
+
+	// Warnings are non-fatal problems encountered during reconciliation, recorded with RecordWarning
+	Warnings []string
+
+	// Resolution describes how the manifest version was chosen, if the ManifestController is a ManifestResolver
+	Resolution *ManifestResolution
+
+	// ApplyResults holds the result of applying each object, if the applier reports them
+	ApplyResults *applyset.ApplyResults
 }
 
 type KnownErrorCode string
@@ -32,4 +44,9 @@ type KnownErrorCode string
 const (
 	KnownErrorApplyFailed        KnownErrorCode = "FailedToApply"
 	KnownErrorVersionCheckFailed KnownErrorCode = "VersionCheckFailed"
+	KnownErrorPolicyViolation    KnownErrorCode = "PolicyViolation"
+	// KnownErrorPruneBlocked is reported when the objects were applied, but pruning was blocked by the PrunePolicy
+	KnownErrorPruneBlocked KnownErrorCode = "PruneBlocked"
+	// KnownErrorRemovedAPI is reported when objects use API versions that are no longer served, see WithDeprecatedAPICheck
+	KnownErrorRemovedAPI KnownErrorCode = "RemovedAPI"
 )
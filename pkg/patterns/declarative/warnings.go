@@ -0,0 +1,85 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package declarative
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/applylib/applyset"
+)
+
+type warningsKey struct{}
+
+// warningCollector accumulates the warnings recorded during a single reconcile
+type warningCollector struct {
+	mutex    sync.Mutex
+	warnings []string
+}
+
+// withWarningCollector returns a context in which RecordWarning collects warnings
+func withWarningCollector(ctx context.Context) (context.Context, *warningCollector) {
+	c := &warningCollector{}
+	return context.WithValue(ctx, warningsKey{}, c), c
+}
+
+// RecordWarning records a non-fatal problem encountered during reconciliation,
+// for example a manifest that was served from a stale cache.
+// Warnings are recorded as events on the DeclarativeObject and are available to Status in StatusInfo.Warnings.
+// If ctx was not created by the Reconciler, the warning is only logged.
+func RecordWarning(ctx context.Context, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.FromContext(ctx).Info("warning: " + msg)
+
+	c, ok := ctx.Value(warningsKey{}).(*warningCollector)
+	if !ok {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, w := range c.warnings {
+		if w == msg {
+			return
+		}
+	}
+	c.warnings = append(c.warnings, msg)
+}
+
+// Warnings returns the warnings recorded so far
+func (c *warningCollector) Warnings() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]string(nil), c.warnings...)
+}
+
+// recordApplyWarnings records a warning for each warning returned by the apiserver during the apply,
+// and for each field that conflicted with another field manager
+func recordApplyWarnings(ctx context.Context, results *applyset.ApplyResults) {
+	if results == nil {
+		return
+	}
+	for _, obj := range results.Objects {
+		for _, warning := range obj.Apply.Warnings {
+			RecordWarning(ctx, "%s %s: %s", obj.GVK.Kind, obj.NameNamespace, warning)
+		}
+		for _, conflict := range obj.Apply.Conflicts {
+			RecordWarning(ctx, "field %s of %s %s is also managed by %q", conflict.Field, obj.GVK.Kind, obj.NameNamespace, conflict.Manager)
+		}
+	}
+}
@@ -42,6 +42,18 @@ type hookableReconciler interface {
 	AddHook(hook Hook)
 }
 
+// ManifestSourceWatcher is implemented by ManifestControllers whose manifests are stored in the cluster,
+// eg in ConfigMaps. WatchChildren calls WatchManifestSource so that changes to the manifests
+// reconcile the objects that use them.
+type ManifestSourceWatcher interface {
+	WatchManifestSource(mgr ctrl.Manager, c controller.Controller, prototype DeclarativeObject) error
+}
+
+// manifestSourceWatchable is implemented by Reconciler, and by types that embed it
+type manifestSourceWatchable interface {
+	watchManifestSource(c controller.Controller) error
+}
+
 type DynamicWatch interface {
 	// Add registers a watch for changes to 'trigger' filtered by 'options' to raise an event on 'target'.
 	// If namespace is specified, the watch will be restricted to that namespace.
@@ -150,6 +162,24 @@ func WatchChildren(options WatchChildrenOptions) error {
 
 	options.Reconciler.AddHook(afterApplyHook)
 
+	if r, ok := options.Reconciler.(manifestSourceWatchable); ok {
+		if err := r.watchManifestSource(options.Controller); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// watchManifestSource watches the source of the manifests, if the ManifestController supports it
+func (r *Reconciler) watchManifestSource(c controller.Controller) error {
+	watcher, ok := r.options.manifestController.(ManifestSourceWatcher)
+	if !ok || r.mgr == nil {
+		return nil
+	}
+	if err := watcher.WatchManifestSource(r.mgr, c, r.prototype); err != nil {
+		return fmt.Errorf("watching manifest source: %w", err)
+	}
 	return nil
 }
 
@@ -87,7 +87,7 @@ func (in *SimpleTestList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SimpleTestSpec) DeepCopyInto(out *SimpleTestSpec) {
 	*out = *in
-	out.CommonSpec = in.CommonSpec
+	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
 	in.PatchSpec.DeepCopyInto(&out.PatchSpec)
 }
 